@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,18 +12,28 @@ import (
 	"time"
 
 	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/logging"
 	"github.com/robaa12/gatway-service/internal/routes"
+	"github.com/robaa12/gatway-service/internal/tracing"
 )
 
 type Application struct {
 	config     *config.Config
 	httpServer *http.Server
+	routes     *routes.Reloader
+	// shutdownTracing flushes spans that are still buffered
+	shutdownTracing func(context.Context) error
 }
 
 func main() {
+	if err := logging.Setup("gateway-service"); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
 	}
 
 	// App instance
@@ -34,7 +44,7 @@ func main() {
 	// Setup services
 	err = app.setupServices()
 	if err != nil {
-		log.Fatalf("Failed to setup services: %v", err)
+		fatal("Failed to setup services", err)
 	}
 
 	// Start server
@@ -42,17 +52,34 @@ func main() {
 		app.startServer()
 	}()
 
+	// Reload routes on SIGHUP or when the config file changes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.watchReloadSignal(ctx)
+	go app.routes.Watch(ctx, app.config.File.Path, app.config.File.WatchInterval)
+	// Retry failed compensations and roll back store creations interrupted by a restart
+	go app.routes.ResumeSagas(ctx, app.config.Saga.RetryInterval)
+
 	// Wait for shutdown signal
 	app.waitForShutdown()
 }
 
 func (app *Application) setupServices() error {
+	shutdownTracing, err := tracing.Init(context.Background(), "gateway-service", app.config.Server.Version)
+	if err != nil {
+		return err
+	}
+	app.shutdownTracing = shutdownTracing
 
-	routerManager := routes.NewRouter(app.config)
+	reloader, err := routes.NewReloader(app.config, config.Load)
+	if err != nil {
+		return err
+	}
+	app.routes = reloader
 
 	app.httpServer = &http.Server{
 		Addr:         fmt.Sprintf("%s:%s", app.config.Server.Host, app.config.Server.Port),
-		Handler:      routerManager.Router,
+		Handler:      reloader,
 		IdleTimeout:  15 * time.Second,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -61,10 +88,28 @@ func (app *Application) setupServices() error {
 }
 
 func (app *Application) startServer() {
-	log.Printf("Starting server on %s:%s\n", app.config.Server.Host, app.config.Server.Port)
+	slog.Info("Starting server", "host", app.config.Server.Host, "port", app.config.Server.Port)
 	err := app.httpServer.ListenAndServe()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("Server failed to start: %v", err)
+		fatal("Server failed to start", err)
+	}
+}
+
+func (app *Application) watchReloadSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("Received SIGHUP, reloading gateway config")
+			if err := app.routes.Reload(); err != nil {
+				slog.Error("Gateway config reload failed, keeping previous routes", "error", err)
+			}
+		}
 	}
 }
 
@@ -75,17 +120,26 @@ func (app *Application) waitForShutdown() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		sig := <-quit
-		log.Printf("Received shutdown signal: %v\n", sig)
+		slog.Info("Received shutdown signal", "signal", sig.String())
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		log.Println("Shutting down server...")
+		slog.Info("Shutting down server")
 		if err := app.httpServer.Shutdown(ctx); err != nil {
-			log.Printf("Server forced to shutdown: %v\n", err)
+			slog.Error("Server forced to shutdown", "error", err)
+		}
+		app.routes.Close()
+		if err := app.shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
 		}
 		done <- true
 	}()
 	<-done
-	log.Println("Server exited properly")
+	slog.Info("Server exited properly")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,120 @@
+// Package access defines the staff roles a store can hand out, the API key scopes it can issue, and
+// the permissions each one grants.
+package access
+
+import "strings"
+
+// Permissions are named "<resource>.<action>" and are what routes.json asks for with
+// "permission:<name>".
+const (
+	StoreDelete    = "store.delete"
+	StaffRead      = "staff.read"
+	StaffWrite     = "staff.write"
+	APIKeysRead    = "api_keys.read"
+	APIKeysWrite   = "api_keys.write"
+	ProductsWrite  = "products.write"
+	ProductsDelete = "products.delete"
+	InventoryWrite = "inventory.write"
+	OrdersRead     = "orders.read"
+	OrdersWrite    = "orders.write"
+	CustomersRead  = "customers.read"
+	CustomersWrite = "customers.write"
+	ReviewsRead    = "reviews.read"
+	ReviewsWrite   = "reviews.write"
+	DashboardRead  = "dashboard.read"
+)
+
+// Roles, from most to least privileged. The user who created a store is always its owner; the
+// owner role can also be given to staff to share full control.
+const (
+	RoleOwner            = "owner"
+	RoleAdmin            = "admin"
+	RoleInventoryManager = "inventory_manager"
+	RoleOrderFulfiller   = "order_fulfiller"
+	RoleReadOnly         = "read_only"
+)
+
+var permissions = []string{
+	StoreDelete, StaffRead, StaffWrite, APIKeysRead, APIKeysWrite,
+	ProductsWrite, ProductsDelete, InventoryWrite,
+	OrdersRead, OrdersWrite,
+	CustomersRead, CustomersWrite,
+	ReviewsRead, ReviewsWrite,
+	DashboardRead,
+}
+
+var readPermissions = []string{StaffRead, OrdersRead, CustomersRead, ReviewsRead, DashboardRead}
+
+var rolePermissions = map[string]map[string]bool{
+	RoleOwner: set(permissions...),
+	RoleAdmin: set(
+		StaffRead, StaffWrite, APIKeysRead, APIKeysWrite,
+		ProductsWrite, ProductsDelete, InventoryWrite,
+		OrdersRead, OrdersWrite,
+		CustomersRead, CustomersWrite,
+		ReviewsRead, ReviewsWrite,
+		DashboardRead,
+	),
+	RoleInventoryManager: set(ProductsWrite, InventoryWrite, OrdersRead, ReviewsRead, DashboardRead),
+	RoleOrderFulfiller:   set(OrdersRead, OrdersWrite, CustomersRead),
+	RoleReadOnly:         set(readPermissions...),
+}
+
+// IsPermission reports whether name is a known permission.
+func IsPermission(name string) bool {
+	return rolePermissions[RoleOwner][name]
+}
+
+// IsRole reports whether name is a known role.
+func IsRole(name string) bool {
+	_, ok := rolePermissions[name]
+	return ok
+}
+
+// Allows reports whether role grants permission. Unknown roles grant nothing.
+func Allows(role, permission string) bool {
+	return rolePermissions[role][permission]
+}
+
+// API key scopes are permissions written "<resource>:<action>"; orders:write grants orders.write.
+// Keys can never manage the store itself, its staff or other keys.
+var scopes = set(
+	"products:write", "products:delete", "inventory:write",
+	"orders:read", "orders:write",
+	"customers:read", "customers:write",
+	"reviews:read", "reviews:write",
+	"dashboard:read",
+)
+
+// IsScope reports whether name is a scope an API key may be given.
+func IsScope(name string) bool {
+	return scopes[name]
+}
+
+// ScopePermission returns the permission a scope grants.
+func ScopePermission(scope string) string {
+	return strings.Replace(scope, ":", ".", 1)
+}
+
+// PermissionScope returns the scope that grants permission.
+func PermissionScope(permission string) string {
+	return strings.Replace(permission, ".", ":", 1)
+}
+
+// ScopesAllow reports whether any of the scopes grants permission.
+func ScopesAllow(keyScopes []string, permission string) bool {
+	for _, scope := range keyScopes {
+		if scopes[scope] && ScopePermission(scope) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
@@ -0,0 +1,78 @@
+package access
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{RoleOwner, StoreDelete, true},
+		{RoleAdmin, StoreDelete, false},
+		{RoleAdmin, APIKeysWrite, true},
+		{RoleInventoryManager, InventoryWrite, true},
+		{RoleInventoryManager, OrdersWrite, false},
+		{RoleOrderFulfiller, OrdersWrite, true},
+		{RoleOrderFulfiller, ProductsWrite, false},
+		{RoleReadOnly, DashboardRead, true},
+		{RoleReadOnly, ReviewsWrite, false},
+		{"manager", OrdersRead, false},
+		{RoleOwner, "store.rename", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.permission, func(t *testing.T) {
+			if got := Allows(tt.role, tt.permission); got != tt.want {
+				t.Fatalf("Allows(%q, %q) = %t, want %t", tt.role, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnerHoldsEveryPermission(t *testing.T) {
+	for role, granted := range rolePermissions {
+		for permission := range granted {
+			if !IsPermission(permission) {
+				t.Errorf("role %s grants unknown permission %s", role, permission)
+			}
+			if !Allows(RoleOwner, permission) {
+				t.Errorf("role %s grants %s, which the owner lacks", role, permission)
+			}
+		}
+	}
+}
+
+func TestScopesAllow(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		permission string
+		want       bool
+	}{
+		{"matching scope", []string{"orders:read", "orders:write"}, OrdersWrite, true},
+		{"read does not grant write", []string{"orders:read"}, OrdersWrite, false},
+		{"no scopes", nil, OrdersRead, false},
+		{"keys cannot manage staff", []string{"staff:write"}, StaffWrite, false},
+		{"keys cannot delete the store", []string{"store:delete"}, StoreDelete, false},
+		{"permission spelling is not a scope", []string{"orders.read"}, OrdersRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopesAllow(tt.scopes, tt.permission); got != tt.want {
+				t.Fatalf("ScopesAllow(%v, %q) = %t, want %t", tt.scopes, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesMapToPermissions(t *testing.T) {
+	for scope := range scopes {
+		permission := ScopePermission(scope)
+		if !IsPermission(permission) {
+			t.Errorf("scope %s grants unknown permission %s", scope, permission)
+		}
+		if PermissionScope(permission) != scope {
+			t.Errorf("PermissionScope(%s) = %s, want %s", permission, PermissionScope(permission), scope)
+		}
+	}
+}
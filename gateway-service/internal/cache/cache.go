@@ -0,0 +1,140 @@
+// Package cache keeps the responses of routes with a "cache:<ttl>" middleware in process memory,
+// so reads repeated within the TTL are answered without calling the service again.
+package cache
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/httputil"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+)
+
+// HeaderCache tells the client whether a response was served from the cache.
+const HeaderCache = "X-Cache"
+
+const (
+	// maxEntries bounds the responses kept; once full, new ones are not kept until some expire
+	maxEntries = 10000
+	// maxBody bounds the size of a kept response; larger ones are sent but not kept
+	maxBody       = 1 << 20
+	sweepInterval = time.Minute
+)
+
+// Cache keeps successful GET and HEAD responses per caller, so one user is never sent what
+// another was. Each replica has its own cache, which is kept across config reloads, and entries are
+// only dropped when they expire.
+type Cache struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+func New() *Cache {
+	return &Cache{
+		entries:   make(map[string]entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Middleware serves responses kept for ttl. It runs after authentication, so responses are kept
+// per user, per API key, or shared by anonymous callers. Requests sent with Cache-Control no-cache
+// skip the cache, and responses other than a 200, marked no-store or setting cookies are not kept.
+func (c *Cache) Middleware(ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if directive(r.Header, "no-cache") || directive(r.Header, "no-store") {
+				w.Header().Set(HeaderCache, "BYPASS")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := auth.CallerScope(r.Context()) + " " + r.Method + " " + r.URL.RequestURI()
+			if e, ok := c.get(key); ok {
+				for name, values := range e.header {
+					w.Header()[name] = slices.Clone(values)
+				}
+				w.Header().Set(HeaderCache, "HIT")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(e.body)
+				return
+			}
+
+			w.Header().Set(HeaderCache, "MISS")
+			before := w.Header().Clone()
+			rec := httputil.NewRecorder(w, maxBody)
+			next.ServeHTTP(rec, r)
+
+			header := rec.SentHeader()
+			if rec.Status() != http.StatusOK || rec.Overflowed() || directive(header, "no-store") ||
+				header.Get("Set-Cookie") != "" {
+				return
+			}
+			c.put(key, entry{
+				header:    httputil.AddedHeaders(before, header, HeaderCache),
+				body:      rec.Body(),
+				expiresAt: time.Now().Add(ttl),
+			})
+		})
+	}
+}
+
+func (c *Cache) get(key string) (entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !e.expiresAt.After(time.Now()) {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (c *Cache) put(key string, e entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(time.Now())
+	if len(c.entries) >= maxEntries {
+		return
+	}
+	c.entries[key] = e
+}
+
+// sweep drops expired responses; callers hold c.mu.
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < sweepInterval && len(c.entries) < maxEntries {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if !e.expiresAt.After(now) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// directive reports whether the Cache-Control header holds the given directive.
+func directive(header http.Header, name string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
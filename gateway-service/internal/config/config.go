@@ -1,108 +1,446 @@
 package config
 
 import (
-	"embed"
-	"encoding/json"
-	"log"
+	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type RouteConfig struct {
-	Path        string   `json:"path"`
-	Methods     []string `json:"methods"`
-	Service     string   `json:"service"`
-	Middlewares []string `json:"middlewares"`
+	Path        string   `json:"path" yaml:"path"`
+	Methods     []string `json:"methods" yaml:"methods"`
+	Service     string   `json:"service" yaml:"service"`
+	Middlewares []string `json:"middlewares" yaml:"middlewares"`
+	// Split sends part of the route's traffic to other versions of its service.
+	Split *SplitConfig `json:"split" yaml:"split"`
+	// Mirror copies the route's requests to another service, such as a staging copy.
+	Mirror *MirrorConfig `json:"mirror" yaml:"mirror"`
+	// Prefix is put in front of Path where the route is exposed, such as "/v1". The service still
+	// gets Path, or Rewrite if it is set.
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// Rewrite is the path sent to the service instead of Path. Its {params} are filled in from the
+	// request's, e.g. "/api/v2/stores/{store_id}/items".
+	Rewrite string `json:"rewrite" yaml:"rewrite"`
+	// Deprecation marks the route as being phased out in favour of a newer version.
+	Deprecation *DeprecationConfig `json:"deprecation" yaml:"deprecation"`
+}
+
+// PublicPath is the path the route is exposed on.
+func (r RouteConfig) PublicPath() string {
+	return r.Prefix + r.Path
+}
+
+// UpstreamPath is the path the route's requests are sent to its service on.
+func (r RouteConfig) UpstreamPath() string {
+	if r.Rewrite != "" {
+		return r.Rewrite
+	}
+	return r.Path
+}
+
+// DeprecationConfig is announced on every response of a deprecated route with the Deprecation
+// header (RFC 9745) and, once a removal date is set, the Sunset header (RFC 8594). Since and
+// Sunset are dates, "2006-01-02" or RFC 3339; Link points to documentation about the change and
+// Successor to the route's replacement.
+type DeprecationConfig struct {
+	Since     string `json:"since" yaml:"since"`
+	Sunset    string `json:"sunset" yaml:"sunset"`
+	Link      string `json:"link" yaml:"link"`
+	Successor string `json:"successor" yaml:"successor"`
+}
+
+// MirrorConfig copies a sample of a route's requests to Service in the background; its responses
+// are discarded. Only GET and HEAD requests are copied unless AllMethods is set, and requests with
+// bodies larger than MaxBody, "1MB" by default, are not copied at all.
+type MirrorConfig struct {
+	Service    string  `json:"service" yaml:"service"`
+	SampleRate float64 `json:"sample_rate" yaml:"sample_rate"`
+	AllMethods bool    `json:"all_methods" yaml:"all_methods"`
+	MaxBody    string  `json:"max_body" yaml:"max_body"`
+}
+
+// SplitConfig spreads a route's requests over several versions of its service, each configured as
+// a service of its own. A request matching one of Pins goes to that pin's service; the others are
+// spread over Backends by weight, or all go to the route's service if there are none.
+type SplitConfig struct {
+	Backends []SplitBackend `json:"backends" yaml:"backends"`
+	Pins     []SplitPin     `json:"pins" yaml:"pins"`
+}
+
+type SplitBackend struct {
+	Service string `json:"service" yaml:"service"`
+	Weight  int    `json:"weight" yaml:"weight"`
+}
+
+// SplitPin sends the requests with Header or Cookie set to Value, or those for one of StoreIDs by
+// their {store_id}, to Service. Exactly one of Header, Cookie and StoreIDs is set.
+type SplitPin struct {
+	Service  string `json:"service" yaml:"service"`
+	Header   string `json:"header" yaml:"header"`
+	Cookie   string `json:"cookie" yaml:"cookie"`
+	Value    string `json:"value" yaml:"value"`
+	StoreIDs []int  `json:"store_ids" yaml:"store_ids"`
 }
 
 type Config struct {
-	Server    ServerConfig
-	Services  map[string]ServiceConfig
-	Routes    []RouteConfig
-	Auth      AuthConfig
-	RateLimit RateLimitConfig
+	Server      ServerConfig
+	Services    map[string]ServiceConfig
+	Routes      []RouteConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	CORS        CORSConfig
+	Saga        SagaConfig
+	Idempotency IdempotencyConfig
+	Login       LoginConfig
+	Storefront  StorefrontConfig
+	Admin       AdminConfig
+	File        FileSourceConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Host string
+	Port             string
+	Host             string
+	Version          string
+	ReadinessTimeout time.Duration
+	// Environment comes from APP_ENV and defaults to "production"; anything but "development" gets
+	// production safety checks.
+	Environment string
+	// TrustedProxies are the load balancers and proxies in front of the gateway, from
+	// TRUSTED_PROXIES. Only requests coming from them may say which client they are for with
+	// X-Forwarded-For or X-Real-IP.
+	TrustedProxies []netip.Prefix
 }
 
+// ServiceConfig describes one upstream service. URL is the first of Instances and is what the
+// gateway's own handlers call; proxied traffic is balanced across every healthy instance.
+// The gateway reports not ready while a Required service is down.
 type ServiceConfig struct {
-	URL     string
-	Timeout time.Duration
+	URL           string
+	Instances     []string
+	Required      bool
+	LoadBalancing string
+	HealthCheck   HealthCheckConfig
+	Timeout       time.Duration
+	Retries       int
+	Breaker       BreakerConfig
+}
+
+// HealthCheckConfig controls active probing of service instances. An instance is ejected after
+// UnhealthyThreshold failed probes in a row and re-admitted after HealthyThreshold successful
+// ones. Probing is disabled when Path is empty.
+type HealthCheckConfig struct {
+	Path               string
+	Interval           time.Duration
+	Timeout            time.Duration
+	UnhealthyThreshold int
+	HealthyThreshold   int
+}
+
+// BreakerConfig controls the per-service circuit breaker: it opens after Failures consecutive
+// failed calls and lets a single probe through once OpenFor has elapsed.
+type BreakerConfig struct {
+	Failures int
+	OpenFor  time.Duration
 }
 
+const (
+	AuthStoreMemory   = "memory"
+	AuthStorePostgres = "postgres"
+
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+
+	// DefaultJWTSecret only exists so the gateway runs out of the box in development
+	DefaultJWTSecret = "Messi is better than Ronaldo"
+	// DefaultInternalSecret is the development key for identity headers sent to the backend
+	// services; they fall back to the same value when INTERNAL_AUTH_SECRET is not set.
+	DefaultInternalSecret = "internal-dev-secret"
+
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
 type AuthConfig struct {
-	JWTSecret       string
-	AccessTokenExp  time.Duration
-	RefreshTokenExp time.Duration
+	// Algorithm is HS256 with JWTSecret, or RS256/EdDSA with a PEM private key in SigningKeyFile.
+	// VerificationKeyFiles hold the public keys of previous signing keys that are still accepted,
+	// each as "path" or "kid=path". A key signed under an explicit SigningKeyID must be listed with
+	// that kid once it is rotated out, or the tokens it signed will no longer verify.
+	Algorithm            string
+	SigningKeyFile       string
+	SigningKeyID         string
+	VerificationKeyFiles []string
+	JWTSecret            string
+	AccessTokenExp       time.Duration
+	RefreshTokenExp      time.Duration
+	// Store selects where revoked tokens and store staff are kept: "memory" or "postgres". It is
+	// read once at startup; reloading the config file does not switch stores.
+	Store       string
+	DatabaseURL string
+	// InternalSecret signs the identity headers the gateway adds to requests for the backend
+	// services. It is read once at startup.
+	InternalSecret string
+	// StoreSlugTTL is how long the store ID behind a slug is cached for ownership checks on
+	// routes that address a store by its slug.
+	StoreSlugTTL time.Duration
+}
+
+// SagaConfig controls the log that store creation sagas are recorded in. Store is "memory" or
+// "postgres" and defaults to the auth store. Compensations that fail are retried every
+// RetryInterval, up to MaxAttempts times per step; a saga left running for StaleAfter is assumed to
+// belong to a gateway that crashed and is rolled back. StaleAfter must outlast a call to any core
+// service, or a saga could be taken over while its gateway is still waiting on one.
+type SagaConfig struct {
+	Store         string
+	DatabaseURL   string
+	RetryInterval time.Duration
+	MaxAttempts   int
+	StaleAfter    time.Duration
+}
+
+// IdempotencyConfig controls how responses to requests with an Idempotency-Key header are kept.
+// Store is "memory" or "postgres" and defaults to the auth store. A response is replayed for TTL
+// after it was first sent; a retry that arrives while the first request is still running waits up
+// to Wait for it before getting a 409.
+type IdempotencyConfig struct {
+	Store       string
+	DatabaseURL string
+	TTL         time.Duration
+	Wait        time.Duration
+}
+
+// LoginConfig slows down password guessing. Once an email has MaxAttempts failed logins from one IP
+// address, AccountMaxAttempts from all addresses together, or an IP address IPMaxAttempts in all,
+// each further attempt has to wait Backoff, doubled with every failure up to Lockout. Failures are
+// forgotten FailureWindow after the last one. Suspicious logins are recorded in an audit log kept
+// for AuditRetention.
+type LoginConfig struct {
+	MaxAttempts        int
+	AccountMaxAttempts int
+	IPMaxAttempts      int
+	Backoff            time.Duration
+	Lockout            time.Duration
+	FailureWindow      time.Duration
+	AuditRetention     time.Duration
+}
+
+// StorefrontConfig controls the pages the gateway composes for storefronts. Each section of a page
+// has SectionTimeout to load before it is left out, and the home page lists HomeProducts products.
+type StorefrontConfig struct {
+	SectionTimeout time.Duration
+	HomeProducts   int
+}
+
+// AdminConfig protects the /admin endpoints with Token and /metrics with MetricsToken, from
+// ADMIN_API_TOKEN and METRICS_TOKEN. Each is disabled while its token is empty. The services read
+// the same METRICS_TOKEN, so one Prometheus credential scrapes them all without admin rights.
+type AdminConfig struct {
+	Token        string
+	MetricsToken string
 }
 
 type RateLimitConfig struct {
 	MaxRequests int
 	Duration    time.Duration
+	Policies    map[string]RateLimitPolicy
+}
+
+// RateLimitPolicy is a named token bucket that routes reference as "rate-limit:<name>".
+// Requests tokens are refilled every Per, Burst caps how many can be spent at once and Key
+// selects what a bucket is shared by: "ip", "user" or "store".
+type RateLimitPolicy struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+	Key      string
+}
+
+// CORSConfig holds the CORS policies routes reference as "cors:<name>". Routes that reference none
+// get the gateway's default policy.
+type CORSConfig struct {
+	Policies map[string]CORSPolicy
 }
 
-//go:embed routes.json
-var routesFile embed.FS
+// CORSPolicy says which browser origins may call a route, with which methods and headers, and
+// for how long browsers may cache the answer to a preflight request.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// FileSourceConfig tells the gateway where its route table lives and how often to check it for changes.
+type FileSourceConfig struct {
+	Path          string
+	WatchInterval time.Duration
+}
 
-func LoadRoutesConfig() ([]RouteConfig, error) {
-	// Load routes from embedded JSON config
-	data, err := routesFile.ReadFile("routes.json")
+func Load() (*Config, error) {
+	fileSource := FileSourceConfig{
+		Path:          getEnv("GATEWAY_CONFIG_FILE", "config/routes.json"),
+		WatchInterval: getDurationEnv("GATEWAY_CONFIG_WATCH_INTERVAL", 5*time.Second),
+	}
+	file, err := LoadFile(fileSource.Path)
 	if err != nil {
-		log.Println("Error loading route config:", err)
 		return nil, err
 	}
-
-	var routes []RouteConfig
-	if err := json.Unmarshal(data, &routes); err != nil {
-		log.Println("Error parsing route config:", err)
+	services, err := file.ServiceConfigs()
+	if err != nil {
 		return nil, err
 	}
-
-	return routes, nil
-}
-func Load() (*Config, error) {
-	routes, err := LoadRoutesConfig()
+	policies, err := file.RateLimitPolicies()
 	if err != nil {
-		log.Println("Failed Loading Config")
 		return nil, err
 	}
+	corsPolicies, err := file.CORSPolicies()
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		services = defaultServices()
+	}
+	trustedProxies, err := parsePrefixes(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	authStore := getEnv("AUTH_STORE", AuthStoreMemory)
+	databaseURL := getEnv("GATEWAY_DATABASE_URL", "")
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
-		},
-		Services: map[string]ServiceConfig{ // Changed ServicesConfig to map
-			"user-service": {
-				URL:     getEnv("USER_SERVICE_URL", "http://localhost:3000"),
-				Timeout: getDurationEnv("USER_SERVICE_TIMEOUT", 5*time.Second),
-			},
-			"order-service": {
-				URL:     getEnv("ORDER_SERVICE_URL", "http://localhost:8082"),
-				Timeout: getDurationEnv("ORDER_SERVICE_TIMEOUT", 5*time.Second),
-			},
-			"product-service": {
-				URL:     getEnv("PRODUCT_SERVICE_URL", "http://localhost:8083"),
-				Timeout: getDurationEnv("PRODUCT_SERVICE_TIMEOUT", 5*time.Second),
-			},
+			Port:             getEnv("SERVER_PORT", "8080"),
+			Host:             getEnv("SERVER_HOST", "localhost"),
+			Version:          getEnv("SERVICE_VERSION", "dev"),
+			ReadinessTimeout: getDurationEnv("READINESS_TIMEOUT", 2*time.Second),
+			Environment:      getEnv("APP_ENV", EnvironmentProduction),
+			TrustedProxies:   trustedProxies,
 		},
+		Services: services,
 
 		Auth: AuthConfig{
-			JWTSecret:       getEnv("JWT_SECRET", "Messi is better than Ronaldo"),
-			AccessTokenExp:  getDurationEnv("JWT_ACCESS_EXPIRATION", 24*time.Hour),
-			RefreshTokenExp: getDurationEnv("JWT_REFRESH_EXPIRATION", 7*24*time.Hour),
+			JWTSecret:            getEnv("JWT_SECRET", DefaultJWTSecret),
+			Algorithm:            getEnv("JWT_ALGORITHM", JWTAlgorithmHS256),
+			SigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+			SigningKeyID:         getEnv("JWT_SIGNING_KEY_ID", ""),
+			VerificationKeyFiles: splitList(getEnv("JWT_VERIFICATION_KEY_FILES", "")),
+			AccessTokenExp:       getDurationEnv("JWT_ACCESS_EXPIRATION", 24*time.Hour),
+			RefreshTokenExp:      getDurationEnv("JWT_REFRESH_EXPIRATION", 7*24*time.Hour),
+			Store:                authStore,
+			DatabaseURL:          databaseURL,
+			InternalSecret:       getEnv("INTERNAL_AUTH_SECRET", DefaultInternalSecret),
+			StoreSlugTTL:         getDurationEnv("STORE_SLUG_CACHE_TTL", 5*time.Minute),
 		},
 		RateLimit: RateLimitConfig{
 			MaxRequests: getEnvInt("RATE_LIMIT_MAX_REQUESTS", 100),
 			Duration:    getDurationEnv("RATE_LIMIT_DURATION", 1*time.Minute),
+			Policies:    policies,
+		},
+		CORS: CORSConfig{
+			Policies: corsPolicies,
+		},
+		Saga: SagaConfig{
+			Store:         getEnv("SAGA_STORE", authStore),
+			DatabaseURL:   databaseURL,
+			RetryInterval: getDurationEnv("SAGA_RETRY_INTERVAL", 30*time.Second),
+			MaxAttempts:   getEnvInt("SAGA_MAX_ATTEMPTS", 10),
+			StaleAfter:    getDurationEnv("SAGA_STALE_AFTER", 2*time.Minute),
 		},
-		Routes: routes,
+		Idempotency: IdempotencyConfig{
+			Store:       getEnv("IDEMPOTENCY_STORE", authStore),
+			DatabaseURL: databaseURL,
+			TTL:         getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+			Wait:        getDurationEnv("IDEMPOTENCY_WAIT", 10*time.Second),
+		},
+		Login: LoginConfig{
+			MaxAttempts:        getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+			AccountMaxAttempts: getEnvInt("LOGIN_ACCOUNT_MAX_ATTEMPTS", 20),
+			IPMaxAttempts:      getEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+			Backoff:            getDurationEnv("LOGIN_BACKOFF", time.Second),
+			Lockout:            getDurationEnv("LOGIN_LOCKOUT", 15*time.Minute),
+			FailureWindow:      getDurationEnv("LOGIN_FAILURE_WINDOW", time.Hour),
+			AuditRetention:     getDurationEnv("LOGIN_AUDIT_RETENTION", 30*24*time.Hour),
+		},
+		Storefront: StorefrontConfig{
+			SectionTimeout: getDurationEnv("STOREFRONT_SECTION_TIMEOUT", 2*time.Second),
+			HomeProducts:   getEnvInt("STOREFRONT_HOME_PRODUCTS", 12),
+		},
+		Admin: AdminConfig{
+			Token:        getEnv("ADMIN_API_TOKEN", ""),
+			MetricsToken: getEnv("METRICS_TOKEN", ""),
+		},
+		Routes: file.Routes,
+		File:   fileSource,
 	}, nil
 }
 
+// defaultServices is used when the config file does not declare any services.
+func defaultServices() map[string]ServiceConfig {
+	return map[string]ServiceConfig{
+		"user-service": newServiceConfig(
+			splitList(getEnv("USER_SERVICE_URL", "http://localhost:3000")),
+			getDurationEnv("USER_SERVICE_TIMEOUT", 5*time.Second),
+		),
+		"order-service": newServiceConfig(
+			splitList(getEnv("ORDER_SERVICE_URL", "http://localhost:8082")),
+			getDurationEnv("ORDER_SERVICE_TIMEOUT", 5*time.Second),
+		),
+		"product-service": newServiceConfig(
+			splitList(getEnv("PRODUCT_SERVICE_URL", "http://localhost:8083")),
+			getDurationEnv("PRODUCT_SERVICE_TIMEOUT", 5*time.Second),
+		),
+	}
+}
+
+// newServiceConfig returns a service config with the default balancing, retry and breaker settings.
+func newServiceConfig(instances []string, timeout time.Duration) ServiceConfig {
+	return ServiceConfig{
+		URL:           instances[0],
+		Instances:     instances,
+		Required:      true,
+		LoadBalancing: LoadBalancingRoundRobin,
+		Timeout:       timeout,
+		Retries:       defaultServiceRetries,
+		Breaker:       defaultBreaker,
+	}
+}
+
+// splitList splits a comma separated value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePrefixes reads a comma separated list of CIDRs; single addresses are taken as one host.
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range splitList(value) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
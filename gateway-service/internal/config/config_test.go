@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "10.0.0.0/8, 192.168.1.7", want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.7/32")}},
+		{value: "172.16.5.9/12", want: []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12")}},
+		{value: "fd00::/8,::ffff:10.1.2.3", want: []netip.Prefix{netip.MustParsePrefix("fd00::/8"), netip.MustParsePrefix("10.1.2.3/32")}},
+		{value: "10.0.0.0/33", wantErr: true},
+		{value: "proxy.internal", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePrefixes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrefixes error = %v, want error %t", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("parsePrefixes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultsToProduction(t *testing.T) {
+	t.Setenv("GATEWAY_CONFIG_FILE", "../../config/routes.json")
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Environment != EnvironmentProduction {
+		t.Fatalf("Environment = %q, want %q", cfg.Server.Environment, EnvironmentProduction)
+	}
+	if err := cfg.Validate(middlewareNames); err == nil || !strings.Contains(err.Error(), "JWT_SECRET must be set") {
+		t.Fatalf("Validate = %v, want the default JWT secret refused", err)
+	}
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultServiceTimeout = 5 * time.Second
+	defaultServiceRetries = 2
+	defaultCORSMaxAge     = 5 * time.Minute
+)
+
+const (
+	LoadBalancingRoundRobin       = "round-robin"
+	LoadBalancingLeastConnections = "least-connections"
+)
+
+var defaultBreaker = BreakerConfig{
+	Failures: 5,
+	OpenFor:  30 * time.Second,
+}
+
+var defaultHealthCheck = HealthCheckConfig{
+	Interval:           10 * time.Second,
+	Timeout:            2 * time.Second,
+	UnhealthyThreshold: 2,
+	HealthyThreshold:   1,
+}
+
+// FileConfig is the on-disk gateway configuration: upstream services and the route table.
+type FileConfig struct {
+	Services   map[string]ServiceFileConfig   `json:"services" yaml:"services"`
+	RateLimits map[string]RateLimitFileConfig `json:"rate_limits" yaml:"rate_limits"`
+	CORS       map[string]CORSFileConfig      `json:"cors_policies" yaml:"cors_policies"`
+	Routes     []RouteConfig                  `json:"routes" yaml:"routes"`
+}
+
+// ServiceFileConfig declares a service by a single url, a list of instances, or both.
+type ServiceFileConfig struct {
+	URL            string                 `json:"url" yaml:"url"`
+	Instances      []string               `json:"instances" yaml:"instances"`
+	Required       *bool                  `json:"required" yaml:"required"`
+	LoadBalancing  string                 `json:"load_balancing" yaml:"load_balancing"`
+	HealthCheck    *HealthCheckFileConfig `json:"health_check" yaml:"health_check"`
+	Timeout        string                 `json:"timeout" yaml:"timeout"`
+	Retries        *int                   `json:"retries" yaml:"retries"`
+	CircuitBreaker *BreakerFileConfig     `json:"circuit_breaker" yaml:"circuit_breaker"`
+}
+
+type HealthCheckFileConfig struct {
+	Path               string `json:"path" yaml:"path"`
+	Interval           string `json:"interval" yaml:"interval"`
+	Timeout            string `json:"timeout" yaml:"timeout"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold" yaml:"unhealthy_threshold"`
+	HealthyThreshold   int    `json:"healthy_threshold" yaml:"healthy_threshold"`
+}
+
+type BreakerFileConfig struct {
+	Failures int    `json:"failures" yaml:"failures"`
+	OpenFor  string `json:"open_for" yaml:"open_for"`
+}
+
+type RateLimitFileConfig struct {
+	Requests int    `json:"requests" yaml:"requests"`
+	Per      string `json:"per" yaml:"per"`
+	Burst    int    `json:"burst" yaml:"burst"`
+	Key      string `json:"key" yaml:"key"`
+}
+
+type CORSFileConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins" yaml:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods" yaml:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers" yaml:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers" yaml:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           string   `json:"max_age" yaml:"max_age"`
+}
+
+// envPattern matches ${VAR} and ${VAR:-default} references inside the config file.
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// LoadFile reads a JSON or YAML gateway config file, picking the format from the file extension.
+// Environment references are expanded before parsing. A file holding only a JSON array is treated
+// as a bare route list.
+func LoadFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	data = expandEnv(data)
+
+	var file FileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	default:
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(data, &file.Routes); err != nil {
+				return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+			}
+			break
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	return &file, nil
+}
+
+// ServiceConfigs converts the file's service entries into runtime service configs.
+func (f *FileConfig) ServiceConfigs() (map[string]ServiceConfig, error) {
+	services := make(map[string]ServiceConfig, len(f.Services))
+	for name, svc := range f.Services {
+		serviceConfig, err := svc.toServiceConfig()
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", name, err)
+		}
+		services[name] = serviceConfig
+	}
+	return services, nil
+}
+
+func (svc ServiceFileConfig) toServiceConfig() (ServiceConfig, error) {
+	// Each instance entry may hold a comma separated list so a single ${VAR} can expand to many
+	var instances []string
+	for _, entry := range append([]string{svc.URL}, svc.Instances...) {
+		instances = append(instances, splitList(entry)...)
+	}
+	if len(instances) == 0 {
+		return ServiceConfig{}, fmt.Errorf("no url or instances configured")
+	}
+
+	timeout, err := parseDuration(svc.Timeout, defaultServiceTimeout)
+	if err != nil {
+		return ServiceConfig{}, fmt.Errorf("invalid timeout: %w", err)
+	}
+	serviceConfig := newServiceConfig(instances, timeout)
+
+	if svc.LoadBalancing != "" {
+		serviceConfig.LoadBalancing = svc.LoadBalancing
+	}
+	if svc.Required != nil {
+		serviceConfig.Required = *svc.Required
+	}
+	if svc.Retries != nil {
+		serviceConfig.Retries = *svc.Retries
+	}
+	if cb := svc.CircuitBreaker; cb != nil {
+		if cb.Failures != 0 {
+			serviceConfig.Breaker.Failures = cb.Failures
+		}
+		if serviceConfig.Breaker.OpenFor, err = parseDuration(cb.OpenFor, defaultBreaker.OpenFor); err != nil {
+			return ServiceConfig{}, fmt.Errorf("invalid circuit breaker open_for: %w", err)
+		}
+	}
+	if hc := svc.HealthCheck; hc != nil {
+		serviceConfig.HealthCheck = defaultHealthCheck
+		serviceConfig.HealthCheck.Path = hc.Path
+		if hc.UnhealthyThreshold != 0 {
+			serviceConfig.HealthCheck.UnhealthyThreshold = hc.UnhealthyThreshold
+		}
+		if hc.HealthyThreshold != 0 {
+			serviceConfig.HealthCheck.HealthyThreshold = hc.HealthyThreshold
+		}
+		if serviceConfig.HealthCheck.Interval, err = parseDuration(hc.Interval, defaultHealthCheck.Interval); err != nil {
+			return ServiceConfig{}, fmt.Errorf("invalid health check interval: %w", err)
+		}
+		if serviceConfig.HealthCheck.Timeout, err = parseDuration(hc.Timeout, defaultHealthCheck.Timeout); err != nil {
+			return ServiceConfig{}, fmt.Errorf("invalid health check timeout: %w", err)
+		}
+	}
+
+	return serviceConfig, nil
+}
+
+// RateLimitPolicies converts the file's rate limit entries into runtime policies. Burst defaults
+// to Requests and Key defaults to "ip".
+func (f *FileConfig) RateLimitPolicies() (map[string]RateLimitPolicy, error) {
+	policies := make(map[string]RateLimitPolicy, len(f.RateLimits))
+	for name, rl := range f.RateLimits {
+		per, err := time.ParseDuration(rl.Per)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: invalid period %q: %w", name, rl.Per, err)
+		}
+		policy := RateLimitPolicy{
+			Requests: rl.Requests,
+			Per:      per,
+			Burst:    rl.Burst,
+			Key:      rl.Key,
+		}
+		if policy.Burst == 0 {
+			policy.Burst = policy.Requests
+		}
+		if policy.Key == "" {
+			policy.Key = "ip"
+		}
+		policies[name] = policy
+	}
+	return policies, nil
+}
+
+// CORSPolicies converts the file's CORS entries into runtime policies. MaxAge defaults to five
+// minutes.
+func (f *FileConfig) CORSPolicies() (map[string]CORSPolicy, error) {
+	policies := make(map[string]CORSPolicy, len(f.CORS))
+	for name, c := range f.CORS {
+		maxAge, err := parseDuration(c.MaxAge, defaultCORSMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("cors policy %q: invalid max_age %q: %w", name, c.MaxAge, err)
+		}
+		policies[name] = CORSPolicy{
+			AllowedOrigins:   c.AllowedOrigins,
+			AllowedMethods:   c.AllowedMethods,
+			AllowedHeaders:   c.AllowedHeaders,
+			ExposedHeaders:   c.ExposedHeaders,
+			AllowCredentials: c.AllowCredentials,
+			MaxAge:           maxAge,
+		}
+	}
+	return policies, nil
+}
+
+// parseDuration parses value, returning def when value is empty.
+func parseDuration(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func expandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		parts := envPattern.FindSubmatch(match)
+		if value := os.Getenv(string(parts[1])); value != "" {
+			return []byte(value)
+		}
+		return parts[2]
+	})
+}
+
+// sizeUnits are the suffixes a size may have, longest first so "KB" is not read as "B".
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize reads a size in bytes, with an optional B, KB, MB or GB suffix.
+func ParseSize(value string) (int64, error) {
+	number, unit := strings.ToUpper(strings.TrimSpace(value)), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number, unit = strings.TrimSpace(strings.TrimSuffix(number, u.suffix)), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * unit, nil
+}
+
+// ParseDate reads a date given as "2006-01-02", meaning midnight UTC, or as RFC 3339.
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/access"
+)
+
+var allowedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+var rateLimitKeys = map[string]bool{
+	"ip":    true,
+	"user":  true,
+	"store": true,
+}
+
+// coreServices are called directly by the gateway's own handlers, so they must always be configured.
+var coreServices = []string{"user-service", "product-service", "order-service"}
+
+// CoreRoute is a request the gateway answers itself, as a saga over several services, rather than
+// proxying it to Service. Path is both where it is exposed and where it reaches Service.
+type CoreRoute struct {
+	Method  string
+	Path    string
+	Service string
+}
+
+// CoreRoutes are the store creation and deletion, which must never reach user-service directly.
+var CoreRoutes = []CoreRoute{
+	{Method: http.MethodPost, Path: "/store", Service: "user-service"},
+	{Method: http.MethodDelete, Path: "/store/{store_id}", Service: "user-service"},
+}
+
+// CoreRoute returns the core route that method on r would reach if it were proxied, matching the
+// service and path it is sent to rather than where it is exposed.
+func (r RouteConfig) CoreRoute(method string) (CoreRoute, bool) {
+	for _, core := range CoreRoutes {
+		if core.Method == method && core.Service == r.Service && samePattern(core.Path, r.UpstreamPath()) {
+			return core, true
+		}
+	}
+	return CoreRoute{}, false
+}
+
+// samePattern reports whether two route patterns match the same paths, whatever their parameters
+// are called.
+func samePattern(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if strings.HasPrefix(as[i], "{") && strings.HasPrefix(bs[i], "{") {
+			continue
+		}
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CoreServiceTimeout is the longest timeout of the core services, which bounds every call the
+// gateway's own handlers and sagas make to them.
+func (c *Config) CoreServiceTimeout() time.Duration {
+	var longest time.Duration
+	for _, name := range coreServices {
+		longest = max(longest, c.Services[name].Timeout)
+	}
+	return longest
+}
+
+// Validate checks the services and route table, reporting every problem found rather than stopping
+// at the first one. middlewares lists the middleware names the router knows how to build.
+func (c *Config) Validate(middlewares []string) error {
+	var errs []error
+
+	for _, name := range coreServices {
+		if _, exists := c.Services[name]; !exists {
+			errs = append(errs, fmt.Errorf("missing required service %q", name))
+		}
+	}
+	for name, svc := range c.Services {
+		if len(svc.Instances) == 0 {
+			errs = append(errs, fmt.Errorf("service %q: no instances configured", name))
+		}
+		for _, instance := range svc.Instances {
+			target, err := url.Parse(instance)
+			if err != nil || target.Scheme == "" || target.Host == "" {
+				errs = append(errs, fmt.Errorf("service %q: invalid url %q", name, instance))
+			}
+		}
+		if svc.LoadBalancing != LoadBalancingRoundRobin && svc.LoadBalancing != LoadBalancingLeastConnections {
+			errs = append(errs, fmt.Errorf("service %q: unknown load balancing strategy %q", name, svc.LoadBalancing))
+		}
+		if hc := svc.HealthCheck; hc.Path != "" {
+			if !strings.HasPrefix(hc.Path, "/") {
+				errs = append(errs, fmt.Errorf("service %q: health check path must start with /", name))
+			}
+			if hc.Interval <= 0 || hc.Timeout <= 0 || hc.UnhealthyThreshold <= 0 || hc.HealthyThreshold <= 0 {
+				errs = append(errs, fmt.Errorf("service %q: health check interval, timeout and thresholds must be positive", name))
+			}
+		}
+		if svc.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("service %q: timeout must be positive", name))
+		}
+		if svc.Retries < 0 {
+			errs = append(errs, fmt.Errorf("service %q: retries cannot be negative", name))
+		}
+		if svc.Breaker.Failures <= 0 || svc.Breaker.OpenFor <= 0 {
+			errs = append(errs, fmt.Errorf("service %q: circuit breaker failures and open_for must be positive", name))
+		}
+	}
+
+	switch c.Auth.Algorithm {
+	case JWTAlgorithmHS256:
+		if c.Auth.JWTSecret == DefaultJWTSecret && c.Server.Environment != EnvironmentDevelopment {
+			errs = append(errs, fmt.Errorf("JWT_SECRET must be set when APP_ENV is %q", c.Server.Environment))
+		}
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+		if c.Auth.SigningKeyFile == "" {
+			errs = append(errs, fmt.Errorf("JWT algorithm %s needs JWT_SIGNING_KEY_FILE", c.Auth.Algorithm))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown JWT algorithm %q", c.Auth.Algorithm))
+	}
+
+	if c.Auth.InternalSecret == DefaultInternalSecret && c.Server.Environment != EnvironmentDevelopment {
+		errs = append(errs, fmt.Errorf("INTERNAL_AUTH_SECRET must be set when APP_ENV is %q", c.Server.Environment))
+	}
+
+	switch c.Auth.Store {
+	case AuthStoreMemory:
+	case AuthStorePostgres:
+		if c.Auth.DatabaseURL == "" {
+			errs = append(errs, errors.New("auth store postgres needs GATEWAY_DATABASE_URL"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown auth store %q", c.Auth.Store))
+	}
+	if c.Auth.StoreSlugTTL <= 0 {
+		errs = append(errs, errors.New("STORE_SLUG_CACHE_TTL must be positive"))
+	}
+
+	switch c.Saga.Store {
+	case AuthStoreMemory:
+	case AuthStorePostgres:
+		if c.Saga.DatabaseURL == "" {
+			errs = append(errs, errors.New("saga store postgres needs GATEWAY_DATABASE_URL"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown saga store %q", c.Saga.Store))
+	}
+	if c.Saga.RetryInterval <= 0 || c.Saga.MaxAttempts <= 0 || c.Saga.StaleAfter <= 0 {
+		errs = append(errs, errors.New("SAGA_RETRY_INTERVAL, SAGA_MAX_ATTEMPTS and SAGA_STALE_AFTER must be positive"))
+	}
+	if timeout := c.CoreServiceTimeout(); c.Saga.StaleAfter <= timeout {
+		// Every saga step is saved as it ends, so a saga only goes stale while a call hangs for longer
+		errs = append(errs, fmt.Errorf("SAGA_STALE_AFTER must be longer than the %s timeout of the core services", timeout))
+	}
+
+	switch c.Idempotency.Store {
+	case AuthStoreMemory:
+	case AuthStorePostgres:
+		if c.Idempotency.DatabaseURL == "" {
+			errs = append(errs, errors.New("idempotency store postgres needs GATEWAY_DATABASE_URL"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown idempotency store %q", c.Idempotency.Store))
+	}
+	if c.Idempotency.TTL <= 0 || c.Idempotency.Wait < 0 {
+		errs = append(errs, errors.New("IDEMPOTENCY_TTL must be positive and IDEMPOTENCY_WAIT cannot be negative"))
+	}
+
+	if c.Login.MaxAttempts <= 0 || c.Login.AccountMaxAttempts <= 0 || c.Login.IPMaxAttempts <= 0 {
+		errs = append(errs, errors.New("LOGIN_MAX_ATTEMPTS, LOGIN_ACCOUNT_MAX_ATTEMPTS and LOGIN_IP_MAX_ATTEMPTS must be positive"))
+	}
+	if c.Login.Backoff <= 0 || c.Login.Lockout < c.Login.Backoff {
+		errs = append(errs, errors.New("LOGIN_BACKOFF must be positive and LOGIN_LOCKOUT at least as long"))
+	}
+	if c.Login.FailureWindow < c.Login.Lockout {
+		errs = append(errs, errors.New("LOGIN_FAILURE_WINDOW must be at least as long as LOGIN_LOCKOUT"))
+	}
+	if c.Login.AuditRetention <= 0 {
+		errs = append(errs, errors.New("LOGIN_AUDIT_RETENTION must be positive"))
+	}
+
+	if c.Storefront.SectionTimeout <= 0 || c.Storefront.HomeProducts <= 0 {
+		errs = append(errs, errors.New("STOREFRONT_SECTION_TIMEOUT and STOREFRONT_HOME_PRODUCTS must be positive"))
+	}
+
+	if c.RateLimit.MaxRequests <= 0 || c.RateLimit.Duration <= 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_MAX_REQUESTS and RATE_LIMIT_DURATION must be positive"))
+	}
+	for name, policy := range c.RateLimit.Policies {
+		if policy.Requests <= 0 || policy.Per <= 0 || policy.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %q: requests, per and burst must be positive", name))
+		}
+		if !rateLimitKeys[policy.Key] {
+			errs = append(errs, fmt.Errorf("rate limit %q: unknown key %q", name, policy.Key))
+		}
+	}
+
+	for name, policy := range c.CORS.Policies {
+		if len(policy.AllowedOrigins) == 0 {
+			errs = append(errs, fmt.Errorf("cors policy %q: no allowed origins", name))
+		}
+		if policy.MaxAge < 0 {
+			errs = append(errs, fmt.Errorf("cors policy %q: max_age cannot be negative", name))
+		}
+	}
+
+	knownMiddlewares := make(map[string]bool, len(middlewares))
+	for _, name := range middlewares {
+		knownMiddlewares[name] = true
+	}
+
+	seen := make(map[string]int)
+	for i, route := range c.Routes {
+		where := fmt.Sprintf("route #%d (%s)", i+1, route.PublicPath())
+
+		if route.Path == "" || !strings.HasPrefix(route.Path, "/") {
+			errs = append(errs, fmt.Errorf("%s: path must start with /", where))
+		}
+		if len(route.Methods) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no methods defined", where))
+		}
+		if _, exists := c.Services[route.Service]; !exists {
+			errs = append(errs, fmt.Errorf("%s: unknown service %q", where, route.Service))
+		}
+		errs = append(errs, validateVersioning(where, route)...)
+		if route.Split != nil {
+			errs = append(errs, c.validateSplit(where, route.Split)...)
+		}
+		if route.Mirror != nil {
+			errs = append(errs, c.validateMirror(where, route)...)
+		}
+		for _, mw := range route.Middlewares {
+			name, param, _ := strings.Cut(mw, ":")
+			if !knownMiddlewares[name] {
+				errs = append(errs, fmt.Errorf("%s: unknown middleware %q", where, mw))
+				continue
+			}
+			if name == "rate-limit" && param != "" {
+				if _, exists := c.RateLimit.Policies[param]; !exists {
+					errs = append(errs, fmt.Errorf("%s: unknown rate limit policy %q", where, param))
+				}
+			}
+			if name == "cors" {
+				if _, exists := c.CORS.Policies[param]; !exists {
+					errs = append(errs, fmt.Errorf("%s: unknown cors policy %q", where, param))
+				}
+			}
+			if name == "permission" {
+				errs = append(errs, validatePermission(where, route, param)...)
+			}
+		}
+		for _, method := range route.Methods {
+			if !allowedMethods[method] {
+				errs = append(errs, fmt.Errorf("%s: unsupported method %q", where, method))
+				continue
+			}
+			errs = append(errs, validateCoreRoute(where, route, method)...)
+			key := method + " " + route.PublicPath()
+			if first, dup := seen[key]; dup {
+				errs = append(errs, fmt.Errorf("%s: duplicate %s, already defined by route #%d", where, key, first))
+				continue
+			}
+			seen[key] = i + 1
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCoreRoute checks that method on route only reaches a core route where the gateway
+// answers it itself, and that it does not take over where a core route is exposed.
+func validateCoreRoute(where string, route RouteConfig, method string) []error {
+	if core, ok := route.CoreRoute(method); ok {
+		if !samePattern(route.PublicPath(), core.Path) || route.Split != nil {
+			return []error{fmt.Errorf("%s: %s would reach %s %s on %s without the gateway's saga; it is only served at %s", where, method, core.Method, core.Path, core.Service, core.Path)}
+		}
+		return nil
+	}
+	for _, core := range CoreRoutes {
+		if core.Method == method && samePattern(route.PublicPath(), core.Path) {
+			return []error{fmt.Errorf("%s: %s %s is handled by the gateway itself and cannot be proxied to %s", where, method, core.Path, route.Service)}
+		}
+	}
+	return nil
+}
+
+// validatePermission checks a "permission:<name>" middleware: the permission must exist, and the
+// route must authenticate the caller and name the store the permission applies to.
+func validatePermission(where string, route RouteConfig, permission string) []error {
+	var errs []error
+	if !access.IsPermission(permission) {
+		errs = append(errs, fmt.Errorf("%s: unknown permission %q", where, permission))
+	}
+	if !slices.Contains(route.Middlewares, "auth") {
+		errs = append(errs, fmt.Errorf("%s: permission %q needs the auth middleware", where, permission))
+	}
+	if !strings.Contains(route.Path, "{store_id}") && !strings.Contains(route.Path, "{store_slug}") {
+		errs = append(errs, fmt.Errorf("%s: permission %q needs a {store_id} or {store_slug} in the path", where, permission))
+	}
+	return errs
+}
+
+// validateSplit checks that a route's split only names configured services, gives some traffic to
+// its backends and says what each pin matches.
+func (c *Config) validateSplit(where string, split *SplitConfig) []error {
+	var errs []error
+	total := 0
+	for _, backend := range split.Backends {
+		if _, exists := c.Services[backend.Service]; !exists {
+			errs = append(errs, fmt.Errorf("%s: unknown split service %q", where, backend.Service))
+		}
+		if backend.Weight < 0 {
+			errs = append(errs, fmt.Errorf("%s: split service %q has a negative weight", where, backend.Service))
+		}
+		total += backend.Weight
+	}
+	if len(split.Backends) > 0 && total <= 0 {
+		errs = append(errs, fmt.Errorf("%s: split backends have no weight", where))
+	}
+	for _, pin := range split.Pins {
+		if _, exists := c.Services[pin.Service]; !exists {
+			errs = append(errs, fmt.Errorf("%s: unknown split service %q", where, pin.Service))
+		}
+		matchers := 0
+		for _, set := range []bool{pin.Header != "", pin.Cookie != "", len(pin.StoreIDs) > 0} {
+			if set {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			errs = append(errs, fmt.Errorf("%s: split pin for %q needs exactly one of header, cookie and store_ids", where, pin.Service))
+		}
+		if (pin.Header != "" || pin.Cookie != "") && pin.Value == "" {
+			errs = append(errs, fmt.Errorf("%s: split pin for %q needs a value", where, pin.Service))
+		}
+	}
+	return errs
+}
+
+// validateMirror checks that a route's mirror names a configured service other than the route's
+// own, samples some of its requests and has a valid body limit.
+func (c *Config) validateMirror(where string, route RouteConfig) []error {
+	var errs []error
+	mirror := route.Mirror
+	if _, exists := c.Services[mirror.Service]; !exists {
+		errs = append(errs, fmt.Errorf("%s: unknown mirror service %q", where, mirror.Service))
+	}
+	if mirror.Service == route.Service {
+		errs = append(errs, fmt.Errorf("%s: mirror service %q is the route's own service", where, mirror.Service))
+	}
+	if mirror.SampleRate <= 0 || mirror.SampleRate > 1 {
+		errs = append(errs, fmt.Errorf("%s: mirror sample_rate must be above 0 and at most 1", where))
+	}
+	if mirror.MaxBody != "" {
+		if _, err := ParseSize(mirror.MaxBody); err != nil {
+			errs = append(errs, fmt.Errorf("%s: mirror max_body: %w", where, err))
+		}
+	}
+	return errs
+}
+
+// validateVersioning checks a route's prefix, that its rewrite only uses parameters its path has,
+// and that its deprecation dates can be sent in headers.
+func validateVersioning(where string, route RouteConfig) []error {
+	var errs []error
+	if route.Prefix != "" && (!strings.HasPrefix(route.Prefix, "/") || strings.HasSuffix(route.Prefix, "/")) {
+		errs = append(errs, fmt.Errorf("%s: prefix must start with / and not end with one", where))
+	}
+	if route.Rewrite != "" {
+		if !strings.HasPrefix(route.Rewrite, "/") {
+			errs = append(errs, fmt.Errorf("%s: rewrite must start with /", where))
+		}
+		params := PathParams(route.Path)
+		for _, param := range PathParams(route.Rewrite) {
+			switch {
+			case !slices.Contains(params, param):
+				errs = append(errs, fmt.Errorf("%s: rewrite uses %q, which the path does not have", where, param))
+			case param != "*" && !strings.Contains(route.Rewrite, "{"+param+"}"):
+				errs = append(errs, fmt.Errorf("%s: rewrite parameter %q cannot have a regexp", where, param))
+			}
+		}
+	}
+
+	if deprecation := route.Deprecation; deprecation != nil {
+		since, err := ParseDate(deprecation.Since)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: deprecation since: %w", where, err))
+		}
+		if deprecation.Sunset != "" {
+			sunset, err := ParseDate(deprecation.Sunset)
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("%s: deprecation sunset: %w", where, err))
+			case !sunset.After(since):
+				errs = append(errs, fmt.Errorf("%s: deprecation sunset must be after since", where))
+			}
+		}
+	}
+	return errs
+}
+
+// PathParams lists the parameters of a chi route pattern: the names of its {name} and
+// {name:regexp} segments, and "*" for a trailing wildcard.
+func PathParams(pattern string) []string {
+	var params []string
+	for len(pattern) > 0 {
+		start := strings.IndexAny(pattern, "{*")
+		if start < 0 {
+			break
+		}
+		if pattern[start] == '*' {
+			params = append(params, "*")
+			pattern = pattern[start+1:]
+			continue
+		}
+		// Regexps may hold braces of their own, as in {id:[0-9]{1,5}}
+		depth, end := 0, len(pattern)
+		for j := start; j < len(pattern); j++ {
+			if pattern[j] == '{' {
+				depth++
+			} else if pattern[j] == '}' {
+				if depth--; depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		name, _, _ := strings.Cut(pattern[min(start+1, end):end], ":")
+		params = append(params, name)
+		pattern = pattern[min(end+1, len(pattern)):]
+	}
+	return params
+}
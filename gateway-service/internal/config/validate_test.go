@@ -0,0 +1,286 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// middlewareNames stands in for the middlewares the router can build.
+var middlewareNames = []string{"auth", "rate-limit", "cors", "permission"}
+
+// validConfig returns a config that passes Validate, for tests to break one thing at a time.
+func validConfig() *Config {
+	service := func(url string) ServiceConfig {
+		return newServiceConfig([]string{url}, 5*time.Second)
+	}
+	return &Config{
+		Server: ServerConfig{Environment: EnvironmentDevelopment},
+		Services: map[string]ServiceConfig{
+			"user-service":    service("http://localhost:3000"),
+			"order-service":   service("http://localhost:8082"),
+			"product-service": service("http://localhost:8083"),
+		},
+		Routes: []RouteConfig{
+			{Path: "/stores/{store_id}/products", Methods: []string{"GET", "POST"}, Service: "product-service", Middlewares: []string{"auth", "permission:products.write"}},
+			{Path: "/auth/login", Methods: []string{"POST"}, Service: "user-service", Middlewares: []string{"rate-limit:login"}},
+		},
+		Auth: AuthConfig{
+			Algorithm:      JWTAlgorithmHS256,
+			JWTSecret:      DefaultJWTSecret,
+			InternalSecret: DefaultInternalSecret,
+			Store:          AuthStoreMemory,
+			StoreSlugTTL:   time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			MaxRequests: 100,
+			Duration:    time.Minute,
+			Policies:    map[string]RateLimitPolicy{"login": {Requests: 10, Per: time.Minute, Burst: 10, Key: "ip"}},
+		},
+		Saga:        SagaConfig{Store: AuthStoreMemory, RetryInterval: time.Second, MaxAttempts: 3, StaleAfter: time.Minute},
+		Idempotency: IdempotencyConfig{Store: AuthStoreMemory, TTL: time.Hour},
+		Login:       LoginConfig{MaxAttempts: 5, AccountMaxAttempts: 20, IPMaxAttempts: 20, Backoff: time.Second, Lockout: time.Minute, FailureWindow: time.Hour, AuditRetention: time.Hour},
+		Storefront:  StorefrontConfig{SectionTimeout: time.Second, HomeProducts: 12},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			change: func(c *Config) {},
+		},
+		{
+			name:    "core service missing",
+			change:  func(c *Config) { delete(c.Services, "order-service") },
+			wantErr: `missing required service "order-service"`,
+		},
+		{
+			name: "instance without a scheme",
+			change: func(c *Config) {
+				svc := c.Services["user-service"]
+				svc.Instances = []string{"localhost:3000"}
+				c.Services["user-service"] = svc
+			},
+			wantErr: `service "user-service": invalid url "localhost:3000"`,
+		},
+		{
+			name:    "default secret outside development",
+			change:  func(c *Config) { c.Server.Environment = "production" },
+			wantErr: "JWT_SECRET must be set",
+		},
+		{
+			name: "sagas go stale before a call times out",
+			change: func(c *Config) {
+				svc := c.Services["order-service"]
+				svc.Timeout = 2 * time.Minute
+				c.Services["order-service"] = svc
+			},
+			wantErr: "SAGA_STALE_AFTER must be longer than the 2m0s timeout",
+		},
+		{
+			name: "store creation served by the gateway",
+			change: func(c *Config) {
+				c.Routes = append(c.Routes, RouteConfig{Path: "/store", Methods: []string{"GET", "POST"}, Service: "user-service"})
+			},
+		},
+		{
+			name: "store creation behind a prefix",
+			change: func(c *Config) {
+				c.Routes = append(c.Routes, RouteConfig{Prefix: "/v1", Path: "/store", Methods: []string{"POST"}, Service: "user-service"})
+			},
+			wantErr: "route #3 (/v1/store): POST would reach POST /store on user-service without the gateway's saga",
+		},
+		{
+			name: "store deletion through a rewrite",
+			change: func(c *Config) {
+				c.Routes = append(c.Routes, RouteConfig{Path: "/shops/{id}", Rewrite: "/store/{id}", Methods: []string{"DELETE"}, Service: "user-service"})
+			},
+			wantErr: "route #3 (/shops/{id}): DELETE would reach DELETE /store/{store_id} on user-service",
+		},
+		{
+			name: "store creation path proxied elsewhere",
+			change: func(c *Config) {
+				c.Routes = append(c.Routes, RouteConfig{Path: "/store", Rewrite: "/stores", Methods: []string{"POST"}, Service: "user-service"})
+			},
+			wantErr: "route #3 (/store): POST /store is handled by the gateway itself",
+		},
+		{
+			name:    "path without a slash",
+			change:  func(c *Config) { c.Routes[1].Path = "auth/login" },
+			wantErr: "route #2 (auth/login): path must start with /",
+		},
+		{
+			name:    "unknown service",
+			change:  func(c *Config) { c.Routes[1].Service = "payment-service" },
+			wantErr: `unknown service "payment-service"`,
+		},
+		{
+			name:    "unsupported method",
+			change:  func(c *Config) { c.Routes[1].Methods = []string{"TRACE"} },
+			wantErr: `unsupported method "TRACE"`,
+		},
+		{
+			name: "duplicate route",
+			change: func(c *Config) {
+				c.Routes = append(c.Routes, RouteConfig{Path: "/auth/login", Methods: []string{"POST"}, Service: "user-service"})
+			},
+			wantErr: "route #3 (/auth/login): duplicate POST /auth/login, already defined by route #2",
+		},
+		{
+			name:    "unknown middleware",
+			change:  func(c *Config) { c.Routes[1].Middlewares = []string{"captcha"} },
+			wantErr: `unknown middleware "captcha"`,
+		},
+		{
+			name:    "unknown rate limit policy",
+			change:  func(c *Config) { c.Routes[1].Middlewares = []string{"rate-limit:signup"} },
+			wantErr: `unknown rate limit policy "signup"`,
+		},
+		{
+			name: "rate limit keyed by something unknown",
+			change: func(c *Config) {
+				c.RateLimit.Policies["login"] = RateLimitPolicy{Requests: 10, Per: time.Minute, Burst: 10, Key: "email"}
+			},
+			wantErr: `rate limit "login": unknown key "email"`,
+		},
+		{
+			name:    "unknown cors policy",
+			change:  func(c *Config) { c.Routes[1].Middlewares = []string{"cors:storefront"} },
+			wantErr: `unknown cors policy "storefront"`,
+		},
+		{
+			name:    "unknown permission",
+			change:  func(c *Config) { c.Routes[0].Middlewares = []string{"auth", "permission:products.sell"} },
+			wantErr: `unknown permission "products.sell"`,
+		},
+		{
+			name:    "permission without auth",
+			change:  func(c *Config) { c.Routes[0].Middlewares = []string{"permission:products.write"} },
+			wantErr: `permission "products.write" needs the auth middleware`,
+		},
+		{
+			name:    "permission without a store in the path",
+			change:  func(c *Config) { c.Routes[0].Path = "/products" },
+			wantErr: `permission "products.write" needs a {store_id} or {store_slug} in the path`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.change(c)
+			err := c.Validate(middlewareNames)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := validConfig()
+	c.Routes[0].Service = "payment-service"
+	c.Routes[1].Methods = nil
+
+	err := c.Validate(middlewareNames)
+	if err == nil {
+		t.Fatal("Validate = nil, want errors")
+	}
+	for _, want := range []string{`unknown service "payment-service"`, "no methods defined"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestValidateVersioning(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   RouteConfig
+		wantErr string
+	}{
+		{
+			name:  "prefix and rewrite",
+			route: RouteConfig{Path: "/stores/{store_id}/items/*", Prefix: "/v2", Rewrite: "/api/v2/stores/{store_id}/items/*"},
+		},
+		{
+			name:    "prefix with a trailing slash",
+			route:   RouteConfig{Path: "/stores", Prefix: "/v2/"},
+			wantErr: "prefix must start with / and not end with one",
+		},
+		{
+			name:    "relative rewrite",
+			route:   RouteConfig{Path: "/stores", Rewrite: "api/stores"},
+			wantErr: "rewrite must start with /",
+		},
+		{
+			name:    "rewrite with a parameter the path lacks",
+			route:   RouteConfig{Path: "/stores/{store_id}", Rewrite: "/stores/{id}"},
+			wantErr: `rewrite uses "id", which the path does not have`,
+		},
+		{
+			name:    "rewrite with a regexp",
+			route:   RouteConfig{Path: "/stores/{store_id}", Rewrite: "/stores/{store_id:[0-9]+}"},
+			wantErr: `rewrite parameter "store_id" cannot have a regexp`,
+		},
+		{
+			name:  "deprecated with a sunset",
+			route: RouteConfig{Path: "/stores", Deprecation: &DeprecationConfig{Since: "2025-01-01", Sunset: "2025-07-01T00:00:00Z"}},
+		},
+		{
+			name:    "deprecated with an unreadable date",
+			route:   RouteConfig{Path: "/stores", Deprecation: &DeprecationConfig{Since: "01/01/2025"}},
+			wantErr: `deprecation since: invalid date "01/01/2025"`,
+		},
+		{
+			name:    "sunset before since",
+			route:   RouteConfig{Path: "/stores", Deprecation: &DeprecationConfig{Since: "2025-07-01", Sunset: "2025-01-01"}},
+			wantErr: "deprecation sunset must be after since",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateVersioning("route", tt.route)
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Fatalf("validateVersioning = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("validateVersioning = %v, want one error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/stores", nil},
+		{"/stores/{store_id}/products/{id}", []string{"store_id", "id"}},
+		{"/stores/{store_id:[0-9]+}", []string{"store_id"}},
+		{"/stores/{id:[0-9]{1,5}}/items", []string{"id"}},
+		{"/static/*", []string{"*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := PathParams(tt.pattern); !slices.Equal(got, tt.want) {
+				t.Fatalf("PathParams(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
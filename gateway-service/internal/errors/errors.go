@@ -3,14 +3,69 @@ package errors
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+// ContentTypeProblem is the media type of error responses.
+const ContentTypeProblem = "application/problem+json"
+
+// CodeValidationFailed is the code of errors listing the request fields that are invalid.
+const CodeValidationFailed = "VALIDATION_FAILED"
+
+// AppError is an error with the status and code it is answered with. Type is the stable code
+// clients match on, such as "NOT_FOUND".
 type AppError struct {
 	Type       string
 	Message    string
 	StatusCode int
+	Fields     []FieldError
+}
+
+// FieldError says what is wrong with one field of the request.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem is the RFC 7807 body of every error response. Code is stable and meant for programs,
+// Detail for people; RequestID matches the logs of every service the request went through.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem describes err as a problem answered with status. The code is the AppError's if it
+// was made for that status, and otherwise derived from the status, e.g. "BAD_REQUEST".
+func NewProblem(status int, err error) Problem {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+		Code:   CodeForStatus(status),
+	}
+	var appErr AppError
+	if errors.As(err, &appErr) && appErr.StatusCode == status {
+		problem.Code = appErr.Type
+		problem.Errors = appErr.Fields
+	}
+	return problem
+}
+
+// CodeForStatus is the code of errors with no more specific one, e.g. "NOT_FOUND" for a 404.
+func CodeForStatus(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
 }
 
 func (e AppError) Error() string {
@@ -33,6 +88,56 @@ func NewBadRequestError(message string) AppError {
 	}
 }
 
+// NewValidationError rejects a request whose fields are invalid, listing what is wrong with each.
+func NewValidationError(message string, fields ...FieldError) AppError {
+	return AppError{
+		Type:       CodeValidationFailed,
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+		Fields:     fields,
+	}
+}
+
+func NewUnauthorizedError(message string) AppError {
+	return AppError{
+		Type:       "UNAUTHORIZED",
+		Message:    message,
+		StatusCode: http.StatusUnauthorized,
+	}
+}
+
+func NewForbiddenError(message string) AppError {
+	return AppError{
+		Type:       "FORBIDDEN",
+		Message:    message,
+		StatusCode: http.StatusForbidden,
+	}
+}
+
+func NewConflictError(message string) AppError {
+	return AppError{
+		Type:       "CONFLICT",
+		Message:    message,
+		StatusCode: http.StatusConflict,
+	}
+}
+
+func NewRequestEntityTooLargeError(message string) AppError {
+	return AppError{
+		Type:       "REQUEST_ENTITY_TOO_LARGE",
+		Message:    message,
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+func NewUnprocessableEntityError(message string) AppError {
+	return AppError{
+		Type:       "UNPROCESSABLE_ENTITY",
+		Message:    message,
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+}
+
 func NewInternalServerError(message string) AppError {
 	return AppError{
 		Type:       "INTERNAL_SERVER_ERROR",
@@ -41,6 +146,38 @@ func NewInternalServerError(message string) AppError {
 	}
 }
 
+func NewTooManyRequestsError(message string) AppError {
+	return AppError{
+		Type:       "TOO_MANY_REQUESTS",
+		Message:    message,
+		StatusCode: http.StatusTooManyRequests,
+	}
+}
+
+func NewBadGatewayError(message string) AppError {
+	return AppError{
+		Type:       "BAD_GATEWAY",
+		Message:    message,
+		StatusCode: http.StatusBadGateway,
+	}
+}
+
+func NewServiceUnavailableError(message string) AppError {
+	return AppError{
+		Type:       "SERVICE_UNAVAILABLE",
+		Message:    message,
+		StatusCode: http.StatusServiceUnavailable,
+	}
+}
+
+func NewGatewayTimeoutError(message string) AppError {
+	return AppError{
+		Type:       "GATEWAY_TIMEOUT",
+		Message:    message,
+		StatusCode: http.StatusGatewayTimeout,
+	}
+}
+
 func ErrCheck(err error) error {
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
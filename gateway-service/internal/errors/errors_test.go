@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	fields := []FieldError{{Field: "email", Message: "email must be an email"}}
+
+	tests := []struct {
+		name       string
+		status     int
+		err        error
+		wantCode   string
+		wantFields int
+	}{
+		{"app error", http.StatusNotFound, NewNotFoundError("store not found"), "NOT_FOUND", 0},
+		{"wrapped app error", http.StatusConflict, fmt.Errorf("creating store: %w", NewConflictError("slug taken")), "CONFLICT", 0},
+		{"validation error", http.StatusBadRequest, NewValidationError("request validation failed", fields...), CodeValidationFailed, 1},
+		{"app error made for another status", http.StatusBadGateway, NewNotFoundError("user not found"), "BAD_GATEWAY", 0},
+		{"plain error", http.StatusInternalServerError, errors.New("boom"), "INTERNAL_SERVER_ERROR", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := NewProblem(tt.status, tt.err)
+			if problem.Code != tt.wantCode || len(problem.Errors) != tt.wantFields {
+				t.Fatalf("problem = %+v, want code %s with %d field errors", problem, tt.wantCode, tt.wantFields)
+			}
+			if problem.Status != tt.status || problem.Title != http.StatusText(tt.status) || problem.Detail != tt.err.Error() {
+				t.Fatalf("problem = %+v does not describe %d %q", problem, tt.status, tt.err)
+			}
+		})
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusNotFound, "NOT_FOUND"},
+		{http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE"},
+		{http.StatusNonAuthoritativeInfo, "NON_AUTHORITATIVE_INFORMATION"},
+		{http.StatusTeapot, "IM_A_TEAPOT"},
+		{599, "ERROR"},
+	}
+	for _, tt := range tests {
+		if got := CodeForStatus(tt.status); got != tt.want {
+			t.Errorf("CodeForStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
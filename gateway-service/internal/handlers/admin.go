@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/internal/proxy"
+	"github.com/robaa12/gatway-service/internal/service"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// defaultAuditLimit and maxAuditLimit bound how many login audit events one request lists
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AdminHandler serves the gateway's operator endpoints
+type AdminHandler struct {
+	storeService *service.StoreService
+	loginAudit   auth.LoginAuditLog
+	upstreams    *proxy.Registry
+	routes       []RouteInfo
+}
+
+// RouteInfo describes a proxied route as the gateway serves it
+type RouteInfo struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+	// GatewayMethods are listed for the route but handled by the gateway itself
+	GatewayMethods []string `json:"gateway_methods,omitempty"`
+	Service        string   `json:"service"`
+	UpstreamPath   string   `json:"upstream_path"`
+	// Middlewares are listed in the order they run, outermost first
+	Middlewares []string                  `json:"middlewares"`
+	Split       *config.SplitConfig       `json:"split,omitempty"`
+	Mirror      *config.MirrorConfig      `json:"mirror,omitempty"`
+	Deprecation *config.DeprecationConfig `json:"deprecation,omitempty"`
+}
+
+// maintenanceRequest turns on maintenance mode for a service. Body is sent as is to its callers,
+// or an error with Message when it is not given
+type maintenanceRequest struct {
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+	RetryAfter string          `json:"retry_after"`
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(storeService *service.StoreService, loginAudit auth.LoginAuditLog, upstreams *proxy.Registry, routes []RouteInfo) *AdminHandler {
+	return &AdminHandler{
+		storeService: storeService,
+		loginAudit:   loginAudit,
+		upstreams:    upstreams,
+		routes:       routes,
+	}
+}
+
+// ListSagas lists sagas, optionally only those in the comma separated ?status= values
+func (h *AdminHandler) ListSagas(w http.ResponseWriter, r *http.Request) {
+	var statuses []string
+	if status := r.URL.Query().Get("status"); status != "" {
+		statuses = strings.Split(status, ",")
+	}
+
+	sagas, err := h.storeService.Sagas.List(r.Context(), statuses...)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing sagas", "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("saga log unavailable"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"sagas": sagas})
+}
+
+// GetSaga returns one saga with its steps
+func (h *AdminHandler) GetSaga(w http.ResponseWriter, r *http.Request) {
+	sg, err := h.storeService.Sagas.Get(r.Context(), chi.URLParam(r, "saga_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading saga", "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("saga log unavailable"))
+		return
+	}
+	if sg == nil {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("saga not found"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, sg)
+}
+
+// RetrySaga retries the compensation of a failed saga
+func (h *AdminHandler) RetrySaga(w http.ResponseWriter, r *http.Request) {
+	sg, err := h.storeService.RetrySaga(r.Context(), chi.URLParam(r, "saga_id"))
+	if err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, sg)
+}
+
+// ListLoginAudit lists suspicious login activity, newest first, optionally only for ?email= and at
+// most ?limit= events
+func (h *AdminHandler) ListLoginAudit(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxAuditLimit {
+			utils.ErrorJSON(w, apperrors.NewBadRequestError("limit must be between 1 and "+strconv.Itoa(maxAuditLimit)))
+			return
+		}
+		limit = n
+	}
+	email := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("email")))
+
+	events, err := h.loginAudit.List(r.Context(), email, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing login audit events", "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("login audit log unavailable"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+// ListRoutes lists the routes the gateway proxies, as loaded from its config
+func (h *AdminHandler) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"routes": h.routes})
+}
+
+// ListServices lists the upstream services with the health of their instances, their breaker,
+// maintenance mode and recent errors
+func (h *AdminHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"services": h.upstreams.Status()})
+}
+
+// GetService returns the status of one upstream service
+func (h *AdminHandler) GetService(w http.ResponseWriter, r *http.Request) {
+	svc := h.upstreams.Service(chi.URLParam(r, "service"))
+	if svc == nil {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("service not found"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, svc.Status())
+}
+
+// StartMaintenance makes the gateway answer every proxied request to a service with a 503 until
+// StopMaintenance is called. It only applies to the replica that receives it
+func (h *AdminHandler) StartMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req maintenanceRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request body"))
+		return
+	}
+	m := &proxy.Maintenance{Message: req.Message, Since: time.Now()}
+	if len(req.Body) > 0 && string(req.Body) != "null" {
+		m.Body = req.Body
+	}
+	if req.RetryAfter != "" {
+		retryAfter, err := time.ParseDuration(req.RetryAfter)
+		if err != nil || retryAfter <= 0 {
+			utils.ErrorJSON(w, apperrors.NewBadRequestError("retry_after must be a positive duration"))
+			return
+		}
+		m.RetryAfter = retryAfter
+	}
+
+	name := chi.URLParam(r, "service")
+	if !h.upstreams.SetMaintenance(name, m) {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("service not found"))
+		return
+	}
+	slog.WarnContext(r.Context(), "Service put in maintenance", "service", name)
+	utils.WriteJSON(w, http.StatusOK, h.upstreams.Service(name).Status())
+}
+
+// StopMaintenance sends a service's requests to it again
+func (h *AdminHandler) StopMaintenance(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "service")
+	if !h.upstreams.SetMaintenance(name, nil) {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("service not found"))
+		return
+	}
+	slog.InfoContext(r.Context(), "Service taken out of maintenance", "service", name)
+	utils.WriteJSON(w, http.StatusOK, h.upstreams.Service(name).Status())
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/access"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// APIKeyHandler manages the API keys a store's integrations use instead of a user login
+type APIKeyHandler struct {
+	authService *auth.Service
+	apiKeys     auth.APIKeyStore
+}
+
+// CreatedAPIKey is returned once, when a key is created; it is the only time the secret is shown
+type CreatedAPIKey struct {
+	auth.APIKey
+	Key string `json:"key"`
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(authService *auth.Service, apiKeys auth.APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{
+		authService: authService,
+		apiKeys:     apiKeys,
+	}
+}
+
+// CreateAPIKey issues a key for the store with the requested scopes
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*auth.Claims)
+	if !ok {
+		utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized"))
+		return
+	}
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Scopes) == 0 {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError("name and scopes are required"))
+		return
+	}
+
+	// A key can never do more than the person who created it
+	role, err := h.authService.StoreRole(r.Context(), claims, storeID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up staff role", "user_id", claims.UserID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !access.IsScope(scope) {
+			utils.ErrorJSON(w, apperrors.NewBadRequestError(fmt.Sprintf("unknown scope %q", scope)))
+			return
+		}
+		if !access.Allows(role, access.ScopePermission(scope)) {
+			utils.ErrorJSON(w, apperrors.NewForbiddenError(fmt.Sprintf("you cannot grant scope %s", scope)))
+			return
+		}
+	}
+
+	key, secret, hash, err := auth.NewAPIKey(storeID, claims.UserID, req.Name, req.Scopes)
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewInternalServerError("could not generate API key"))
+		return
+	}
+	if err := h.apiKeys.Create(r.Context(), key, hash); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving API key", "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("API key store unavailable"))
+		return
+	}
+	slog.InfoContext(r.Context(), "API key created", "user_id", claims.UserID, "key_id", key.ID, "store_id", storeID, "scopes", key.Scopes)
+
+	utils.WriteJSON(w, http.StatusCreated, CreatedAPIKey{APIKey: key, Key: secret})
+}
+
+// ListAPIKeys returns the store's keys, revoked ones included, without their secrets
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	keys, err := h.apiKeys.List(r.Context(), storeID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing API keys", "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("API key store unavailable"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"api_keys": keys})
+}
+
+// RevokeAPIKey stops a key from being accepted; the record is kept for auditing
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	keyID := chi.URLParam(r, "key_id")
+
+	revoked, err := h.apiKeys.Revoke(r.Context(), storeID, keyID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error revoking API key", "key_id", keyID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("API key store unavailable"))
+		return
+	}
+	if !revoked {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("API key not found"))
+		return
+	}
+	slog.InfoContext(r.Context(), "API key revoked", "key_id", keyID, "store_id", storeID)
+	w.WriteHeader(http.StatusNoContent)
+}
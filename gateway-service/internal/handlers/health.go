@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+const (
+	statusUp       = "up"
+	statusDown     = "down"
+	statusOK       = "ok"
+	statusDegraded = "degraded"
+)
+
+// processStart is kept outside HealthHandler so uptime survives config reloads.
+var processStart = time.Now()
+
+// HealthHandler serves the liveness and readiness endpoints used by the orchestrator.
+type HealthHandler struct {
+	cfg    *config.Config
+	client *http.Client
+}
+
+type (
+	InstanceStatus struct {
+		URL       string `json:"url"`
+		Status    string `json:"status"`
+		LatencyMS int64  `json:"latency_ms"`
+		Version   string `json:"version,omitempty"`
+		Error     string `json:"error,omitempty"`
+	}
+
+	ServiceStatus struct {
+		Status    string           `json:"status"`
+		Required  bool             `json:"required"`
+		LatencyMS int64            `json:"latency_ms"`
+		Version   string           `json:"version,omitempty"`
+		Instances []InstanceStatus `json:"instances"`
+	}
+
+	ReadinessResponse struct {
+		Status   string                   `json:"status"`
+		Version  string                   `json:"version"`
+		Services map[string]ServiceStatus `json:"services"`
+	}
+)
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(cfg *config.Config) *HealthHandler {
+	return &HealthHandler{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Server.ReadinessTimeout},
+	}
+}
+
+// Healthz reports that the gateway process is alive. It never calls other services.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	_ = utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"status":         statusOK,
+		"version":        h.cfg.Server.Version,
+		"uptime_seconds": int64(time.Since(processStart).Seconds()),
+	})
+}
+
+// Readyz probes every configured service concurrently and answers 503 if a required one is down.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.cfg.Server.ReadinessTimeout)
+	defer cancel()
+
+	response := h.checkServices(ctx)
+
+	status := http.StatusOK
+	if response.Status == statusDown {
+		status = http.StatusServiceUnavailable
+	}
+	_ = utils.WriteJSON(w, status, response)
+}
+
+// checkServices probes every service and derives the overall status: "down" when a required
+// service is down, "degraded" when only optional ones are.
+func (h *HealthHandler) checkServices(ctx context.Context) ReadinessResponse {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	services := make(map[string]ServiceStatus, len(h.cfg.Services))
+
+	for name, svc := range h.cfg.Services {
+		wg.Add(1)
+		go func(name string, svc config.ServiceConfig) {
+			defer wg.Done()
+			status := h.checkService(ctx, svc)
+			mu.Lock()
+			services[name] = status
+			mu.Unlock()
+		}(name, svc)
+	}
+	wg.Wait()
+
+	response := ReadinessResponse{
+		Status:   statusOK,
+		Version:  h.cfg.Server.Version,
+		Services: services,
+	}
+	for _, svc := range services {
+		if svc.Status == statusUp {
+			continue
+		}
+		if svc.Required {
+			response.Status = statusDown
+			break
+		}
+		response.Status = statusDegraded
+	}
+	return response
+}
+
+// checkService probes all instances of a service; the service is up if any instance is.
+func (h *HealthHandler) checkService(ctx context.Context, svc config.ServiceConfig) ServiceStatus {
+	path := svc.HealthCheck.Path
+	if path == "" {
+		path = "/"
+	}
+
+	instances := make([]InstanceStatus, len(svc.Instances))
+	var wg sync.WaitGroup
+	for i, instanceURL := range svc.Instances {
+		wg.Add(1)
+		go func(i int, instanceURL string) {
+			defer wg.Done()
+			instances[i] = h.probe(ctx, instanceURL, path)
+		}(i, instanceURL)
+	}
+	wg.Wait()
+
+	status := ServiceStatus{
+		Status:    statusDown,
+		Required:  svc.Required,
+		Instances: instances,
+	}
+	// Report the fastest healthy instance
+	for _, inst := range instances {
+		if inst.Status != statusUp {
+			continue
+		}
+		if status.Status != statusUp || inst.LatencyMS < status.LatencyMS {
+			status.LatencyMS = inst.LatencyMS
+		}
+		status.Status = statusUp
+		if status.Version == "" {
+			status.Version = inst.Version
+		}
+	}
+	return status
+}
+
+func (h *HealthHandler) probe(ctx context.Context, instanceURL, path string) InstanceStatus {
+	result := InstanceStatus{
+		URL:    instanceURL,
+		Status: statusDown,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(instanceURL, "/")+path, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.Version = resp.Header.Get("X-Service-Version")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = "unexpected status " + resp.Status
+		return result
+	}
+	result.Status = statusUp
+	return result
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+func newUpstream(t *testing.T, status int, version string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("probed %s, want /health", r.URL.Path)
+		}
+		w.Header().Set("X-Service-Version", version)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestHealthz(t *testing.T) {
+	h := NewHealthHandler(&config.Config{Server: config.ServerConfig{Version: "1.2.3", ReadinessTimeout: time.Second}})
+	w := httptest.NewRecorder()
+	h.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["status"] != statusOK || body["version"] != "1.2.3" {
+		t.Fatalf("body = %v", body)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	up := newUpstream(t, http.StatusOK, "2.0.0")
+	down := newUpstream(t, http.StatusServiceUnavailable, "")
+	service := func(required bool, instances ...string) config.ServiceConfig {
+		return config.ServiceConfig{
+			Instances:   instances,
+			Required:    required,
+			HealthCheck: config.HealthCheckConfig{Path: "/health"},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		services   map[string]config.ServiceConfig
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name:       "all up",
+			services:   map[string]config.ServiceConfig{"user-service": service(true, up), "order-service": service(false, up)},
+			wantCode:   http.StatusOK,
+			wantStatus: statusOK,
+		},
+		{
+			name:       "one instance of a required service up",
+			services:   map[string]config.ServiceConfig{"user-service": service(true, down, up)},
+			wantCode:   http.StatusOK,
+			wantStatus: statusOK,
+		},
+		{
+			name:       "optional service down",
+			services:   map[string]config.ServiceConfig{"user-service": service(true, up), "order-service": service(false, down)},
+			wantCode:   http.StatusOK,
+			wantStatus: statusDegraded,
+		},
+		{
+			name:       "required service down",
+			services:   map[string]config.ServiceConfig{"user-service": service(true, down), "order-service": service(false, up)},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: statusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(&config.Config{
+				Server:   config.ServerConfig{Version: "1.2.3", ReadinessTimeout: time.Second},
+				Services: tt.services,
+			})
+			w := httptest.NewRecorder()
+			h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			var body ReadinessResponse
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.wantStatus {
+				t.Fatalf("readiness = %q, want %q", body.Status, tt.wantStatus)
+			}
+			if len(body.Services) != len(tt.services) {
+				t.Fatalf("reported %d services, want %d", len(body.Services), len(tt.services))
+			}
+			for name, svc := range body.Services {
+				if svc.Status == statusUp && svc.Version != "2.0.0" {
+					t.Errorf("%s version = %q, want the instance's X-Service-Version", name, svc.Version)
+				}
+			}
+		})
+	}
+}
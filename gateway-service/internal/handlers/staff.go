@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/access"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// StaffHandler manages who, besides the owner, may work on a store and with which role
+type StaffHandler struct {
+	authService *auth.Service
+	staff       auth.StaffStore
+}
+
+// NewStaffHandler creates a new staff handler
+func NewStaffHandler(authService *auth.Service, staff auth.StaffStore) *StaffHandler {
+	return &StaffHandler{
+		authService: authService,
+		staff:       staff,
+	}
+}
+
+// ListStaff returns the staff of a store
+func (h *StaffHandler) ListStaff(w http.ResponseWriter, r *http.Request) {
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	members, err := h.staff.List(r.Context(), storeID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing staff", "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"staff": members})
+}
+
+// PutStaff gives a user a role in the store, or changes the role they already have
+func (h *StaffHandler) PutStaff(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
+		return
+	}
+	if !access.IsRole(req.Role) {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(fmt.Sprintf("unknown role %q", req.Role)))
+		return
+	}
+
+	storeID, userID, ok := h.authorizeChange(w, r, req.Role)
+	if !ok {
+		return
+	}
+
+	membership := auth.Membership{StoreID: storeID, UserID: userID, Role: req.Role, UpdatedAt: time.Now()}
+	if err := h.staff.Put(r.Context(), membership); err != nil {
+		slog.ErrorContext(r.Context(), "Error saving staff member", "user_id", userID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return
+	}
+	slog.InfoContext(r.Context(), "Staff member saved", "user_id", userID, "role", req.Role, "store_id", storeID)
+	utils.WriteJSON(w, http.StatusOK, membership)
+}
+
+// RemoveStaff takes away a user's role in the store
+func (h *StaffHandler) RemoveStaff(w http.ResponseWriter, r *http.Request) {
+	storeID, userID, ok := h.authorizeChange(w, r, "")
+	if !ok {
+		return
+	}
+
+	removed, err := h.staff.Remove(r.Context(), storeID, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error removing staff member", "user_id", userID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return
+	}
+	if !removed {
+		utils.ErrorJSON(w, apperrors.NewNotFoundError("staff member not found"))
+		return
+	}
+	slog.InfoContext(r.Context(), "Staff member removed", "user_id", userID, "store_id", storeID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMyStores returns the stores the caller is staff of
+func (h *StaffHandler) ListMyStores(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*auth.Claims)
+	if !ok {
+		utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	members, err := h.staff.ListForUser(r.Context(), claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing staff memberships", "user_id", claims.UserID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"staff": members})
+}
+
+// authorizeChange reads the store and user from the path and checks that the caller may change that
+// member to newRole ("" when removing). Nobody can change their own membership, and only owners can
+// grant, change or take away the owner role.
+func (h *StaffHandler) authorizeChange(w http.ResponseWriter, r *http.Request, newRole string) (int, int, bool) {
+	claims, ok := r.Context().Value("user").(*auth.Claims)
+	if !ok {
+		utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized"))
+		return 0, 0, false
+	}
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return 0, 0, false
+	}
+	userID, err := utils.GetID(r, "user_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return 0, 0, false
+	}
+	if userID == claims.UserID {
+		utils.ErrorJSON(w, apperrors.NewForbiddenError("you cannot change your own staff role"))
+		return 0, 0, false
+	}
+
+	callerRole, err := h.authService.StoreRole(r.Context(), claims, storeID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up staff role", "user_id", claims.UserID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return 0, 0, false
+	}
+	if callerRole == access.RoleOwner {
+		return storeID, userID, true
+	}
+
+	currentRole, err := h.staff.Role(r.Context(), storeID, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error looking up staff role", "user_id", userID, "store_id", storeID, "error", err)
+		utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("staff store unavailable"))
+		return 0, 0, false
+	}
+	if newRole == access.RoleOwner || currentRole == access.RoleOwner {
+		utils.ErrorJSON(w, apperrors.NewForbiddenError("only an owner can manage owners"))
+		return 0, 0, false
+	}
+	return storeID, userID, true
+}
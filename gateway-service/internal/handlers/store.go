@@ -2,8 +2,9 @@ package handlers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	apperrors "github.com/robaa12/gatway-service/internal/errors"
 	"github.com/robaa12/gatway-service/internal/middleware/auth"
@@ -47,18 +48,63 @@ func (h *StoreHandler) CreateStore(w http.ResponseWriter, r *http.Request) {
 	// Add user ID from token to request
 	storeRequest.UserID = uint(claims.UserID)
 
-	store, err := h.storeService.CreateStore(&storeRequest)
+	store, err := h.storeService.CreateStore(r.Context(), &storeRequest)
 	if err != nil {
 		utils.ErrorJSON(w, err)
 		return
 	}
 
 	//  Generate new tokens with updated store IDs
-	tokenResponse, err := h.jwtService.GenerateUpdatedTokenResponse(claims.UserID, claims.StoresID, store.ID)
+	tokenResponse, err := h.jwtService.GenerateUpdatedTokenResponse(claims, store.ID)
 	if err != nil {
-		log.Printf("Error generating new tokens: %v", err)
+		slog.ErrorContext(r.Context(), "Error generating new tokens", "error", err)
 	}
 	storeResponse := store.GetStoreResponse(tokenResponse)
 	// Return the enhanced response
 	utils.WriteJSON(w, http.StatusCreated, storeResponse)
 }
+
+// DeleteStore handles the distributed transaction for deleting a store from all services. With
+// ?export=true the store's data is collected first and returned, and nothing is deleted if that fails.
+func (h *StoreHandler) DeleteStore(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*auth.Claims)
+	if !ok {
+		utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized"))
+		return
+	}
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		utils.ErrorJSON(w, apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	export := false
+	if raw := r.URL.Query().Get("export"); raw != "" {
+		if export, err = strconv.ParseBool(raw); err != nil {
+			utils.ErrorJSON(w, apperrors.NewBadRequestError("export must be true or false"))
+			return
+		}
+	}
+
+	response := model.DeleteStoreResponse{StoreID: uint(storeID)}
+	if export {
+		if response.Export, err = h.storeService.ExportStore(r.Context(), uint(storeID)); err != nil {
+			utils.ErrorJSON(w, err)
+			return
+		}
+	}
+
+	sg, err := h.storeService.DeleteStore(r.Context(), claims.UserID, uint(storeID))
+	if err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	response.SagaID = sg.ID
+	slog.InfoContext(r.Context(), "Store deleted", "user_id", claims.UserID, "store_id", storeID)
+
+	// Generate new tokens without the deleted store
+	response.AccessToken, err = h.jwtService.GenerateTokenResponseWithoutStore(claims, uint(storeID))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error generating new tokens", "error", err)
+	}
+	utils.WriteJSON(w, http.StatusOK, response)
+}
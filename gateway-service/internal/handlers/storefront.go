@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/service"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// StorefrontHandler serves the pages the gateway composes for storefronts
+type StorefrontHandler struct {
+	storefrontService *service.StorefrontService
+}
+
+// NewStorefrontHandler creates a new storefront handler
+func NewStorefrontHandler(storefrontService *service.StorefrontService) *StorefrontHandler {
+	return &StorefrontHandler{
+		storefrontService: storefrontService,
+	}
+}
+
+// Home returns everything a store's home page shows
+func (h *StorefrontHandler) Home(w http.ResponseWriter, r *http.Request) {
+	page, err := h.storefrontService.HomePage(r.Context(), chi.URLParam(r, "store_slug"))
+	if err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, page)
+}
+
+// ProductPage returns everything a product's page shows
+func (h *StorefrontHandler) ProductPage(w http.ResponseWriter, r *http.Request) {
+	page, err := h.storefrontService.ProductPage(r.Context(), chi.URLParam(r, "store_slug"), chi.URLParam(r, "slug"))
+	if err != nil {
+		utils.ErrorJSON(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, page)
+}
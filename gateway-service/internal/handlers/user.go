@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/robaa12/gatway-service/internal/config"
 	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/internal/tracing"
 	"github.com/robaa12/gatway-service/utils"
 )
 
@@ -34,7 +36,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user information from user-service
-	userInfo, err := h.getUserInfo(userID)
+	userInfo, err := h.getUserInfo(r.Context(), userID)
 	if err != nil {
 		utils.ErrorJSON(w, fmt.Errorf("error fetching user data: %v", err), http.StatusInternalServerError)
 		return
@@ -47,7 +49,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // getUserInfo retrieves user information from the user-service
-func (h *UserHandler) getUserInfo(userID string) ([]byte, error) {
+func (h *UserHandler) getUserInfo(ctx context.Context, userID string) ([]byte, error) {
 	// Get user-service URL from configuration
 	userServiceURL := h.cfg.Services["user-service"].URL
 
@@ -55,8 +57,12 @@ func (h *UserHandler) getUserInfo(userID string) ([]byte, error) {
 	requestURL := fmt.Sprintf("%s/user/%s", userServiceURL, userID)
 
 	// Create and execute the request
-	client := &http.Client{Timeout: h.cfg.Services["user-service"].Timeout}
-	resp, err := client.Get(requestURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build user-service request: %w", err)
+	}
+	client := &http.Client{Transport: tracing.Transport(http.DefaultTransport), Timeout: h.cfg.Services["user-service"].Timeout}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to user-service: %w", err)
 	}
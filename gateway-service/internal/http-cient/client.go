@@ -2,40 +2,67 @@ package httpcient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/robaa12/gatway-service/internal/identity"
 	"github.com/robaa12/gatway-service/internal/model"
+	"github.com/robaa12/gatway-service/internal/tracing"
 )
 
+// Names of the services a store is created in.
+const (
+	ServiceUser    = "user_service"
+	ServiceProduct = "product_service"
+	ServiceOrder   = "order_service"
+)
+
+// ErrStoreNotFound is returned when a service does not have the store, e.g. because an earlier
+// attempt already deleted it.
+var ErrStoreNotFound = errors.New("store not found")
+
+// ErrNotFound is returned when a service does not have what was asked for.
+var ErrNotFound = errors.New("not found")
+
+// ErrRejected is returned when a service answered a request with a 4xx, so it did not act on it.
+// Other errors, such as timeouts and 5xx answers, leave it unknown whether the request was applied.
+var ErrRejected = errors.New("request rejected")
+
 type Client struct {
 	userServiceURL    string
 	productServiceURL string
 	orderServiceURL   string
 	client            *http.Client
+	signer            *identity.Signer
 }
 
-// NewClient creates a new HTTP client for the gateway service
-func NewClient(userServiceURL, productServiceURL, orderServiceURL string) *Client {
+// NewClient creates a new HTTP client for the gateway service. Every request gives up after
+// timeout, so a service that hangs cannot hold up a saga or its compensation, which run without the
+// caller's deadline.
+func NewClient(userServiceURL, productServiceURL, orderServiceURL string, signer *identity.Signer, timeout time.Duration) *Client {
 	return &Client{
 		userServiceURL:    userServiceURL,
 		productServiceURL: productServiceURL,
 		orderServiceURL:   orderServiceURL,
-		client:            &http.Client{},
+		client:            &http.Client{Transport: tracing.Transport(http.DefaultTransport), Timeout: timeout},
+		signer:            signer,
 	}
 }
 
 // Helper method to create store in user service
-func (c *Client) CreateStoreInUserService(storeRequest *model.StoreRequest) (*model.StoreUserResponse, error) {
+func (c *Client) CreateStoreInUserService(ctx context.Context, storeRequest *model.StoreRequest) (*model.StoreUserResponse, error) {
 	requestBody, err := json.Marshal(storeRequest)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling request body failed: %w", err)
 	}
-	resp, respBody, err := c.sendRequest(http.MethodPost, c.userServiceURL+"/store", requestBody)
+	resp, respBody, err := c.sendRequest(ctx, http.MethodPost, c.userServiceURL+"/store", requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -48,29 +75,30 @@ func (c *Client) CreateStoreInUserService(storeRequest *model.StoreRequest) (*mo
 	// Parse the store response to extract the store IDAdd commentMore actions
 	var storeResponse model.StoreUserResponse
 	if err := json.Unmarshal(respBody, &storeResponse); err != nil {
-		log.Printf("Error parsing store response: %v", err)
+		slog.ErrorContext(ctx, "Error parsing store response", "error", err)
 		// Continue anyway to return the original response
 	}
 
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: user service returned status code %d", ErrRejected, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusCreated {
-		// Forward the error response from the user service
-
 		return nil, fmt.Errorf("user service returned status code %d", resp.StatusCode)
 	}
 	return &storeResponse, nil
 }
-func (c *Client) CreateStoreInServices(storeServicesRequest *model.ServiceCreateStoreRequest) (map[string]model.ServiceResponse, []string) {
+func (c *Client) CreateStoreInServices(ctx context.Context, storeServicesRequest *model.ServiceCreateStoreRequest) (map[string]model.ServiceResponse, []string) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	serviceResponses := make(map[string]model.ServiceResponse)
 	successfulServices := make([]string, 0)
 	services := []model.Service{
-		{Name: "product_service", URL: c.productServiceURL},
-		{Name: "order_service", URL: c.orderServiceURL},
+		{Name: ServiceProduct, URL: c.productServiceURL},
+		{Name: ServiceOrder, URL: c.orderServiceURL},
 		// Add more here
 	}
 	for _, svc := range services {
-		c.createStoreInService(&wg, &mu, svc.Name, svc.URL, storeServicesRequest, serviceResponses, &successfulServices)
+		c.createStoreInService(ctx, &wg, &mu, svc.Name, svc.URL, storeServicesRequest, serviceResponses, &successfulServices)
 	}
 
 	// Wait for all goroutines to complete
@@ -82,6 +110,7 @@ func (c *Client) CreateStoreInServices(storeServicesRequest *model.ServiceCreate
 
 // Helper function to create store in a service
 func (c *Client) createStoreInService(
+	ctx context.Context,
 	wg *sync.WaitGroup,
 	mu *sync.Mutex,
 	serviceName string,
@@ -100,7 +129,7 @@ func (c *Client) createStoreInService(
 		if err != nil {
 			errorMsg = "marshaling request body failed: " + err.Error()
 		} else {
-			resp, respBody, reqErr := c.sendRequest(http.MethodPost, url+"/stores", requestBody)
+			resp, respBody, reqErr := c.sendRequest(ctx, http.MethodPost, url+"/stores", requestBody)
 			if reqErr != nil {
 				errorMsg = reqErr.Error()
 			} else if resp != nil && resp.StatusCode != http.StatusCreated {
@@ -121,49 +150,28 @@ func (c *Client) createStoreInService(
 	}()
 }
 
-// Compensating transaction: Delete store from all successful services
-func (c *Client) CompensateStoreCreation(successfulServices []string, storeID uint) {
-	var wg sync.WaitGroup
-
-	for _, serviceName := range successfulServices {
-		wg.Add(1)
-		go func(service string) {
-			defer wg.Done()
-			var err error
-			switch service {
-			case "product_service":
-				err = c.deleteStoreFromService(c.productServiceURL+"/stores", storeID)
-			case "order_service":
-				err = c.deleteStoreFromService(c.orderServiceURL+"/stores", storeID)
-			}
-			if err != nil {
-				log.Printf("Compensation transaction failed for %s: %v", service, err)
-			} else {
-				log.Printf("Successfully performed compensating transaction for %s", service)
-			}
-		}(serviceName)
+// DeleteStoreFromService deletes a store from one of the services it is created in. Product and
+// order services only archive it, so RestoreStoreInService can bring it back.
+func (c *Client) DeleteStoreFromService(ctx context.Context, service string, storeID uint) error {
+	switch service {
+	case ServiceUser:
+		return c.deleteStoreFromService(ctx, c.userServiceURL+"/store", storeID)
+	case ServiceProduct:
+		return c.deleteStoreFromService(ctx, c.productServiceURL+"/stores", storeID)
+	case ServiceOrder:
+		return c.deleteStoreFromService(ctx, c.orderServiceURL+"/stores", storeID)
+	default:
+		return fmt.Errorf("unknown service %q", service)
 	}
-
-	// Always attempt to delete from user service as well
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err := c.deleteStoreFromService(c.userServiceURL+"/store", storeID)
-		if err != nil {
-			log.Printf("Failed to delete store from user service: %v", err)
-		} else {
-			log.Printf("Successfully deleted store from user service")
-		}
-	}()
-
-	wg.Wait()
 }
 
-// Compensating transaction: Delete store from any service
-func (c *Client) deleteStoreFromService(serviceURL string, storeID uint) error {
+// Helper function to delete a store from any service
+func (c *Client) deleteStoreFromService(ctx context.Context, serviceURL string, storeID uint) error {
 	url := fmt.Sprintf("%s/%d", serviceURL, storeID)
+	// The new store is not in the caller's token yet, so vouch for it explicitly
+	ctx = identity.WithStore(ctx, int(storeID))
 
-	resp, _, err := c.sendRequest(http.MethodDelete, url, nil)
+	resp, _, err := c.sendRequest(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -173,6 +181,9 @@ func (c *Client) deleteStoreFromService(serviceURL string, storeID uint) error {
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrStoreNotFound
+	}
 	if resp.StatusCode >= 300 {
 		return fmt.Errorf("delete store from service failed with status code %d", resp.StatusCode)
 	}
@@ -180,15 +191,94 @@ func (c *Client) deleteStoreFromService(serviceURL string, storeID uint) error {
 	return nil
 }
 
+// RestoreStoreInService brings back a store archived by DeleteStoreFromService; it undoes a store
+// deletion step. user-service deletes stores for good, so they cannot be restored there.
+func (c *Client) RestoreStoreInService(ctx context.Context, service string, storeID uint) error {
+	var serviceURL string
+	switch service {
+	case ServiceProduct:
+		serviceURL = c.productServiceURL
+	case ServiceOrder:
+		serviceURL = c.orderServiceURL
+	default:
+		return fmt.Errorf("stores cannot be restored in %q", service)
+	}
+
+	url := fmt.Sprintf("%s/stores/%d/restore", serviceURL, storeID)
+	resp, _, err := c.sendRequest(identity.WithStore(ctx, int(storeID)), http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrStoreNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("restore store in service failed with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ExportStore collects a store's products, orders and customers as the services return them.
+func (c *Client) ExportStore(ctx context.Context, storeID uint) (*model.StoreExport, error) {
+	export := &model.StoreExport{StoreID: storeID}
+	parts := []struct {
+		url  string
+		into *json.RawMessage
+	}{
+		{fmt.Sprintf("%s/stores/%d/products", c.productServiceURL, storeID), &export.Products},
+		{fmt.Sprintf("%s/stores/%d/orders", c.orderServiceURL, storeID), &export.Orders},
+		{fmt.Sprintf("%s/stores/%d/customers", c.orderServiceURL, storeID), &export.Customers},
+	}
+	for _, part := range parts {
+		data, err := c.fetchJSON(ctx, part.url)
+		if err != nil {
+			return nil, err
+		}
+		*part.into = data
+	}
+	export.ExportedAt = time.Now()
+	return export, nil
+}
+
+// fetchJSON returns the body of a GET request, or an empty list when the service has nothing to
+// return.
+func (c *Client) fetchJSON(ctx context.Context, url string) (json.RawMessage, error) {
+	data, err := c.getJSON(ctx, url)
+	if errors.Is(err, ErrNotFound) {
+		return json.RawMessage("[]"), nil
+	}
+	return data, err
+}
+
+// getJSON returns the body of a GET request, or ErrNotFound.
+func (c *Client) getJSON(ctx context.Context, url string) (json.RawMessage, error) {
+	resp, respBody, err := c.sendRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status code %d", url, resp.StatusCode)
+	}
+	if !json.Valid(respBody) {
+		return nil, fmt.Errorf("GET %s returned invalid JSON", url)
+	}
+	return respBody, nil
+}
+
 // Helper method to send HTTP requests
-func (c *Client) sendRequest(method, url string, body []byte) (*http.Response, []byte, error) {
+// The request carries ctx so the trace continues into the called service, and is signed with the
+// caller's identity from ctx.
+func (c *Client) sendRequest(ctx context.Context, method, url string, body []byte) (*http.Response, []byte, error) {
 	var req *http.Request
 	var err error
 
 	if body != nil {
-		req, err = http.NewRequest(method, url, bytes.NewBuffer(body))
+		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	} else {
-		req, err = http.NewRequest(method, url, nil)
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 	}
 
 	if err != nil {
@@ -196,6 +286,7 @@ func (c *Client) sendRequest(method, url string, body []byte) (*http.Response, [
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	c.signer.Sign(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -0,0 +1,87 @@
+package httpcient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/robaa12/gatway-service/internal/model"
+	"github.com/robaa12/gatway-service/internal/storeslug"
+)
+
+// userServiceResponse is the envelope user-service wraps its responses in.
+type userServiceResponse struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// GetStoreBySlug looks a store up in user-service, which owns store slugs.
+func (c *Client) GetStoreBySlug(ctx context.Context, slug string) (*model.Store, error) {
+	data, err := c.getUserServiceData(ctx, c.userServiceURL+"/store/slug/"+url.PathEscape(slug))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var store model.Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("parsing store response: %w", err)
+	}
+	return &store, nil
+}
+
+// StoreIDBySlug returns the ID of the store with slug, to resolve slugs with a storeslug.Resolver.
+func (c *Client) StoreIDBySlug(ctx context.Context, slug string) (int, error) {
+	store, err := c.GetStoreBySlug(ctx, slug)
+	if errors.Is(err, ErrStoreNotFound) {
+		return 0, storeslug.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(store.ID), nil
+}
+
+// GetActiveTheme returns the theme the store currently shows, or null if it has none.
+func (c *Client) GetActiveTheme(ctx context.Context, storeID uint) (json.RawMessage, error) {
+	return c.getUserServiceData(ctx, fmt.Sprintf("%s/store/theme/%d/active", c.userServiceURL, storeID))
+}
+
+// GetProductBySlug returns a product with its SKUs, related products and review statistics.
+func (c *Client) GetProductBySlug(ctx context.Context, storeID uint, slug string) (json.RawMessage, error) {
+	return c.getJSON(ctx, fmt.Sprintf("%s/stores/%d/products/slug/%s", c.productServiceURL, storeID, url.PathEscape(slug)))
+}
+
+// GetStoreProducts returns one page of the store's products.
+func (c *Client) GetStoreProducts(ctx context.Context, storeID uint, limit, offset int) (json.RawMessage, error) {
+	return c.getJSON(ctx, fmt.Sprintf("%s/stores/%d/products?limit=%d&offset=%d", c.productServiceURL, storeID, limit, offset))
+}
+
+// GetCategories returns the store's categories.
+func (c *Client) GetCategories(ctx context.Context, storeID uint) (json.RawMessage, error) {
+	return c.getJSON(ctx, fmt.Sprintf("%s/stores/%d/categories", c.productServiceURL, storeID))
+}
+
+// GetCollections returns the store's collections.
+func (c *Client) GetCollections(ctx context.Context, storeID uint) (json.RawMessage, error) {
+	return c.getJSON(ctx, fmt.Sprintf("%s/stores/%d/collections", c.productServiceURL, storeID))
+}
+
+// getUserServiceData returns the data of a user-service response.
+func (c *Client) getUserServiceData(ctx context.Context, url string) (json.RawMessage, error) {
+	body, err := c.getJSON(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var resp userServiceResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("GET %s returned an unexpected response: %w", url, err)
+	}
+	if resp.Data == nil {
+		return json.RawMessage("null"), nil
+	}
+	return resp.Data, nil
+}
@@ -0,0 +1,93 @@
+// Package httputil holds the response plumbing shared by the gateway middlewares that keep
+// responses to send them again later, such as the response cache and idempotency keys.
+package httputil
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+)
+
+// Recorder passes a response through to the client while keeping a copy of its status, headers
+// and body. Bodies larger than the limit are still sent but not kept.
+type Recorder struct {
+	http.ResponseWriter
+	limit    int
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+// NewRecorder records the response written to w, keeping at most limit bytes of body.
+func NewRecorder(w http.ResponseWriter, limit int) *Recorder {
+	return &Recorder{ResponseWriter: w, limit: limit}
+}
+
+func (rw *Recorder) WriteHeader(status int) {
+	if rw.header == nil {
+		rw.status = status
+		rw.header = rw.ResponseWriter.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *Recorder) Write(b []byte) (int, error) {
+	if rw.header == nil {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.overflow {
+		if rw.body.Len()+len(b) > rw.limit {
+			rw.overflow = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush proxied streams.
+func (rw *Recorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Status returns the status sent, which is a 200 when the handler wrote nothing.
+func (rw *Recorder) Status() int {
+	if rw.header == nil {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// SentHeader returns the headers as they were when the status was sent, or the current ones when
+// the handler wrote nothing.
+func (rw *Recorder) SentHeader() http.Header {
+	if rw.header == nil {
+		return rw.ResponseWriter.Header().Clone()
+	}
+	return rw.header
+}
+
+// Body returns the body kept, which is empty once it went over the limit.
+func (rw *Recorder) Body() []byte {
+	return rw.body.Bytes()
+}
+
+// Overflowed reports whether the body went over the limit and was not kept.
+func (rw *Recorder) Overflowed() bool {
+	return rw.overflow
+}
+
+// AddedHeaders returns the headers in after that were not already in before with the same values,
+// leaving out the named ones. Taking before just ahead of the handler leaves out the headers the
+// gateway's outer middlewares set again on every response.
+func AddedHeaders(before, after http.Header, ignore ...string) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if !slices.Contains(ignore, name) && !slices.Equal(before[name], values) {
+			added[name] = slices.Clone(values)
+		}
+	}
+	return added
+}
@@ -0,0 +1,110 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      func(w http.ResponseWriter)
+		wantStatus   int
+		wantBody     string
+		wantOverflow bool
+	}{
+		{
+			name: "status and body",
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":1}`))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":1}`,
+		},
+		{
+			name:       "body without a status",
+			handler:    func(w http.ResponseWriter) { w.Write([]byte("ok")) },
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "nothing written",
+			handler:    func(w http.ResponseWriter) {},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "second status ignored",
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusAccepted)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "body over the limit",
+			handler: func(w http.ResponseWriter) {
+				w.Write([]byte("0123456789"))
+				w.Write([]byte("0123456789"))
+			},
+			wantStatus:   http.StatusOK,
+			wantOverflow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rw := NewRecorder(w, 16)
+			tt.handler(rw)
+
+			if rw.Status() != tt.wantStatus || string(rw.Body()) != tt.wantBody || rw.Overflowed() != tt.wantOverflow {
+				t.Fatalf("recorded %d %q (overflowed %t), want %d %q (%t)",
+					rw.Status(), rw.Body(), rw.Overflowed(), tt.wantStatus, tt.wantBody, tt.wantOverflow)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("client got status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRecorderKeepsHeadersAsSent(t *testing.T) {
+	w := httptest.NewRecorder()
+	rw := NewRecorder(w, 1024)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Header().Set("X-Too-Late", "1")
+
+	if got := rw.SentHeader().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("sent Content-Type = %q", got)
+	}
+	if rw.SentHeader().Get("X-Too-Late") != "" {
+		t.Fatal("header set after the status was recorded as sent")
+	}
+}
+
+func TestAddedHeaders(t *testing.T) {
+	before := http.Header{
+		"X-Request-Id": {"req-1"},
+		"Vary":         {"Origin"},
+	}
+	after := http.Header{
+		"X-Request-Id":  {"req-1"},
+		"Vary":          {"Origin", "Accept-Encoding"},
+		"Content-Type":  {"application/json"},
+		"X-Cache":       {"MISS"},
+		"Cache-Control": {"max-age=60"},
+	}
+
+	got := AddedHeaders(before, after, "X-Cache")
+	want := http.Header{
+		"Vary":          {"Origin", "Accept-Encoding"},
+		"Content-Type":  {"application/json"},
+		"Cache-Control": {"max-age=60"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("AddedHeaders = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Package idempotency lets clients retry POST and PUT requests safely. The first response to a
+// request carrying an Idempotency-Key header is kept, and retries with the same key get that
+// response back instead of reaching the backend again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// lockTimeout is how long a key stays claimed by a request that has not finished. The request
+// renews the claim every lockRenewInterval while it runs, however long that is, so a key is only
+// taken over once the gateway handling it stopped.
+const (
+	lockTimeout       = time.Minute
+	lockRenewInterval = lockTimeout / 3
+)
+
+// Record is a claimed key. It is in progress until Completed is set along with the response.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Store keeps idempotency records. Keys are unique per scope, the identity that sent them.
+type Store interface {
+	// Begin claims rec's key. If a live record already holds it, that record is returned instead.
+	Begin(ctx context.Context, rec *Record) (*Record, error)
+	// Get returns the live record for the key, or nil.
+	Get(ctx context.Context, scope, key string) (*Record, error)
+	// Complete stores the response of a claimed record.
+	Complete(ctx context.Context, rec *Record) error
+	// Renew keeps the claim on a key whose request is still running for another lockTimeout.
+	Renew(ctx context.Context, scope, key string) error
+	// Release frees a key whose request did not finish, so it can be retried.
+	Release(ctx context.Context, scope, key string) error
+	Close() error
+}
+
+// OpenStore opens the store selected by cfg.Store.
+func OpenStore(cfg config.IdempotencyConfig) (Store, error) {
+	switch cfg.Store {
+	case config.AuthStoreMemory:
+		return NewMemoryStore(), nil
+	case config.AuthStorePostgres:
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q", cfg.Store)
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, errors.New("postgres idempotency store needs a database url")
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to idempotency store: %w", err)
+	}
+	if err := db.AutoMigrate(&idempotencyRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to run idempotency store migration: %w", err)
+	}
+	return NewPostgresStore(db), nil
+}
+
+type recordKey struct {
+	scope string
+	key   string
+}
+
+// MemoryStore keeps records in process memory, so they are neither shared between replicas nor
+// kept across restarts.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[recordKey]Record
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[recordKey]Record)}
+}
+
+func (m *MemoryStore) Begin(_ context.Context, rec *Record) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+	k := recordKey{rec.Scope, rec.Key}
+	if existing, ok := m.records[k]; ok && now.Before(existing.ExpiresAt) {
+		return &existing, nil
+	}
+	rec.ExpiresAt = now.Add(lockTimeout)
+	m.records[k] = *rec
+	return nil, nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, scope, key string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[recordKey{scope, key}]
+	if !ok || !time.Now().Before(rec.ExpiresAt) {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (m *MemoryStore) Complete(_ context.Context, rec *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records[recordKey{rec.Scope, rec.Key}] = *rec
+	return nil
+}
+
+func (m *MemoryStore) Renew(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := recordKey{scope, key}
+	if rec, ok := m.records[k]; ok && !rec.Completed {
+		rec.ExpiresAt = time.Now().Add(lockTimeout)
+		m.records[k] = rec
+	}
+	return nil
+}
+
+func (m *MemoryStore) Release(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := recordKey{scope, key}
+	if rec, ok := m.records[k]; ok && !rec.Completed {
+		delete(m.records, k)
+	}
+	return nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// sweep drops expired records, at most once a minute.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for k, rec := range m.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(m.records, k)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/httputil"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+const (
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed marks a response that was stored earlier rather than produced for this request
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// maxRequestBody bounds the body read to tell retries from different requests
+	maxRequestBody = 10 << 20
+	// maxStoredBody bounds the responses kept; larger ones are sent but not replayed
+	maxStoredBody = 1 << 20
+	pollInterval  = 100 * time.Millisecond
+)
+
+// Guard replays the stored response of POST and PUT requests retried with the same Idempotency-Key.
+type Guard struct {
+	store Store
+	ttl   time.Duration
+	wait  time.Duration
+}
+
+func NewGuard(store Store, cfg config.IdempotencyConfig) *Guard {
+	return &Guard{
+		store: store,
+		ttl:   cfg.TTL,
+		wait:  cfg.Wait,
+	}
+}
+
+// Middleware runs after authentication, so keys are kept per user, per API key, or per client
+// address for anonymous callers. A key reused for a different request gets a 422, and a retry of a request
+// still running waits for its response or gets a 409. Responses with a 5xx status are not kept, so
+// those requests can be retried.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("could not read request body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &Record{
+			Scope:       scope(r),
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+		}
+		deadline := time.Now().Add(g.wait)
+		for {
+			existing, err := g.store.Begin(r.Context(), rec)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to claim idempotency key", "error", err)
+				_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("idempotency store unavailable"))
+				return
+			}
+			switch {
+			case existing == nil:
+				g.serve(w, r, next, rec)
+				return
+			case existing.Fingerprint != rec.Fingerprint:
+				_ = utils.ErrorJSON(w, apperrors.NewUnprocessableEntityError("Idempotency-Key was already used for a different request"))
+				return
+			case existing.Completed:
+				replay(w, existing)
+				return
+			case !time.Now().Before(deadline):
+				w.Header().Set("Retry-After", "1")
+				_ = utils.ErrorJSON(w, apperrors.NewConflictError("a request with this Idempotency-Key is still in progress"))
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	})
+}
+
+// serve passes a request that claimed its key to next and keeps the response.
+func (g *Guard) serve(w http.ResponseWriter, r *http.Request, next http.Handler, rec *Record) {
+	ctx := context.WithoutCancel(r.Context())
+	stored := false
+	defer func() {
+		if stored {
+			return
+		}
+		if err := g.store.Release(ctx, rec.Scope, rec.Key); err != nil {
+			slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+		}
+	}()
+
+	// Requests such as store creation may run longer than a claim lasts
+	renewing := make(chan struct{})
+	defer close(renewing)
+	go g.renew(ctx, rec, renewing)
+
+	before := w.Header().Clone()
+	rw := httputil.NewRecorder(w, maxStoredBody)
+	next.ServeHTTP(rw, r)
+	if rw.Status() >= http.StatusInternalServerError || rw.Overflowed() {
+		return
+	}
+
+	rec.Completed = true
+	rec.StatusCode = rw.Status()
+	rec.Header = httputil.AddedHeaders(before, rw.SentHeader())
+	rec.Body = rw.Body()
+	rec.ExpiresAt = time.Now().Add(g.ttl)
+	if err := g.store.Complete(ctx, rec); err != nil {
+		slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+		return
+	}
+	stored = true
+}
+
+// renew keeps rec's key claimed until done is closed.
+func (g *Guard) renew(ctx context.Context, rec *Record, done <-chan struct{}) {
+	ticker := time.NewTicker(lockRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := g.store.Renew(ctx, rec.Scope, rec.Key); err != nil {
+				slog.ErrorContext(ctx, "Failed to renew idempotency key", "error", err)
+			}
+		}
+	}
+}
+
+// scope is who a key belongs to. Anonymous callers have nothing else to tell them apart, so each
+// client address gets its own keys rather than one caller replaying another's response.
+func scope(r *http.Request) string {
+	caller := auth.CallerScope(r.Context())
+	if caller != auth.AnonymousScope {
+		return caller
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return caller + ":" + host
+}
+
+func replay(w http.ResponseWriter, rec *Record) {
+	for name, values := range rec.Header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(rec.StatusCode)
+	_, _ = w.Write(rec.Body)
+}
+
+// fingerprint identifies the request a key was first used for.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
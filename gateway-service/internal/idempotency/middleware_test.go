@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+func TestMiddlewareKeepsAnonymousClientsApart(t *testing.T) {
+	calls := 0
+	guard := NewGuard(NewMemoryStore(), config.IdempotencyConfig{TTL: time.Hour, Wait: time.Second})
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(strconv.Itoa(calls)))
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"total":10}`))
+		r.RemoteAddr = remoteAddr
+		r.Header.Set(HeaderKey, "checkout-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send("203.0.113.7:52000")
+	retry := send("203.0.113.7:52001")
+	other := send("198.51.100.4:40000")
+
+	if retry.Header().Get(HeaderReplayed) != "true" || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry from the same client = %q (replayed %q), want %q replayed", retry.Body, retry.Header().Get(HeaderReplayed), first.Body)
+	}
+	if other.Header().Get(HeaderReplayed) != "" || other.Body.String() == first.Body.String() {
+		t.Fatalf("another anonymous client got the first client's response %q", other.Body)
+	}
+	if calls != 2 {
+		t.Fatalf("backend called %d times, want 2", calls)
+	}
+}
+
+func TestMemoryStoreRenew(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if existing, err := store.Begin(ctx, &Record{Scope: "user:1", Key: "k", Fingerprint: "f"}); err != nil || existing != nil {
+		t.Fatalf("Begin = %v, %v", existing, err)
+	}
+
+	// A claim about to run out is renewed for another lockTimeout
+	k := recordKey{"user:1", "k"}
+	rec := store.records[k]
+	rec.ExpiresAt = time.Now().Add(time.Second)
+	store.records[k] = rec
+	if err := store.Renew(ctx, "user:1", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if left := time.Until(store.records[k].ExpiresAt); left < lockTimeout-time.Second {
+		t.Fatalf("claim expires in %s after renewing, want about %s", left, lockTimeout)
+	}
+
+	// A completed record keeps the expiry of its response
+	rec = store.records[k]
+	rec.Completed = true
+	rec.ExpiresAt = time.Now().Add(time.Hour)
+	store.records[k] = rec
+	if err := store.Renew(ctx, "user:1", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.records[k].ExpiresAt.Equal(rec.ExpiresAt) {
+		t.Fatal("renewing changed the expiry of a completed record")
+	}
+}
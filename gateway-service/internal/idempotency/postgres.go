@@ -0,0 +1,161 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const postgresCleanupInterval = time.Hour
+
+type idempotencyRecord struct {
+	Scope       string `gorm:"primaryKey"`
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string `gorm:"not null"`
+	Completed   bool   `gorm:"not null"`
+	StatusCode  int
+	Header      string    `gorm:"type:text"`
+	Body        []byte    `gorm:"type:bytea"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (idempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+func toRecord(rec *Record) (*idempotencyRecord, error) {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyRecord{
+		Scope:       rec.Scope,
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		Completed:   rec.Completed,
+		StatusCode:  rec.StatusCode,
+		Header:      string(header),
+		Body:        rec.Body,
+		ExpiresAt:   rec.ExpiresAt,
+	}, nil
+}
+
+func (r idempotencyRecord) toRecord() (*Record, error) {
+	rec := &Record{
+		Scope:       r.Scope,
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		StatusCode:  r.StatusCode,
+		Body:        r.Body,
+		ExpiresAt:   r.ExpiresAt,
+	}
+	if r.Header != "" {
+		if err := json.Unmarshal([]byte(r.Header), &rec.Header); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Header == nil {
+		rec.Header = http.Header{}
+	}
+	return rec, nil
+}
+
+// PostgresStore shares records between every gateway replica, so a retry is recognised whichever
+// replica it reaches. Expired records are cleaned up in the background until Close.
+type PostgresStore struct {
+	db          *gorm.DB
+	stopCleanup context.CancelFunc
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &PostgresStore{db: db, stopCleanup: cancel}
+	go p.cleanup(ctx)
+	return p
+}
+
+func (p *PostgresStore) Begin(ctx context.Context, rec *Record) (*Record, error) {
+	db := p.db.WithContext(ctx)
+	// An expired record no longer holds its key
+	if err := db.Where("scope = ? AND key = ? AND expires_at <= ?", rec.Scope, rec.Key, time.Now()).
+		Delete(&idempotencyRecord{}).Error; err != nil {
+		return nil, err
+	}
+
+	rec.ExpiresAt = time.Now().Add(lockTimeout)
+	row, err := toRecord(rec)
+	if err != nil {
+		return nil, err
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+	return p.Get(ctx, rec.Scope, rec.Key)
+}
+
+func (p *PostgresStore) Get(ctx context.Context, scope, key string) (*Record, error) {
+	var rows []idempotencyRecord
+	err := p.db.WithContext(ctx).
+		Where("scope = ? AND key = ? AND expires_at > ?", scope, key, time.Now()).
+		Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return rows[0].toRecord()
+}
+
+func (p *PostgresStore) Complete(ctx context.Context, rec *Record) error {
+	row, err := toRecord(rec)
+	if err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Save(row).Error
+}
+
+func (p *PostgresStore) Renew(ctx context.Context, scope, key string) error {
+	return p.db.WithContext(ctx).Model(&idempotencyRecord{}).
+		Where("scope = ? AND key = ? AND NOT completed", scope, key).
+		Update("expires_at", time.Now().Add(lockTimeout)).Error
+}
+
+func (p *PostgresStore) Release(ctx context.Context, scope, key string) error {
+	return p.db.WithContext(ctx).
+		Where("scope = ? AND key = ? AND NOT completed", scope, key).
+		Delete(&idempotencyRecord{}).Error
+}
+
+func (p *PostgresStore) Close() error {
+	p.stopCleanup()
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// cleanup deletes expired records once an hour until ctx is cancelled.
+func (p *PostgresStore) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(postgresCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&idempotencyRecord{}).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to clean up expired idempotency keys", "error", err)
+			}
+		}
+	}
+}
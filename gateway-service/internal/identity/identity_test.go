@@ -0,0 +1,100 @@
+package identity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// knownSignature is the signature the services' verifiers test against, so both sides of the
+// format are pinned to the same value.
+const knownSignature = "e1741847cfd2687c2fa4e3774f58e20423cb0d458df6c70f00899344530fb2bc"
+
+func TestSignatureFormat(t *testing.T) {
+	s := NewSigner("test-secret")
+	got := s.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000")
+	if got != knownSignature {
+		t.Fatalf("signature = %s, want %s", got, knownSignature)
+	}
+}
+
+func TestSignatureCoversEveryField(t *testing.T) {
+	s := NewSigner("test-secret")
+	base := s.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000")
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"method", s.signature("DELETE", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000")},
+		{"path", s.signature("PUT", "/orders/8/items/3", "42", "1,2", "req-1", "1700000000")},
+		{"user", s.signature("PUT", "/orders/7/items/3", "43", "1,2", "req-1", "1700000000")},
+		{"stores", s.signature("PUT", "/orders/7/items/3", "42", "1,2,3", "req-1", "1700000000")},
+		{"request id", s.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-2", "1700000000")},
+		{"timestamp", s.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000001")},
+		{"secret", NewSigner("other-secret").signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got == base {
+				t.Fatalf("changing the %s left the signature unchanged", tt.name)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        Identity
+		requestID string
+		wantUser  string
+		wantStore string
+	}{
+		{name: "anonymous"},
+		{name: "user", id: Identity{UserID: 42}, requestID: "req-1", wantUser: "42"},
+		{name: "user with stores", id: Identity{UserID: 42, StoreIDs: []int{1, 2}}, wantUser: "42", wantStore: "1,2"},
+		{name: "api key", id: Identity{StoreIDs: []int{9}}, wantStore: "9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := With(context.Background(), tt.id)
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, tt.requestID)
+			r := httptest.NewRequest(http.MethodPost, "/stores/1/products", nil).WithContext(ctx)
+			// Identity headers sent by the client must never reach the service
+			r.Header.Set(HeaderUserID, "1")
+			r.Header.Set(HeaderStoreIDs, "1,2,3")
+			r.Header.Set(HeaderSignature, "forged")
+
+			s := NewSigner("test-secret")
+			s.Sign(r)
+
+			h := r.Header
+			if got := h.Get(HeaderUserID); got != tt.wantUser {
+				t.Errorf("%s = %q, want %q", HeaderUserID, got, tt.wantUser)
+			}
+			if got := h.Get(HeaderStoreIDs); got != tt.wantStore {
+				t.Errorf("%s = %q, want %q", HeaderStoreIDs, got, tt.wantStore)
+			}
+			if got := h.Get(HeaderRequestID); got != tt.requestID {
+				t.Errorf("%s = %q, want %q", HeaderRequestID, got, tt.requestID)
+			}
+			want := s.signature(http.MethodPost, "/stores/1/products", tt.wantUser, tt.wantStore, tt.requestID, h.Get(HeaderTimestamp))
+			if got := h.Get(HeaderSignature); got != want {
+				t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+			}
+		})
+	}
+}
+
+func TestWithStore(t *testing.T) {
+	ctx := With(context.Background(), Identity{UserID: 1, StoreIDs: []int{1}})
+	ctx = WithStore(ctx, 2)
+	ctx = WithStore(ctx, 2)
+	if got := FromContext(ctx).StoreIDs; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("StoreIDs = %v, want [1 2]", got)
+	}
+}
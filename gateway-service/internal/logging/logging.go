@@ -0,0 +1,176 @@
+// Package logging sets up the structured logger. Every record carries the id of the request it was
+// logged for, which the gateway passes on to the services, and sensitive fields are redacted
+// before anything is written.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are redacted wherever they appear in a record, as attribute names, header names
+// or fields of logged values. Keys are compared in lower case without "-" and "_", so "apikey"
+// also covers "X-API-Key" and "token" covers "refresh_token". The list is the same in every
+// service, as pinned by TestSensitiveKeys.
+var sensitiveKeys = []string{"password", "authorization", "token", "secret", "apikey", "cookie"}
+
+// Setup installs the default logger for service. LOG_FORMAT is "json" (the default) or "text", and
+// LOG_LEVEL is "debug", "info" (the default), "warn" or "error". Output of the standard log
+// package goes through the same logger.
+func Setup(service string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	switch format := getEnv("LOG_FORMAT", "json"); format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}).With("service", service))
+	return nil
+}
+
+// Middleware logs every request once it has been served. It must run after middleware.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			slog.Log(r.Context(), level, "Request served",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_ip", r.RemoteAddr)
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// contextHandler adds the request id from the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if strings.HasPrefix(a.Value.String(), "Bearer ") {
+			return slog.String(a.Key, redacted)
+		}
+	case slog.KindAny:
+		a.Value = slog.AnyValue(redact(a.Value.Any()))
+	}
+	return a
+}
+
+// redact returns v with its sensitive fields replaced. Values other than errors and headers are
+// logged as their JSON encoding, so any field a struct or map would marshal is checked.
+func redact(v any) any {
+	switch v := v.(type) {
+	case error, fmt.Stringer:
+		return v
+	case http.Header:
+		header := make(http.Header, len(v))
+		for name, values := range v {
+			if isSensitive(name) {
+				values = []string{redacted}
+			}
+			header[name] = values
+		}
+		return header
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v
+	}
+	return redactJSON(decoded)
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
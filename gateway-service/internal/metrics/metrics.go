@@ -0,0 +1,144 @@
+// Package metrics records HTTP request metrics for Prometheus and serves them on /metrics.
+//
+// The gateway and every service guard /metrics the same way: scrapes must send the deployment's
+// METRICS_TOKEN as a bearer token, and the endpoint answers 404 while no token is set. A Prometheus
+// scrape job for any of them looks like
+//
+//	scrape_configs:
+//	  - job_name: gateway
+//	    authorization:
+//	      credentials_file: /etc/prometheus/metrics-token
+//	    static_configs:
+//	      - targets: ["gateway-service:8080"]
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are registered globally so they keep counting across config reloads.
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "HTTP requests handled by the gateway, by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "Time taken to answer HTTP requests, by route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	upstreamRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_requests_total",
+		Help: "Requests proxied to upstream services, by service and status code.",
+	}, []string{"service", "method", "status"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_upstream_request_duration_seconds",
+		Help:    "Time taken by upstream services to answer proxied requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	routeBackendRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_route_backend_requests_total",
+		Help: "Requests of split routes, by the service version they were sent to, why, and status code.",
+	}, []string{"route", "service", "reason", "status"})
+
+	mirrorRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_mirror_requests_total",
+		Help: "Copies of requests sent to mirror services, by status code, or dropped and why.",
+	}, []string{"route", "service", "outcome"})
+
+	deprecatedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_deprecated_requests_total",
+		Help: "Requests to deprecated routes, by route, to see who still calls them before their sunset.",
+	}, []string{"route"})
+)
+
+// Handler serves the Prometheus scrape endpoint to requests bearing token. Metrics name routes
+// and error rates, so they are not served at all while token is empty.
+func Handler(token string) http.Handler {
+	scrape := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "metrics token required", http.StatusUnauthorized)
+			return
+		}
+		scrape.ServeHTTP(w, r)
+	})
+}
+
+// Middleware records request count and latency labelled with chi's route pattern, so
+// /stores/1/products and /stores/2/products share one series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+		requestsTotal.WithLabelValues(r.Method, route, statusLabel(ww.Status())).Inc()
+		requestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveUpstream records one proxied call to an upstream service.
+func ObserveUpstream(service, method string, status int, duration time.Duration) {
+	upstreamRequestsTotal.WithLabelValues(service, method, statusLabel(status)).Inc()
+	upstreamDuration.WithLabelValues(service, method).Observe(duration.Seconds())
+}
+
+// ObserveRouteBackend records which version of its service a request of a split route was sent to,
+// so versions can be compared before one is promoted.
+func ObserveRouteBackend(route, service, reason string, status int) {
+	routeBackendRequestsTotal.WithLabelValues(route, service, reason, statusLabel(status)).Inc()
+}
+
+// ObserveMirror records the status code a mirror service answered a copied request with.
+func ObserveMirror(route, service string, status int) {
+	mirrorRequestsTotal.WithLabelValues(route, service, statusLabel(status)).Inc()
+}
+
+// MirrorDropped records a request that was not copied to its mirror service, and why.
+func MirrorDropped(route, service, reason string) {
+	mirrorRequestsTotal.WithLabelValues(route, service, reason).Inc()
+}
+
+// ObserveDeprecated records a request to a deprecated route.
+func ObserveDeprecated(route string) {
+	deprecatedRequestsTotal.WithLabelValues(route).Inc()
+}
+
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "unmatched"
+	}
+	return rctx.RoutePattern()
+}
+
+func statusLabel(status int) string {
+	// Handlers that write a body without calling WriteHeader answer 200
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return strconv.Itoa(status)
+}
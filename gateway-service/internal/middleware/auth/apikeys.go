@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyPrefix starts every API key, so the auth middleware can tell one apart from a JWT.
+const apiKeyPrefix = "sk_"
+
+// apiKeyTouchInterval is how stale a key's last-used time may get before a request updates it.
+const apiKeyTouchInterval = time.Minute
+
+// APIKey lets a store's integrations call the gateway without a user login. Only a hash of the
+// secret is stored; the secret itself is shown once, when the key is created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	StoreID    int        `json:"store_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyStore keeps store API keys.
+type APIKeyStore interface {
+	Create(ctx context.Context, key APIKey, hash string) error
+	// FindByHash returns the key whose secret hashes to hash, or nil if there is none.
+	FindByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context, storeID int) ([]APIKey, error)
+	// Revoke marks a key revoked and reports whether an active key was found.
+	Revoke(ctx context.Context, storeID int, id string) (bool, error)
+	// Touch records that the key was used at the given time.
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// NewAPIKey creates a key for the store along with its secret and the hash the secret is stored under.
+func NewAPIKey(storeID, createdBy int, name string, scopes []string) (APIKey, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return APIKey{}, "", "", err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	key := APIKey{
+		ID:        newTokenID(),
+		StoreID:   storeID,
+		Name:      name,
+		Prefix:    secret[:len(apiKeyPrefix)+6],
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	return key, secret, HashAPIKey(secret), nil
+}
+
+// HashAPIKey hashes a key secret for storage and lookup. Secrets are random 256 bit values, so a
+// plain SHA-256 is enough; there is nothing to brute force.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than a JWT.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with, if any.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value("api_key").(*APIKey)
+	return key, ok
+}
+
+// MemoryAPIKeyStore keeps API keys in process memory; they are lost on restart.
+type MemoryAPIKeyStore struct {
+	mu     sync.RWMutex
+	keys   map[string]*APIKey
+	hashes map[string]string
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:   make(map[string]*APIKey),
+		hashes: make(map[string]string),
+	}
+}
+
+func (m *MemoryAPIKeyStore) Create(_ context.Context, key APIKey, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[key.ID] = &key
+	m.hashes[hash] = key.ID
+	return nil
+}
+
+func (m *MemoryAPIKeyStore) FindByHash(_ context.Context, hash string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[m.hashes[hash]]
+	if !ok {
+		return nil, nil
+	}
+	found := *key
+	return &found, nil
+}
+
+func (m *MemoryAPIKeyStore) List(_ context.Context, storeID int) ([]APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := []APIKey{}
+	for _, key := range m.keys {
+		if key.StoreID == storeID {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (m *MemoryAPIKeyStore) Revoke(_ context.Context, storeID int, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if !ok || key.StoreID != storeID || key.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return true, nil
+}
+
+func (m *MemoryAPIKeyStore) Touch(_ context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if key, ok := m.keys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRecord struct {
+	ID         string `gorm:"primaryKey"`
+	StoreID    int    `gorm:"not null;index"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	Hash       string `gorm:"not null;uniqueIndex"`
+	Scopes     string `gorm:"not null"`
+	CreatedBy  int    `gorm:"not null"`
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (apiKeyRecord) TableName() string {
+	return "store_api_keys"
+}
+
+func (k apiKeyRecord) toAPIKey() APIKey {
+	var scopes []string
+	if k.Scopes != "" {
+		scopes = strings.Split(k.Scopes, ",")
+	}
+	return APIKey{
+		ID:         k.ID,
+		StoreID:    k.StoreID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+// PostgresAPIKeyStore shares API keys between every gateway replica.
+type PostgresAPIKeyStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresAPIKeyStore(db *gorm.DB) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+func (p *PostgresAPIKeyStore) Create(ctx context.Context, key APIKey, hash string) error {
+	return p.db.WithContext(ctx).Create(&apiKeyRecord{
+		ID:        key.ID,
+		StoreID:   key.StoreID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Hash:      hash,
+		Scopes:    strings.Join(key.Scopes, ","),
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt,
+	}).Error
+}
+
+func (p *PostgresAPIKeyStore) FindByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var records []apiKeyRecord
+	if err := p.db.WithContext(ctx).Where("hash = ?", hash).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	key := records[0].toAPIKey()
+	return &key, nil
+}
+
+func (p *PostgresAPIKeyStore) List(ctx context.Context, storeID int) ([]APIKey, error) {
+	var records []apiKeyRecord
+	if err := p.db.WithContext(ctx).Where("store_id = ?", storeID).Order("created_at").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, record.toAPIKey())
+	}
+	return keys, nil
+}
+
+func (p *PostgresAPIKeyStore) Revoke(ctx context.Context, storeID int, id string) (bool, error) {
+	result := p.db.WithContext(ctx).Model(&apiKeyRecord{}).
+		Where("id = ? AND store_id = ? AND revoked_at IS NULL", id, storeID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (p *PostgresAPIKeyStore) Touch(ctx context.Context, id string, at time.Time) error {
+	return p.db.WithContext(ctx).Model(&apiKeyRecord{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
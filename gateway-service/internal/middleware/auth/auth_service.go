@@ -3,16 +3,26 @@ package auth
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/robaa12/gatway-service/internal/access"
 	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/storeslug"
+	"github.com/robaa12/gatway-service/internal/tracing"
 	"github.com/robaa12/gatway-service/utils"
 )
 
@@ -20,8 +30,16 @@ type Service struct {
 	jwtService  *JWTService
 	userService config.ServiceConfig
 	client      *http.Client
+	revocations RevocationStore
+	staff       StaffStore
+	apiKeys     APIKeyStore
+	logins      *loginGuard
+	slugs       *storeslug.Resolver
 }
 
+// errInvalidCredentials is returned when user-service rejects an email and password.
+var errInvalidCredentials = errors.New("invalid credentials")
+
 type (
 	LoginRequest struct {
 		Email    string `json:"email"`
@@ -65,6 +83,8 @@ type (
 		FirstName string  `json:"first_name"`
 		LastName  string  `json:"last_name"`
 		Stores    []Store `json:"stores"`
+		IsActive  bool    `json:"isActive"`
+		IsBanned  bool    `json:"is_banned"`
 	}
 	LoginAPIResponse struct {
 		Message string   `json:"message"`
@@ -73,11 +93,20 @@ type (
 	}
 )
 
-func NewAuthService(cfg *config.Config) *Service {
+func NewAuthService(cfg *config.Config, jwtService *JWTService, stores *Stores, slugs *storeslug.Resolver) *Service {
 	return &Service{
-		jwtService:  NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenExp, cfg.Auth.RefreshTokenExp),
+		jwtService:  jwtService,
 		userService: cfg.Services["user-service"],
-		client:      &http.Client{Timeout: 5 * time.Second},
+		client:      &http.Client{Transport: tracing.Transport(http.DefaultTransport), Timeout: 5 * time.Second},
+		revocations: stores.Revocations,
+		staff:       stores.Staff,
+		apiKeys:     stores.APIKeys,
+		logins: &loginGuard{
+			attempts: stores.LoginAttempts,
+			audit:    stores.LoginAudit,
+			cfg:      cfg.Login,
+		},
+		slugs: slugs,
 	}
 }
 
@@ -90,12 +119,41 @@ func (s *Service) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userData, err := s.authenticateUser(loginReq)
+	ctx := r.Context()
+	attempt := newAttempt(loginReq.Email, r)
+	wait, err := s.logins.wait(ctx, attempt)
 	if err != nil {
-		_ = utils.ErrorJSON(w, err, http.StatusUnauthorized)
+		slog.ErrorContext(ctx, "Failed to check failed logins", "error", err)
+		_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("login is unavailable, try again later"))
+		return
+	}
+	if wait > 0 {
+		slog.WarnContext(ctx, "Login refused after repeated failures", "email", attempt.email, "ip", attempt.ip, "retry_after", wait)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		_ = utils.ErrorJSON(w, apperrors.NewTooManyRequestsError("too many failed login attempts, try again later"))
 		return
 	}
 
+	userData, err := s.authenticateUser(ctx, loginReq)
+	if errors.Is(err, errInvalidCredentials) {
+		s.logins.fail(ctx, attempt)
+		_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError(err.Error()))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to authenticate user", "error", err)
+		_ = utils.ErrorJSON(w, apperrors.NewBadGatewayError("could not reach the user service"))
+		return
+	}
+
+	if event, err := accountStatus(userData, LoginEventBannedLogin, LoginEventInactiveLogin); err != nil {
+		slog.WarnContext(ctx, "Login refused for account", "user_id", userData.ID, "event", event)
+		s.logins.record(ctx, LoginEvent{Event: event, Email: attempt.email, IP: attempt.ip, UserID: userData.ID})
+		_ = utils.ErrorJSON(w, err)
+		return
+	}
+	s.logins.succeed(ctx, attempt)
+
 	response, err := s.generateLoginResponse(userData)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusInternalServerError)
@@ -121,12 +179,22 @@ func (s *Service) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log the incoming request
-	log.Printf("Registration request received: %+v", registerReq)
+	slog.DebugContext(r.Context(), "Registration request received", "email", registerReq.Email)
 
 	// Basic validation
-	if registerReq.Email == "" || registerReq.Password == "" || registerReq.FirstName == "" || registerReq.LastName == "" {
-		_ = utils.ErrorJSON(w, errors.New("email, password, firstName, and lastName are required"), http.StatusBadRequest)
+	var missing []apperrors.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"email", registerReq.Email},
+		{"password", registerReq.Password},
+		{"firstName", registerReq.FirstName},
+		{"lastName", registerReq.LastName},
+	} {
+		if field.value == "" {
+			missing = append(missing, apperrors.FieldError{Field: field.name, Message: field.name + " is required"})
+		}
+	}
+	if len(missing) > 0 {
+		_ = utils.ErrorJSON(w, apperrors.NewValidationError("email, password, firstName, and lastName are required", missing...))
 		return
 	}
 
@@ -137,13 +205,10 @@ func (s *Service) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log the outgoing request to user service
-	log.Printf("Sending to user service: %s", string(jsonData))
-
 	// Register the user
-	userData, err := s.registerUser(bytes.NewReader(jsonData))
+	userData, err := s.registerUser(r.Context(), bytes.NewReader(jsonData))
 	if err != nil {
-		log.Printf("Registration error: %v", err)
+		slog.WarnContext(r.Context(), "Registration failed", "error", err)
 		statusCode := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "already exists") {
 			statusCode = http.StatusConflict
@@ -154,8 +219,19 @@ func (s *Service) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log successful registration
-	log.Printf("User registered successfully: %+v", userData)
+	slog.InfoContext(r.Context(), "User registered successfully", "user_id", userData.ID)
+
+	// user-service creates accounts inactive until their email is verified, and inactive accounts
+	// may not hold tokens, so they log in once verified instead
+	if !userData.IsActive {
+		_ = utils.WriteJSON(w, http.StatusCreated, map[string]any{
+			"message":               "Registered, verify your email to log in",
+			"user_id":               userData.ID,
+			"email":                 userData.Email,
+			"verification_required": true,
+		})
+		return
+	}
 
 	// Generate tokens
 	response, err := s.generateLoginResponse(userData)
@@ -182,7 +258,17 @@ func (s *Service) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenResponse, err := s.generateNewTokenPair(claims.UserID, claims.StoresID)
+	if err := s.checkAccount(r, claims.UserID); err != nil {
+		_ = utils.ErrorJSON(w, err)
+		return
+	}
+
+	if err := s.rotateRefreshToken(r.Context(), claims); err != nil {
+		_ = utils.ErrorJSON(w, err)
+		return
+	}
+
+	tokenResponse, err := s.generateNewTokenPair(claims.UserID, claims.StoresID, claims.FamilyID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusInternalServerError)
 		return
@@ -191,14 +277,64 @@ func (s *Service) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	_ = utils.WriteJSON(w, http.StatusOK, tokenResponse)
 }
 
+// JWKS publishes the public keys tokens are verified with, so other services can check them.
+func (s *Service) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = utils.WriteJSON(w, http.StatusOK, s.jwtService.keys.JWKS())
+}
+
+// Logout ends the session the access token belongs to. Revoking its token family also invalidates
+// the refresh token issued alongside it.
+func (s *Service) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*Claims)
+	if !ok {
+		_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("authorization required"))
+		return
+	}
+
+	if claims.FamilyID != "" {
+		if _, err := s.revocations.Revoke(r.Context(), familyKey(claims.FamilyID), s.familyExpiry()); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to revoke token family", "error", err)
+			_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("could not log out, try again later"))
+			return
+		}
+	}
+	if claims.Id != "" {
+		if _, err := s.revocations.Revoke(r.Context(), tokenKey(claims.Id), time.Unix(claims.ExpiresAt, 0)); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to revoke access token", "error", err)
+			_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("could not log out, try again later"))
+			return
+		}
+	}
+
+	_ = utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+// LogoutAll ends every session of the user by refusing all tokens issued to them until now.
+func (s *Service) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*Claims)
+	if !ok {
+		_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("authorization required"))
+		return
+	}
+
+	if err := s.revocations.RevokeUser(r.Context(), claims.UserID, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke tokens of user", "user_id", claims.UserID, "error", err)
+		_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("could not log out, try again later"))
+		return
+	}
+
+	_ = utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logged out of all sessions"})
+}
+
 // validateCredentials validates the user credentials
-func (s *Service) authenticateUser(login LoginRequest) (*UserData, error) {
+func (s *Service) authenticateUser(ctx context.Context, login LoginRequest) (*UserData, error) {
 	reqBody, err := json.Marshal(login)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.makeUserServiceRequest("POST", "/user/login", reqBody)
+	resp, err := s.makeUserServiceRequest(ctx, "POST", "/user/login", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -209,10 +345,11 @@ func (s *Service) authenticateUser(login LoginRequest) (*UserData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading response: %v", err)
 	}
-	log.Printf("Raw login response: %s", string(bodyBytes))
-
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("invalid credentials")
+		return nil, errInvalidCredentials
 	}
 
 	var loginResp LoginAPIResponse
@@ -228,49 +365,269 @@ func (s *Service) authenticateUser(login LoginRequest) (*UserData, error) {
 	return &userData, nil
 }
 
+// checkAccount refuses to refresh the tokens of a user who has since been banned, deactivated or
+// deleted in user-service. Their other tokens are revoked as well, so they are logged out
+// everywhere once their current access token is refused too.
+func (s *Service) checkAccount(r *http.Request, userID int) error {
+	ctx := r.Context()
+	userData, err := s.fetchUser(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check account status", "user_id", userID, "error", err)
+		return apperrors.NewBadGatewayError("could not reach the user service")
+	}
+
+	var event string
+	if userData == nil {
+		err = apperrors.NewUnauthorizedError("account no longer exists")
+	} else {
+		event, err = accountStatus(userData, LoginEventBannedRefresh, LoginEventInactiveRefresh)
+	}
+	if err == nil {
+		return nil
+	}
+
+	slog.WarnContext(ctx, "Token refresh refused for account", "user_id", userID, "reason", err)
+	if event != "" {
+		s.logins.record(ctx, LoginEvent{Event: event, Email: userData.Email, IP: clientIP(r), UserID: userID})
+	}
+	if revokeErr := s.revocations.RevokeUser(ctx, userID, time.Now()); revokeErr != nil {
+		slog.ErrorContext(ctx, "Failed to revoke tokens of user", "user_id", userID, "error", revokeErr)
+	}
+	return err
+}
+
+// accountStatus returns an error, and the given audit event that goes with it, when the account
+// may not be issued tokens.
+func accountStatus(userData *UserData, bannedEvent, inactiveEvent string) (string, error) {
+	switch {
+	case userData.IsBanned:
+		return bannedEvent, apperrors.NewForbiddenError("account is banned")
+	case !userData.IsActive:
+		return inactiveEvent, apperrors.NewForbiddenError("account is not active")
+	}
+	return "", nil
+}
+
+// fetchUser returns the user as user-service has them now, or nil if they no longer exist.
+func (s *Service) fetchUser(ctx context.Context, userID int) (*UserData, error) {
+	resp, err := s.makeUserServiceRequest(ctx, http.MethodGet, fmt.Sprintf("/user/%d", userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var userResp LoginAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&userResp); err != nil {
+		return nil, fmt.Errorf("error parsing user response: %v", err)
+	}
+	return &userResp.Data, nil
+}
+
 func (s *Service) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := strings.Replace(r.Header.Get("Authorization"), "Bearer ", "", 1)
 		if token == "" {
-			_ = utils.ErrorJSON(w, errors.New("authorization header required"), http.StatusUnauthorized)
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("authorization header required"))
 			return
 		}
 
 		claims, err := s.jwtService.ValidateToken(token)
 		if err != nil {
-			_ = utils.ErrorJSON(w, err, http.StatusUnauthorized)
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError(err.Error()))
+			return
+		}
+		if claims.TokenType != TokenTypeAccess {
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("invalid token type"))
+			return
+		}
+		if err := s.checkRevoked(r.Context(), claims, true); err != nil {
+			_ = utils.ErrorJSON(w, err)
 			return
 		}
 
 		ctx := context.WithValue(r.Context(), "user", claims)
+		ctx = identity.With(ctx, identity.Identity{UserID: claims.UserID, StoreIDs: claims.StoresID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AuthOrAPIKeyMiddleware accepts a store API key, sent as the Bearer token or in X-API-Key, in place
+// of a user JWT. Keys carry no user, so it is only used on routes whose permission middleware then
+// checks the key's store and scopes.
+func (s *Service) AuthOrAPIKeyMiddleware(next http.Handler) http.Handler {
+	jwtAuth := s.AuthMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential := r.Header.Get("X-API-Key")
+		if credential == "" {
+			credential = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if !IsAPIKey(credential) {
+			jwtAuth.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := s.apiKeys.FindByHash(r.Context(), HashAPIKey(credential))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to look up API key", "error", err)
+			_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("API key check unavailable"))
+			return
+		}
+		if key == nil || key.RevokedAt != nil {
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("invalid API key"))
+			return
+		}
+
+		// Recording every use would write on each request, so last-used is kept to the minute
+		now := time.Now()
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+			if err := s.apiKeys.Touch(r.Context(), key.ID, now); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to record use of API key", "key_id", key.ID, "error", err)
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), "api_key", key)
+		ctx = identity.With(ctx, identity.Identity{StoreIDs: []int{key.StoreID}})
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// StoreOwnershipMiddleware lets the request through when the caller owns the store it addresses
+// by {store_id} or {store_slug}. A successful change to the store may have renamed it, so its
+// cached slugs are then forgotten.
 func (s *Service) StoreOwnershipMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims, ok := r.Context().Value("user").(*Claims)
 		if !ok {
-			_ = utils.ErrorJSON(w, errors.New("invalid store owner"), http.StatusUnauthorized)
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("invalid store owner"))
 			return
 		}
 
-		storeID, err := utils.GetID(r, "store_id")
+		storeID, err := s.storeID(r)
 		if err != nil {
-			_ = utils.ErrorJSON(w, err, http.StatusBadRequest)
+			_ = utils.ErrorJSON(w, err)
 			return
 		}
 
 		if !contains(claims.StoresID, storeID) {
-			_ = utils.ErrorJSON(w, errors.New("unauthorized owner"), http.StatusUnauthorized)
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized owner"))
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if ww.Status() < http.StatusBadRequest {
+			s.slugs.ForgetStore(storeID)
+		}
 	})
 }
 
-func (s *Service) makeUserServiceRequest(method, path string, body any) (*http.Response, error) {
+// storeID returns the ID of the store the request addresses, taken from {store_id} or resolved
+// from {store_slug}. Its errors are AppErrors.
+func (s *Service) storeID(r *http.Request) (int, error) {
+	slug := chi.URLParam(r, "store_slug")
+	if slug == "" || chi.URLParam(r, "store_id") != "" {
+		storeID, err := utils.GetID(r, "store_id")
+		if err != nil {
+			return 0, apperrors.NewBadRequestError(err.Error())
+		}
+		return storeID, nil
+	}
+
+	storeID, err := s.slugs.Resolve(r.Context(), slug)
+	if errors.Is(err, storeslug.ErrNotFound) {
+		return 0, apperrors.NewNotFoundError("store not found")
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to resolve store slug", "store_slug", slug, "error", err)
+		return 0, apperrors.NewServiceUnavailableError("store lookup unavailable")
+	}
+	return storeID, nil
+}
+
+// RequirePermission lets the request through when the caller's role in the store addressed by
+// {store_id} or {store_slug} grants permission, or when the request's API key belongs to that store
+// and has the matching scope. It must run after AuthMiddleware or AuthOrAPIKeyMiddleware.
+func (s *Service) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			storeID, err := s.storeID(r)
+			if err != nil {
+				_ = utils.ErrorJSON(w, err)
+				return
+			}
+
+			if key, ok := APIKeyFromContext(r.Context()); ok {
+				if key.StoreID != storeID {
+					_ = utils.ErrorJSON(w, apperrors.NewForbiddenError("API key does not belong to this store"))
+					return
+				}
+				if !access.ScopesAllow(key.Scopes, permission) {
+					_ = utils.ErrorJSON(w, apperrors.NewForbiddenError(fmt.Sprintf("API key lacks scope %s", access.PermissionScope(permission))))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, ok := r.Context().Value("user").(*Claims)
+			if !ok {
+				_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("unauthorized"))
+				return
+			}
+
+			role, err := s.StoreRole(r.Context(), claims, storeID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to look up role of user", "user_id", claims.UserID, "store_id", storeID, "error", err)
+				_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError("permission check unavailable"))
+				return
+			}
+			if !access.Allows(role, permission) {
+				_ = utils.ErrorJSON(w, apperrors.NewForbiddenError(fmt.Sprintf("missing permission %s", permission)))
+				return
+			}
+
+			// Staff are not in the token's store list; tell the services this store was checked
+			next.ServeHTTP(w, r.WithContext(identity.WithStore(r.Context(), storeID)))
+		})
+	}
+}
+
+// StoreRole returns the caller's role in a store: owner for the stores in their token, otherwise
+// their staff role, or "" if they have none.
+func (s *Service) StoreRole(ctx context.Context, claims *Claims, storeID int) (string, error) {
+	if contains(claims.StoresID, storeID) {
+		return access.RoleOwner, nil
+	}
+	return s.staff.Role(ctx, storeID, claims.UserID)
+}
+
+// AnonymousScope is the CallerScope of requests sent without credentials.
+const AnonymousScope = "anonymous"
+
+// CallerScope names who sent the request: its API key, its user, or AnonymousScope. What the
+// gateway keeps per caller, such as idempotent and cached responses, is kept apart by it.
+func CallerScope(ctx context.Context) string {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return "api_key:" + key.ID
+	}
+	if id := identity.FromContext(ctx); id.UserID != 0 {
+		return "user:" + strconv.Itoa(id.UserID)
+	}
+	return AnonymousScope
+}
+
+func (s *Service) makeUserServiceRequest(ctx context.Context, method, path string, body any) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
 		switch v := body.(type) {
@@ -282,11 +639,12 @@ func (s *Service) makeUserServiceRequest(method, path string, body any) (*http.R
 			return nil, errors.New("invalid request body")
 		}
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", s.userService.URL, path), reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", s.userService.URL, path), reqBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.RequestIDHeader, middleware.GetReqID(ctx))
 	return s.client.Do(req)
 }
 
@@ -305,7 +663,7 @@ func (s *Service) generateLoginResponse(userData *UserData) (*LoginResponse, err
 	for _, store := range userData.Stores {
 		stores = append(stores, store.ID)
 	}
-	accessToken, refreshToken, err := s.jwtService.GenerateTokenPair(userData.ID, stores)
+	accessToken, refreshToken, err := s.jwtService.GenerateTokenPair(userData.ID, stores, "")
 	if err != nil {
 		return nil, err
 	}
@@ -324,8 +682,8 @@ func (s *Service) generateLoginResponse(userData *UserData) (*LoginResponse, err
 
 	return response, nil
 }
-func (s *Service) registerUser(body io.Reader) (*UserData, error) {
-	resp, err := s.makeUserServiceRequest("POST", "/user", body)
+func (s *Service) registerUser(ctx context.Context, body io.Reader) (*UserData, error) {
+	resp, err := s.makeUserServiceRequest(ctx, "POST", "/user", body)
 	if err != nil {
 		return nil, fmt.Errorf("request error: %v", err)
 	}
@@ -337,9 +695,6 @@ func (s *Service) registerUser(body io.Reader) (*UserData, error) {
 		return nil, fmt.Errorf("error reading response: %v", err)
 	}
 
-	// Log the raw response for debugging
-	log.Printf("Raw response from user service: %s", string(bodyBytes))
-
 	if resp.StatusCode != http.StatusCreated {
 		return nil, fmt.Errorf("error registering user: status %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
@@ -349,9 +704,6 @@ func (s *Service) registerUser(body io.Reader) (*UserData, error) {
 		return nil, fmt.Errorf("error parsing response: %v, body: %s", err, string(bodyBytes))
 	}
 
-	// Log the parsed response
-	log.Printf("Parsed API response: %+v", apiResponse)
-
 	// Convert the flat APIResponse to UserData
 	userData := &UserData{
 		ID:        apiResponse.ID,
@@ -359,7 +711,8 @@ func (s *Service) registerUser(body io.Reader) (*UserData, error) {
 		LastName:  apiResponse.LastName,
 		Email:     apiResponse.Email,
 		Stores:    apiResponse.Stores,
-		IsActive:  true, // Default for new users
+		IsActive:  apiResponse.IsActive,
+		IsBanned:  apiResponse.IsBanned,
 	}
 
 	// Validate the user data
@@ -376,15 +729,15 @@ func (s *Service) validateRefreshToken(token string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims.TokenType != "refresh" {
+	if claims.TokenType != TokenTypeRefresh {
 		return nil, errors.New("invalid token type")
 	}
 
 	return claims, nil
 }
 
-func (s *Service) generateNewTokenPair(userID int, storesID []int) (*TokenResponse, error) {
-	accessToken, refreshToken, err := s.jwtService.GenerateTokenPair(userID, storesID)
+func (s *Service) generateNewTokenPair(userID int, storesID []int, familyID string) (*TokenResponse, error) {
+	accessToken, refreshToken, err := s.jwtService.GenerateTokenPair(userID, storesID, familyID)
 	if err != nil {
 		return nil, err
 	}
@@ -396,6 +749,74 @@ func (s *Service) generateNewTokenPair(userID int, storesID []int) (*TokenRespon
 	}, nil
 }
 
+// rotateRefreshToken spends a refresh token. Refresh tokens are single use, so seeing one a second
+// time means it was copied: the whole token family is revoked and every holder has to log in again.
+func (s *Service) rotateRefreshToken(ctx context.Context, claims *Claims) error {
+	if claims.Id == "" || claims.FamilyID == "" {
+		return apperrors.NewUnauthorizedError("refresh token is no longer accepted, please log in again")
+	}
+	// The token's own id is left out: it is revoked once used and that is what detects reuse
+	if err := s.checkRevoked(ctx, claims, false); err != nil {
+		return err
+	}
+
+	reused, err := s.revocations.Revoke(ctx, tokenKey(claims.Id), time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to spend refresh token", "error", err)
+		return apperrors.NewServiceUnavailableError("token store unavailable")
+	}
+	if !reused {
+		return nil
+	}
+
+	slog.WarnContext(ctx, "Refresh token reuse detected, revoking token family", "user_id", claims.UserID, "family_id", claims.FamilyID)
+	if _, err := s.revocations.Revoke(ctx, familyKey(claims.FamilyID), s.familyExpiry()); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke token family", "family_id", claims.FamilyID, "error", err)
+	}
+	return apperrors.NewUnauthorizedError("refresh token has already been used")
+}
+
+// checkRevoked refuses a token when its family, or the token itself if includeToken is set, was
+// revoked, or when the user logged out everywhere after it was issued. Both times are compared to
+// the microsecond, the precision Postgres keeps the cutoff at, so a token issued in the same
+// microsecond as the cutoff is refused.
+func (s *Service) checkRevoked(ctx context.Context, claims *Claims, includeToken bool) error {
+	var keys []string
+	if includeToken && claims.Id != "" {
+		keys = append(keys, tokenKey(claims.Id))
+	}
+	if claims.FamilyID != "" {
+		keys = append(keys, familyKey(claims.FamilyID))
+	}
+
+	if len(keys) > 0 {
+		revoked, err := s.revocations.IsRevoked(ctx, keys...)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check token revocation", "error", err)
+			return apperrors.NewServiceUnavailableError("token store unavailable")
+		}
+		if revoked {
+			return apperrors.NewUnauthorizedError("token has been revoked")
+		}
+	}
+
+	before, err := s.revocations.RevokedBefore(ctx, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check token revocation", "error", err)
+		return apperrors.NewServiceUnavailableError("token store unavailable")
+	}
+	if !before.IsZero() && !claims.issuedAt().After(before.Truncate(time.Microsecond)) {
+		return apperrors.NewUnauthorizedError("token has been revoked")
+	}
+	return nil
+}
+
+// familyExpiry is how long a family revocation must last: the newest refresh token in the family
+// can be at most one refresh lifetime old.
+func (s *Service) familyExpiry() time.Time {
+	return time.Now().Add(s.jwtService.GetRefreshTokenExpiry())
+}
+
 func contains(slice []int, item int) bool {
 	for _, s := range slice {
 		if s == item {
@@ -404,3 +825,22 @@ func contains(slice []int, item int) bool {
 	}
 	return false
 }
+
+// AdminMiddleware guards the gateway's own admin endpoints with a static bearer token. They answer
+// 404 while no token is configured, so they stay hidden unless an operator turns them on.
+func AdminMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				utils.NotFound(w, r)
+				return
+			}
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("admin token required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/access"
+	"github.com/robaa12/gatway-service/internal/storeslug"
+)
+
+// slugLookups resolves "shop" to store 7 and counts the lookups it is asked for.
+type slugLookups struct {
+	calls int
+}
+
+func (l *slugLookups) lookup(ctx context.Context, slug string) (int, error) {
+	l.calls++
+	if slug != "shop" {
+		return 0, storeslug.ErrNotFound
+	}
+	return 7, nil
+}
+
+// serve sends a request through a router that mounts handler on pattern, as the caller described
+// by claims or key.
+func serve(pattern string, handler func(http.Handler) http.Handler, method, path string, claims *Claims, key *APIKey) int {
+	router := chi.NewRouter()
+	router.With(handler).MethodFunc(method, pattern, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(method, path, nil)
+	ctx := r.Context()
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user", claims)
+	}
+	if key != nil {
+		ctx = context.WithValue(ctx, "api_key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r.WithContext(ctx))
+	return w.Code
+}
+
+func TestStoreOwnershipMiddleware(t *testing.T) {
+	owner := &Claims{UserID: 1, StoresID: []int{7}}
+	stranger := &Claims{UserID: 2, StoresID: []int{8}}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		claims   *Claims
+		wantCode int
+	}{
+		{"owner by ID", "/stores/{store_id}", "/stores/7", owner, http.StatusOK},
+		{"stranger by ID", "/stores/{store_id}", "/stores/7", stranger, http.StatusUnauthorized},
+		{"malformed ID", "/stores/{store_id}", "/stores/seven", owner, http.StatusBadRequest},
+		{"owner by slug", "/store/slug/{store_slug}", "/store/slug/shop", owner, http.StatusOK},
+		{"stranger by slug", "/store/slug/{store_slug}", "/store/slug/shop", stranger, http.StatusUnauthorized},
+		{"unknown slug", "/store/slug/{store_slug}", "/store/slug/missing", owner, http.StatusNotFound},
+		{"no token", "/store/slug/{store_slug}", "/store/slug/shop", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &slugLookups{}
+			s := &Service{slugs: storeslug.NewResolver(l.lookup, time.Minute)}
+
+			if code := serve(tt.pattern, s.StoreOwnershipMiddleware, http.MethodGet, tt.path, tt.claims, nil); code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStoreOwnershipMiddlewareForgetsChangedStores(t *testing.T) {
+	l := &slugLookups{}
+	s := &Service{slugs: storeslug.NewResolver(l.lookup, time.Minute)}
+	owner := &Claims{UserID: 1, StoresID: []int{7}}
+
+	serve("/store/slug/{store_slug}", s.StoreOwnershipMiddleware, http.MethodGet, "/store/slug/shop", owner, nil)
+	serve("/store/slug/{store_slug}", s.StoreOwnershipMiddleware, http.MethodGet, "/store/slug/shop", owner, nil)
+	if l.calls != 1 {
+		t.Fatalf("looked shop up %d times, want the second request served from the cache", l.calls)
+	}
+
+	// The update may have renamed the store
+	serve("/stores/{store_id}", s.StoreOwnershipMiddleware, http.MethodPut, "/stores/7", owner, nil)
+	serve("/store/slug/{store_slug}", s.StoreOwnershipMiddleware, http.MethodGet, "/store/slug/shop", owner, nil)
+	if l.calls != 2 {
+		t.Fatalf("looked shop up %d times, want it looked up again after the store changed", l.calls)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	owner := &Claims{UserID: 1, StoresID: []int{7}}
+	fulfiller := &Claims{UserID: 2}
+	readOnly := &Claims{UserID: 3}
+	stranger := &Claims{UserID: 4, StoresID: []int{8}}
+
+	tests := []struct {
+		name     string
+		path     string
+		claims   *Claims
+		key      *APIKey
+		wantCode int
+	}{
+		{"owner by ID", "/stores/7/orders", owner, nil, http.StatusOK},
+		{"owner by slug", "/storefront/shop/orders", owner, nil, http.StatusOK},
+		{"staff whose role grants it", "/storefront/shop/orders", fulfiller, nil, http.StatusOK},
+		{"staff whose role does not", "/storefront/shop/orders", readOnly, nil, http.StatusForbidden},
+		{"staff of another store", "/stores/8/orders", fulfiller, nil, http.StatusForbidden},
+		{"user without a role", "/stores/7/orders", stranger, nil, http.StatusForbidden},
+		{"key with the scope", "/storefront/shop/orders", nil, &APIKey{StoreID: 7, Scopes: []string{"orders:write"}}, http.StatusOK},
+		{"key without the scope", "/stores/7/orders", nil, &APIKey{StoreID: 7, Scopes: []string{"orders:read"}}, http.StatusForbidden},
+		{"key of another store", "/stores/7/orders", nil, &APIKey{StoreID: 8, Scopes: []string{"orders:write"}}, http.StatusForbidden},
+		{"unknown slug", "/storefront/missing/orders", owner, nil, http.StatusNotFound},
+		{"no credentials", "/stores/7/orders", nil, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staff := NewMemoryStaffStore()
+			for _, m := range []Membership{
+				{StoreID: 7, UserID: 2, Role: access.RoleOrderFulfiller},
+				{StoreID: 7, UserID: 3, Role: access.RoleReadOnly},
+			} {
+				if err := staff.Put(context.Background(), m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l := &slugLookups{}
+			s := &Service{staff: staff, slugs: storeslug.NewResolver(l.lookup, time.Minute)}
+			pattern := "/stores/{store_id}/orders"
+			if strings.HasPrefix(tt.path, "/storefront/") {
+				pattern = "/storefront/{store_slug}/orders"
+			}
+
+			code := serve(pattern, s.RequirePermission(access.OrdersWrite), http.MethodPost, tt.path, tt.claims, tt.key)
+			if code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
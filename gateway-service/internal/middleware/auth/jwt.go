@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,15 +19,36 @@ type TokenResponse struct {
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Claims identify the token itself with the standard jti claim and the login session it belongs to
+// with FamilyID. Every token issued from one login, including rotated refresh tokens, shares the
+// family, so revoking it logs that session out. IssuedAtMicro repeats iat to the microsecond, so a
+// login right after logging out everywhere is not caught by a cutoff in the same second.
 type Claims struct {
-	UserID    int    `json:"user_id"`
-	StoresID  []int  `json:"stores_id"`
-	TokenType string `json:"token_type"`
+	UserID        int    `json:"user_id"`
+	StoresID      []int  `json:"stores_id"`
+	TokenType     string `json:"token_type"`
+	FamilyID      string `json:"fid,omitempty"`
+	IssuedAtMicro int64  `json:"iat_us,omitempty"`
 	jwt.StandardClaims
 }
 
+// issuedAt returns when the token was issued, to the microsecond when it says so. Tokens issued
+// before iat_us existed only know the second, and count as issued at its start so a cutoff within
+// that second still refuses them.
+func (c *Claims) issuedAt() time.Time {
+	if c.IssuedAtMicro != 0 {
+		return time.UnixMicro(c.IssuedAtMicro)
+	}
+	return time.Unix(c.IssuedAt, 0)
+}
+
 type JWTService struct {
-	secretKey          []byte
+	keys               *KeySet
 	accessTokenExpiry  time.Duration
 	refreshTokenExpiry time.Duration
 }
@@ -34,20 +58,30 @@ func (s *JWTService) GetAccessTokenExpiry() time.Duration {
 	return s.accessTokenExpiry
 }
 
-func NewJWTService(secretKey string, accessExpiry, refreshExpiry time.Duration) *JWTService {
+func NewJWTService(keys *KeySet, accessExpiry, refreshExpiry time.Duration) *JWTService {
 	return &JWTService{
-		secretKey:          []byte(secretKey),
+		keys:               keys,
 		accessTokenExpiry:  accessExpiry,
 		refreshTokenExpiry: refreshExpiry,
 	}
 }
 
-func (s *JWTService) GenerateTokenPair(userID int, storeID []int) (accessToken, refreshToken string, err error) {
-	accessToken, err = s.GenerateToken(userID, storeID, "access", s.accessTokenExpiry)
+// GetRefreshTokenExpiry returns the configured refresh token expiry duration
+func (s *JWTService) GetRefreshTokenExpiry() time.Duration {
+	return s.refreshTokenExpiry
+}
+
+// GenerateTokenPair issues an access and a refresh token in the given token family. An empty
+// familyID starts a new family, as a fresh login does.
+func (s *JWTService) GenerateTokenPair(userID int, storeID []int, familyID string) (accessToken, refreshToken string, err error) {
+	if familyID == "" {
+		familyID = newTokenID()
+	}
+	accessToken, err = s.GenerateToken(userID, storeID, familyID, TokenTypeAccess, s.accessTokenExpiry)
 	if err != nil {
 		return "", "", err
 	}
-	refreshToken, err = s.GenerateToken(userID, storeID, "refresh", s.refreshTokenExpiry)
+	refreshToken, err = s.GenerateToken(userID, storeID, familyID, TokenTypeRefresh, s.refreshTokenExpiry)
 	if err != nil {
 		return "", "", err
 	}
@@ -55,24 +89,26 @@ func (s *JWTService) GenerateTokenPair(userID int, storeID []int) (accessToken,
 	return accessToken, refreshToken, nil
 }
 
-func (s *JWTService) GenerateToken(userID int, storeID []int, tokenType string, expiry time.Duration) (string, error) {
+func (s *JWTService) GenerateToken(userID int, storeID []int, familyID, tokenType string, expiry time.Duration) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID:    userID,
-		StoresID:  storeID,
-		TokenType: tokenType,
+		UserID:        userID,
+		StoresID:      storeID,
+		TokenType:     tokenType,
+		FamilyID:      familyID,
+		IssuedAtMicro: now.UnixMicro(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(expiry).Unix(),
+			Id:        newTokenID(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expiry).Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	return s.keys.Sign(claims)
 }
 
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil // ✅ Explicitly return []byte
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keys.keyFunc)
 	if err != nil {
 		return nil, errors.New("invalid token")
 	}
@@ -90,14 +126,39 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 }
 
 // GenerateUpdatedTokenResponse generates a new TokenResponse if the new store ID is not already present.
-func (s *JWTService) GenerateUpdatedTokenResponse(userID int, currentStoreIDs []int, newStoreID uint) (*TokenResponse, error) {
+// The new tokens stay in the caller's token family so logging out still ends the session.
+func (s *JWTService) GenerateUpdatedTokenResponse(claims *Claims, newStoreID uint) (*TokenResponse, error) {
+	userID, currentStoreIDs := claims.UserID, claims.StoresID
 	for _, sid := range currentStoreIDs {
 		if sid == int(newStoreID) {
 			return nil, nil // No update needed
 		}
 	}
 	updatedStoreIDs := append(currentStoreIDs, int(newStoreID))
-	accessToken, refreshToken, err := s.GenerateTokenPair(userID, updatedStoreIDs)
+	accessToken, refreshToken, err := s.GenerateTokenPair(userID, updatedStoreIDs, claims.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.GetAccessTokenExpiry().Seconds()),
+	}, nil
+}
+
+// GenerateTokenResponseWithoutStore generates a new TokenResponse without a deleted store, or
+// returns nil if the claims do not have it.
+func (s *JWTService) GenerateTokenResponseWithoutStore(claims *Claims, storeID uint) (*TokenResponse, error) {
+	if !contains(claims.StoresID, int(storeID)) {
+		return nil, nil
+	}
+	updatedStoreIDs := make([]int, 0, len(claims.StoresID)-1)
+	for _, sid := range claims.StoresID {
+		if sid != int(storeID) {
+			updatedStoreIDs = append(updatedStoreIDs, sid)
+		}
+	}
+	accessToken, refreshToken, err := s.GenerateTokenPair(claims.UserID, updatedStoreIDs, claims.FamilyID)
 	if err != nil {
 		return nil, err
 	}
@@ -122,3 +183,12 @@ func (s *JWTService) GetUserIDFromJWT(r *http.Request) (string, error) {
 
 	return strconv.Itoa(claims.UserID), nil
 }
+
+// newTokenID returns a random identifier for the jti and fid claims.
+func newTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+// verificationKey is a key tokens may be verified with, bound to the algorithm it is used for so a
+// token cannot pick a weaker one (e.g. HS256 keyed with an RSA public key).
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    any
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens may still be verified with.
+// Keeping the previous public keys in the set lets tokens signed before a rotation stay valid until
+// they expire.
+type KeySet struct {
+	method     jwt.SigningMethod
+	signingKey any
+	signingKID string
+	verify     map[string]verificationKey
+}
+
+// LoadKeySet builds the key set described by cfg. HS256 uses the shared secret; RS256 and EdDSA read
+// a PEM private key from cfg.SigningKeyFile and any extra PEM public keys from
+// cfg.VerificationKeyFiles. Key ids are derived from the public keys unless cfg.SigningKeyID names
+// the signing key or a verification key is listed as "kid=path".
+func LoadKeySet(cfg config.AuthConfig) (*KeySet, error) {
+	switch cfg.Algorithm {
+	case config.JWTAlgorithmHS256:
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("HS256 needs a JWT secret")
+		}
+		return &KeySet{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(cfg.JWTSecret),
+			verify: map[string]verificationKey{
+				"": {method: jwt.SigningMethodHS256, key: []byte(cfg.JWTSecret)},
+			},
+		}, nil
+	case config.JWTAlgorithmRS256, config.JWTAlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.SigningKeyFile == "" {
+		return nil, fmt.Errorf("%s needs a signing key file", cfg.Algorithm)
+	}
+	privateKey, err := readPrivateKey(cfg.Algorithm, cfg.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := privateKey.(crypto.Signer).Public()
+
+	kid := cfg.SigningKeyID
+	if kid == "" {
+		if kid, err = keyID(publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := &KeySet{
+		method:     jwt.GetSigningMethod(cfg.Algorithm),
+		signingKey: privateKey,
+		signingKID: kid,
+		verify:     map[string]verificationKey{},
+	}
+	keys.verify[kid] = verificationKey{method: keys.method, key: publicKey}
+
+	for _, entry := range cfg.VerificationKeyFiles {
+		kid, path, named := strings.Cut(entry, "=")
+		if !named {
+			path = entry
+		} else if kid == "" || kid == keys.signingKID {
+			return nil, fmt.Errorf("%s: key id %q is empty or already names the signing key", path, kid)
+		}
+		publicKey, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		method, err := methodFor(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if !named {
+			if kid, err = keyID(publicKey); err != nil {
+				return nil, err
+			}
+		}
+		keys.verify[kid] = verificationKey{method: method, key: publicKey}
+	}
+	return keys, nil
+}
+
+// Sign signs claims with the current signing key and names it in the kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signingKID != "" {
+		token.Header["kid"] = k.signingKID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// keyFunc picks the verification key named by the token's kid header and refuses tokens whose alg
+// does not match that key.
+func (k *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+type (
+	// JWK is the public half of a signing key in RFC 7517 form.
+	JWK struct {
+		KeyType   string `json:"kty"`
+		KeyID     string `json:"kid"`
+		Use       string `json:"use"`
+		Algorithm string `json:"alg"`
+		Curve     string `json:"crv,omitempty"`
+		X         string `json:"x,omitempty"`
+		N         string `json:"n,omitempty"`
+		E         string `json:"e,omitempty"`
+	}
+
+	JWKSet struct {
+		Keys []JWK `json:"keys"`
+	}
+)
+
+// JWKS lists the public verification keys. A shared HS256 secret is never published.
+func (k *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for kid, key := range k.verify {
+		jwk := JWK{KeyID: kid, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func readPrivateKey(algorithm, path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+
+	var key crypto.PrivateKey
+	if algorithm == config.JWTAlgorithmRS256 {
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+	} else {
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading verification key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: not a PEM encoded key", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func methodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("only RSA and Ed25519 public keys are supported")
+	}
+}
+
+// keyID derives a stable kid from the public key so every gateway replica names a key the same way.
+func keyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
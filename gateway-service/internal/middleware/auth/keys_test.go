@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+// writeKeyPair writes a new Ed25519 key pair to dir and returns the private and public key paths.
+func writeKeyPair(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	for path, block := range map[string]*pem.Block{
+		privatePath: {Type: "PRIVATE KEY", Bytes: privateDER},
+		publicPath:  {Type: "PUBLIC KEY", Bytes: publicDER},
+	} {
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return privatePath, publicPath
+}
+
+func TestLoadKeySetKeepsRotatedKeyIDs(t *testing.T) {
+	dir := t.TempDir()
+	oldPrivate, oldPublic := writeKeyPair(t, dir, "old")
+	newPrivate, _ := writeKeyPair(t, dir, "new")
+	claims := jwt.StandardClaims{Subject: "42"}
+
+	tests := []struct {
+		name      string
+		oldKID    string
+		verifyKey string
+	}{
+		{name: "named key id", oldKID: "2025-01", verifyKey: "2025-01=" + oldPublic},
+		{name: "derived key id", verifyKey: oldPublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := LoadKeySet(config.AuthConfig{
+				Algorithm:      config.JWTAlgorithmEdDSA,
+				SigningKeyFile: oldPrivate,
+				SigningKeyID:   tt.oldKID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := before.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := LoadKeySet(config.AuthConfig{
+				Algorithm:            config.JWTAlgorithmEdDSA,
+				SigningKeyFile:       newPrivate,
+				SigningKeyID:         "2026-01",
+				VerificationKeyFiles: []string{tt.verifyKey},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{}, after.keyFunc); err != nil {
+				t.Fatalf("token signed before the rotation: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadKeySetRejectsBadKeyIDs(t *testing.T) {
+	dir := t.TempDir()
+	private, public := writeKeyPair(t, dir, "signing")
+	_, old := writeKeyPair(t, dir, "old")
+
+	for _, entry := range []string{"=" + old, "2026-01=" + old} {
+		_, err := LoadKeySet(config.AuthConfig{
+			Algorithm:            config.JWTAlgorithmEdDSA,
+			SigningKeyFile:       private,
+			SigningKeyID:         "2026-01",
+			VerificationKeyFiles: []string{entry},
+		})
+		if err == nil {
+			t.Fatalf("LoadKeySet accepted verification key %q", entry)
+		}
+	}
+
+	// Listing the signing key's own public key again is harmless
+	if _, err := LoadKeySet(config.AuthConfig{
+		Algorithm:            config.JWTAlgorithmEdDSA,
+		SigningKeyFile:       private,
+		VerificationKeyFiles: []string{public},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
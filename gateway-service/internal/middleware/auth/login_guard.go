@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+// loginGuard slows down password guessing. Failed logins are counted per email and IP address
+// pair, so one address cannot keep guessing at an account; per email, so many addresses together
+// cannot either; and per IP address, so one address cannot try many accounts. Once a count passes
+// its limit, every further attempt has to wait twice as long as the one before, up to the lockout.
+//
+// The per email count lets anyone who knows an email slow its owner's logins down by sending bad
+// passwords, so its limit is set well above the per address one: the owner is only held up by an
+// attack spread over many addresses, and never for longer than the lockout.
+type loginGuard struct {
+	attempts LoginAttemptStore
+	audit    LoginAuditLog
+	cfg      config.LoginConfig
+}
+
+// attempt is one login try, identified by the email it was for and the address it came from.
+type attempt struct {
+	email string
+	ip    string
+}
+
+func newAttempt(email string, r *http.Request) attempt {
+	return attempt{
+		email: strings.ToLower(strings.TrimSpace(email)),
+		ip:    clientIP(r),
+	}
+}
+
+func (a attempt) accountKey() string {
+	return "email:" + a.email + "|ip:" + a.ip
+}
+
+func (a attempt) emailKey() string {
+	return "email:" + a.email
+}
+
+func (a attempt) ipKey() string {
+	return "ip:" + a.ip
+}
+
+// wait returns how long the caller has to wait before a can be tried, or 0 if it can be now.
+func (g *loginGuard) wait(ctx context.Context, a attempt) (time.Duration, error) {
+	accountAttempts, err := g.attempts.Get(ctx, a.accountKey())
+	if err != nil {
+		return 0, err
+	}
+	emailAttempts, err := g.attempts.Get(ctx, a.emailKey())
+	if err != nil {
+		return 0, err
+	}
+	ipAttempts, err := g.attempts.Get(ctx, a.ipKey())
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	wait := max(
+		g.lockedUntil(accountAttempts, g.cfg.MaxAttempts).Sub(now),
+		g.lockedUntil(emailAttempts, g.cfg.AccountMaxAttempts).Sub(now),
+		g.lockedUntil(ipAttempts, g.cfg.IPMaxAttempts).Sub(now),
+	)
+	return max(wait, 0), nil
+}
+
+// fail records a failed login. The attempt that reaches a limit and the one that reaches the
+// lockout are audited; failures in between are not, so an ongoing attack does not flood the log.
+func (g *loginGuard) fail(ctx context.Context, a attempt) {
+	now := time.Now()
+	for _, limit := range []struct {
+		key string
+		max int
+	}{
+		{a.accountKey(), g.cfg.MaxAttempts},
+		{a.emailKey(), g.cfg.AccountMaxAttempts},
+		{a.ipKey(), g.cfg.IPMaxAttempts},
+	} {
+		attempts, err := g.attempts.Fail(ctx, limit.key, now, g.cfg.FailureWindow)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record failed login", "error", err)
+			continue
+		}
+
+		event := ""
+		switch delay := g.delay(attempts.Failures, limit.max); {
+		case delay == 0:
+			continue
+		case attempts.Failures == limit.max:
+			event = LoginEventThrottled
+		case delay == g.cfg.Lockout && g.delay(attempts.Failures-1, limit.max) < g.cfg.Lockout:
+			event = LoginEventLocked
+		default:
+			continue
+		}
+
+		lockedUntil := g.lockedUntil(attempts, limit.max)
+		slog.WarnContext(ctx, "Repeated failed logins", "event", event, "key", limit.key,
+			"failures", attempts.Failures, "locked_until", lockedUntil)
+		g.record(ctx, LoginEvent{
+			Event:       event,
+			Email:       a.email,
+			IP:          a.ip,
+			Failures:    attempts.Failures,
+			LockedUntil: &lockedUntil,
+		})
+	}
+}
+
+// succeed forgets the failures of the email. Those of the address alone are kept, so logging in to
+// an account of one's own does not buy more guesses at others.
+func (g *loginGuard) succeed(ctx context.Context, a attempt) {
+	for _, key := range []string{a.accountKey(), a.emailKey()} {
+		if err := g.attempts.Reset(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Failed to reset failed logins", "error", err)
+		}
+	}
+}
+
+// record adds event to the audit log. Losing an event must not stop a login, so failures are
+// only logged.
+func (g *loginGuard) record(ctx context.Context, event LoginEvent) {
+	event.CreatedAt = time.Now()
+	event.RequestID = middleware.GetReqID(ctx)
+	if err := g.audit.Record(ctx, event, event.CreatedAt.Add(g.cfg.AuditRetention)); err != nil {
+		slog.ErrorContext(ctx, "Failed to record login audit event", "event", event.Event, "error", err)
+	}
+}
+
+// lockedUntil is when the next attempt is allowed after the given failures.
+func (g *loginGuard) lockedUntil(attempts LoginAttempts, limit int) time.Time {
+	return attempts.LastFailure.Add(g.delay(attempts.Failures, limit))
+}
+
+// delay is how long to wait after the given number of failures: nothing below the limit, then
+// Backoff doubled for every failure past it, capped at Lockout.
+func (g *loginGuard) delay(failures, limit int) time.Duration {
+	if failures < limit {
+		return 0
+	}
+	delay := g.cfg.Backoff
+	for i := limit; i < failures && delay < g.cfg.Lockout; i++ {
+		delay *= 2
+	}
+	return min(delay, g.cfg.Lockout)
+}
+
+// clientIP is the caller's address. realip.Middleware has already replaced RemoteAddr with the
+// address reported by one of the configured trusted proxies, if the request came through one.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
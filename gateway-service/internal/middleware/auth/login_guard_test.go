@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+var testLoginConfig = config.LoginConfig{
+	MaxAttempts:        3,
+	AccountMaxAttempts: 4,
+	IPMaxAttempts:      5,
+	Backoff:            time.Second,
+	Lockout:            8 * time.Second,
+	FailureWindow:      time.Hour,
+	AuditRetention:     time.Hour,
+}
+
+func newTestLoginGuard(cfg config.LoginConfig) *loginGuard {
+	return &loginGuard{attempts: NewMemoryLoginAttemptStore(), audit: NewMemoryLoginAuditLog(), cfg: cfg}
+}
+
+// loginFrom returns the attempt of a login for email sent from ip.
+func loginFrom(email, ip string) attempt {
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	r.RemoteAddr = ip + ":41000"
+	return newAttempt(email, r)
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	g := newTestLoginGuard(testLoginConfig)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 8 * time.Second},
+		{7, 8 * time.Second},
+		{1000, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := g.delay(tt.failures, 3); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardWait(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// failures are sent before next is tried
+		failures []attempt
+		succeed  bool
+		next     attempt
+		wantWait bool
+	}{
+		{
+			name:     "below the limit",
+			failures: []attempt{loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1")},
+			next:     loginFrom("a@example.com", "10.0.0.1"),
+		},
+		{
+			name:     "account limit from one address",
+			failures: []attempt{loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1"), loginFrom("A@example.com ", "10.0.0.1")},
+			next:     loginFrom("a@example.com", "10.0.0.1"),
+			wantWait: true,
+		},
+		{
+			name:     "owner on another address is not locked out",
+			failures: []attempt{loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1")},
+			next:     loginFrom("a@example.com", "10.0.0.2"),
+		},
+		{
+			name: "account limit across addresses",
+			failures: []attempt{
+				loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.2"),
+				loginFrom("a@example.com", "10.0.0.3"), loginFrom("a@example.com", "10.0.0.4"),
+			},
+			next:     loginFrom("a@example.com", "10.0.0.5"),
+			wantWait: true,
+		},
+		{
+			name: "address limit across accounts",
+			failures: []attempt{
+				loginFrom("a@example.com", "10.0.0.1"), loginFrom("b@example.com", "10.0.0.1"), loginFrom("c@example.com", "10.0.0.1"),
+				loginFrom("d@example.com", "10.0.0.1"), loginFrom("e@example.com", "10.0.0.1"),
+			},
+			next:     loginFrom("f@example.com", "10.0.0.1"),
+			wantWait: true,
+		},
+		{
+			name: "success forgets the failures from every address",
+			failures: []attempt{
+				loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.2"),
+				loginFrom("a@example.com", "10.0.0.3"), loginFrom("a@example.com", "10.0.0.4"),
+			},
+			succeed: true,
+			next:    loginFrom("a@example.com", "10.0.0.4"),
+		},
+		{
+			name:     "success forgets the account failures",
+			failures: []attempt{loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1"), loginFrom("a@example.com", "10.0.0.1")},
+			succeed:  true,
+			next:     loginFrom("a@example.com", "10.0.0.1"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestLoginGuard(testLoginConfig)
+			for _, a := range tt.failures {
+				g.fail(ctx, a)
+			}
+			if tt.succeed {
+				g.succeed(ctx, tt.next)
+			}
+
+			wait, err := g.wait(ctx, tt.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if waiting := wait > 0; waiting != tt.wantWait {
+				t.Fatalf("wait = %s, want waiting %t", wait, tt.wantWait)
+			}
+			if wait > g.cfg.Backoff {
+				t.Fatalf("wait = %s, longer than the first backoff", wait)
+			}
+		})
+	}
+}
+
+func TestLoginGuardSuccessKeepsAddressFailures(t *testing.T) {
+	ctx := context.Background()
+	g := newTestLoginGuard(testLoginConfig)
+	a := loginFrom("a@example.com", "10.0.0.1")
+
+	g.fail(ctx, a)
+	g.fail(ctx, a)
+	g.succeed(ctx, a)
+
+	attempts, err := g.attempts.Get(ctx, a.ipKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Failures != 2 {
+		t.Fatalf("address failures = %d after a success, want 2", attempts.Failures)
+	}
+}
+
+func TestLoginGuardAuditsThrottlingAndLockout(t *testing.T) {
+	ctx := context.Background()
+	cfg := testLoginConfig
+	cfg.AccountMaxAttempts = 100
+	cfg.IPMaxAttempts = 100
+	g := newTestLoginGuard(cfg)
+	a := loginFrom("a@example.com", "10.0.0.1")
+
+	// Failures 3 to 6 wait 1s, 2s, 4s and 8s; only the first and the one reaching the lockout
+	// are audited
+	for range 8 {
+		g.fail(ctx, a)
+	}
+
+	events, err := g.audit.List(ctx, "a@example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[int]string)
+	for _, event := range events {
+		got[event.Failures] = event.Event
+	}
+	if len(got) != 2 || got[3] != LoginEventThrottled || got[6] != LoginEventLocked {
+		t.Fatalf("audited events by failures = %v, want 3: %s and 6: %s", got, LoginEventThrottled, LoginEventLocked)
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Events recorded in the login audit log.
+const (
+	LoginEventThrottled       = "login_throttled"
+	LoginEventLocked          = "login_locked"
+	LoginEventBannedLogin     = "banned_login"
+	LoginEventInactiveLogin   = "inactive_login"
+	LoginEventBannedRefresh   = "banned_refresh"
+	LoginEventInactiveRefresh = "inactive_refresh"
+)
+
+// memoryAuditLimit bounds how many events the in-memory audit log keeps.
+const memoryAuditLimit = 1000
+
+// LoginAttempts counts the failed logins of one email or IP address since failures were last
+// forgotten.
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// LoginAttemptStore tracks failed logins by key, an email or an IP address.
+type LoginAttemptStore interface {
+	// Get returns the failures recorded for key, or none once they have been forgotten.
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	// Fail records a failure at the given time and returns the updated count. The failures are
+	// forgotten forgetAfter later unless another one is recorded first.
+	Fail(ctx context.Context, key string, at time.Time, forgetAfter time.Duration) (LoginAttempts, error)
+	// Reset forgets the failures of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginEvent is an audit record of suspicious login activity.
+type LoginEvent struct {
+	Event       string     `json:"event"`
+	Email       string     `json:"email,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	UserID      int        `json:"user_id,omitempty"`
+	Failures    int        `json:"failures,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	RequestID   string     `json:"request_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// LoginAuditLog keeps login events for operators to review.
+type LoginAuditLog interface {
+	// Record keeps event until the given time.
+	Record(ctx context.Context, event LoginEvent, until time.Time) error
+	// List returns up to limit events, newest first, only those for email if it is set.
+	List(ctx context.Context, email string, limit int) ([]LoginEvent, error)
+}
+
+// MemoryLoginAttemptStore keeps failed logins in process memory, so each gateway replica counts
+// the attempts it sees on its own.
+type MemoryLoginAttemptStore struct {
+	mu        sync.Mutex
+	attempts  map[string]memoryLoginAttempts
+	lastSweep time.Time
+}
+
+type memoryLoginAttempts struct {
+	LoginAttempts
+	expiresAt time.Time
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts:  make(map[string]memoryLoginAttempts),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryLoginAttemptStore) Get(_ context.Context, key string) (LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[key]
+	if !ok || !a.expiresAt.After(time.Now()) {
+		return LoginAttempts{}, nil
+	}
+	return a.LoginAttempts, nil
+}
+
+func (m *MemoryLoginAttemptStore) Fail(_ context.Context, key string, at time.Time, forgetAfter time.Duration) (LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(at)
+	a, ok := m.attempts[key]
+	if !ok || !a.expiresAt.After(at) {
+		a = memoryLoginAttempts{}
+	}
+	a.Failures++
+	a.LastFailure = at
+	a.expiresAt = at.Add(forgetAfter)
+	m.attempts[key] = a
+	return a.LoginAttempts, nil
+}
+
+func (m *MemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// sweep drops forgotten failures; callers hold m.mu.
+func (m *MemoryLoginAttemptStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, a := range m.attempts {
+		if !a.expiresAt.After(now) {
+			delete(m.attempts, key)
+		}
+	}
+}
+
+// MemoryLoginAuditLog keeps the latest login events in process memory; they are lost on restart.
+type MemoryLoginAuditLog struct {
+	mu     sync.Mutex
+	events []memoryLoginEvent
+}
+
+type memoryLoginEvent struct {
+	LoginEvent
+	expiresAt time.Time
+}
+
+func NewMemoryLoginAuditLog() *MemoryLoginAuditLog {
+	return &MemoryLoginAuditLog{}
+}
+
+func (m *MemoryLoginAuditLog) Record(_ context.Context, event LoginEvent, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	kept := m.events[:0]
+	for _, e := range m.events {
+		if e.expiresAt.After(now) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) >= memoryAuditLimit {
+		kept = kept[len(kept)-memoryAuditLimit+1:]
+	}
+	m.events = append(kept, memoryLoginEvent{LoginEvent: event, expiresAt: until})
+	return nil
+}
+
+func (m *MemoryLoginAuditLog) List(_ context.Context, email string, limit int) ([]LoginEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	events := []LoginEvent{}
+	for _, e := range m.events {
+		if e.expiresAt.After(now) && (email == "" || e.Email == email) {
+			events = append(events, e.LoginEvent)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginAttempt struct {
+	Key         string    `gorm:"primaryKey"`
+	Failures    int       `gorm:"not null"`
+	LastFailure time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+type loginAuditRecord struct {
+	ID          uint   `gorm:"primaryKey"`
+	Event       string `gorm:"not null"`
+	Email       string `gorm:"index"`
+	IP          string
+	UserID      int
+	Failures    int
+	LockedUntil *time.Time
+	RequestID   string
+	CreatedAt   time.Time `gorm:"not null;index"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (loginAuditRecord) TableName() string {
+	return "login_audit_events"
+}
+
+func (r loginAuditRecord) toLoginEvent() LoginEvent {
+	return LoginEvent{
+		Event:       r.Event,
+		Email:       r.Email,
+		IP:          r.IP,
+		UserID:      r.UserID,
+		Failures:    r.Failures,
+		LockedUntil: r.LockedUntil,
+		RequestID:   r.RequestID,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+// PostgresLoginAttemptStore shares failed logins between every gateway replica, so an attacker
+// gains nothing by spreading attempts across them.
+type PostgresLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresLoginAttemptStore(db *gorm.DB) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{db: db}
+}
+
+func (p *PostgresLoginAttemptStore) Get(ctx context.Context, key string) (LoginAttempts, error) {
+	var rows []loginAttempt
+	err := p.db.WithContext(ctx).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return LoginAttempts{}, err
+	}
+	return LoginAttempts{Failures: rows[0].Failures, LastFailure: rows[0].LastFailure}, nil
+}
+
+// Fail counts the failure in a single upsert, so concurrent attempts are never lost.
+func (p *PostgresLoginAttemptStore) Fail(ctx context.Context, key string, at time.Time, forgetAfter time.Duration) (LoginAttempts, error) {
+	var row loginAttempt
+	err := p.db.WithContext(ctx).Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure, expires_at) VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.expires_at > EXCLUDED.last_failure
+				THEN login_attempts.failures + 1 ELSE 1 END,
+			last_failure = EXCLUDED.last_failure,
+			expires_at = EXCLUDED.expires_at
+		RETURNING key, failures, last_failure, expires_at`,
+		key, at, at.Add(forgetAfter)).Scan(&row).Error
+	return LoginAttempts{Failures: row.Failures, LastFailure: row.LastFailure}, err
+}
+
+func (p *PostgresLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return p.db.WithContext(ctx).Where("key = ?", key).Delete(&loginAttempt{}).Error
+}
+
+// Cleanup deletes, once an hour until ctx is cancelled, the failures that have been forgotten.
+func (p *PostgresLoginAttemptStore) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(postgresCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&loginAttempt{}).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to clean up expired login attempts", "error", err)
+			}
+		}
+	}
+}
+
+// PostgresLoginAuditLog keeps login events for every gateway replica in one table.
+type PostgresLoginAuditLog struct {
+	db *gorm.DB
+}
+
+func NewPostgresLoginAuditLog(db *gorm.DB) *PostgresLoginAuditLog {
+	return &PostgresLoginAuditLog{db: db}
+}
+
+func (p *PostgresLoginAuditLog) Record(ctx context.Context, event LoginEvent, until time.Time) error {
+	return p.db.WithContext(ctx).Create(&loginAuditRecord{
+		Event:       event.Event,
+		Email:       event.Email,
+		IP:          event.IP,
+		UserID:      event.UserID,
+		Failures:    event.Failures,
+		LockedUntil: event.LockedUntil,
+		RequestID:   event.RequestID,
+		CreatedAt:   event.CreatedAt,
+		ExpiresAt:   until,
+	}).Error
+}
+
+func (p *PostgresLoginAuditLog) List(ctx context.Context, email string, limit int) ([]LoginEvent, error) {
+	query := p.db.WithContext(ctx).Where("expires_at > ?", time.Now())
+	if email != "" {
+		query = query.Where("email = ?", email)
+	}
+	var rows []loginAuditRecord
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	events := make([]LoginEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, row.toLoginEvent())
+	}
+	return events, nil
+}
+
+// Cleanup deletes, once an hour until ctx is cancelled, the events past their retention.
+func (p *PostgresLoginAuditLog) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(postgresCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&loginAuditRecord{}).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to clean up expired login audit events", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore remembers revoked tokens so a signed, unexpired JWT can still be refused.
+// Entries only need to live until the token they cover would have expired anyway.
+type RevocationStore interface {
+	// Revoke records id as revoked until the given time and reports whether it already was.
+	// Refresh rotation relies on that answer being atomic to spot a replayed refresh token.
+	Revoke(ctx context.Context, id string, until time.Time) (alreadyRevoked bool, err error)
+	// IsRevoked reports whether any of ids has been revoked.
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+	// RevokeUser refuses every token issued to userID before the given time.
+	RevokeUser(ctx context.Context, userID int, before time.Time) error
+	// RevokedBefore returns the cutoff set by RevokeUser, or the zero time if there is none.
+	RevokedBefore(ctx context.Context, userID int) (time.Time, error)
+}
+
+// tokenKey and familyKey keep single tokens and token families apart in one keyspace.
+func tokenKey(jti string) string {
+	return "token:" + jti
+}
+
+func familyKey(familyID string) string {
+	return "family:" + familyID
+}
+
+// memorySweepInterval bounds how often expired entries are dropped from the in-memory store.
+const memorySweepInterval = 10 * time.Minute
+
+// MemoryRevocationStore keeps revocations in process memory. It is lost on restart and not
+// shared between gateway replicas, so it only suits development and single-instance setups.
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time
+	users     map[int]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked:   make(map[string]time.Time),
+		users:     make(map[int]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryRevocationStore) Revoke(_ context.Context, id string, until time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+	if expires, ok := m.revoked[id]; ok && expires.After(now) {
+		return true, nil
+	}
+	m.revoked[id] = until
+	return false, nil
+}
+
+func (m *MemoryRevocationStore) IsRevoked(_ context.Context, ids ...string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if expires, ok := m.revoked[id]; ok && expires.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MemoryRevocationStore) RevokeUser(_ context.Context, userID int, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[userID] = before
+	return nil
+}
+
+func (m *MemoryRevocationStore) RevokedBefore(_ context.Context, userID int) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.users[userID], nil
+}
+
+// sweep drops expired entries; callers hold m.mu.
+func (m *MemoryRevocationStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for id, expires := range m.revoked {
+		if !expires.After(now) {
+			delete(m.revoked, id)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const postgresCleanupInterval = time.Hour
+
+type revokedToken struct {
+	ID        string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+type revokedUser struct {
+	UserID        int       `gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `gorm:"not null"`
+}
+
+// PostgresRevocationStore shares revocations between every gateway replica and survives restarts.
+type PostgresRevocationStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresRevocationStore(db *gorm.DB) *PostgresRevocationStore {
+	return &PostgresRevocationStore{db: db}
+}
+
+func (p *PostgresRevocationStore) Revoke(ctx context.Context, id string, until time.Time) (bool, error) {
+	result := p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&revokedToken{ID: id, ExpiresAt: until})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 0, nil
+}
+
+func (p *PostgresRevocationStore) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	var count int64
+	err := p.db.WithContext(ctx).Model(&revokedToken{}).
+		Where("id IN ? AND expires_at > ?", ids, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (p *PostgresRevocationStore) RevokeUser(ctx context.Context, userID int, before time.Time) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&revokedUser{UserID: userID, RevokedBefore: before}).Error
+}
+
+func (p *PostgresRevocationStore) RevokedBefore(ctx context.Context, userID int) (time.Time, error) {
+	var user revokedUser
+	err := p.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&user).Error
+	return user.RevokedBefore, err
+}
+
+// Cleanup deletes, once an hour until ctx is cancelled, the entries whose tokens have expired.
+func (p *PostgresRevocationStore) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(postgresCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&revokedToken{}).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to clean up expired token revocations", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestCheckRevokedCutoff(t *testing.T) {
+	cutoff := time.Date(2025, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	// issued returns the claims of a token issued at the given time.
+	issued := func(at time.Time) *Claims {
+		return &Claims{
+			UserID:         1,
+			IssuedAtMicro:  at.UnixMicro(),
+			StandardClaims: jwt.StandardClaims{IssuedAt: at.Unix()},
+		}
+	}
+	// legacy returns the claims of a token issued before iat_us, which only knows the second.
+	legacy := func(at time.Time) *Claims {
+		return &Claims{UserID: 1, StandardClaims: jwt.StandardClaims{IssuedAt: at.Unix()}}
+	}
+
+	tests := []struct {
+		name        string
+		claims      *Claims
+		wantRevoked bool
+	}{
+		{"issued a second before", issued(cutoff.Add(-time.Second)), true},
+		{"issued a millisecond before", issued(cutoff.Add(-time.Millisecond)), true},
+		{"issued in the same microsecond", issued(cutoff), true},
+		{"issued a millisecond after, same second", issued(cutoff.Add(time.Millisecond)), false},
+		{"issued a second after", issued(cutoff.Add(time.Second)), false},
+		{"legacy, same second", legacy(cutoff.Add(100 * time.Millisecond)), true},
+		{"legacy, next second", legacy(cutoff.Add(time.Second)), false},
+		{"other user", &Claims{UserID: 2, IssuedAtMicro: cutoff.Add(-time.Second).UnixMicro()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryRevocationStore()
+			if err := store.RevokeUser(context.Background(), 1, cutoff); err != nil {
+				t.Fatal(err)
+			}
+			s := &Service{revocations: store}
+
+			err := s.checkRevoked(context.Background(), tt.claims, true)
+			if revoked := err != nil; revoked != tt.wantRevoked {
+				t.Fatalf("revoked = %t (%v), want %t", revoked, err, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestCheckRevokedTokenAndFamily(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		revoke       string
+		includeToken bool
+		wantRevoked  bool
+	}{
+		{"nothing revoked", "", true, false},
+		{"family revoked", familyKey("family-1"), false, true},
+		{"token revoked", tokenKey("token-1"), true, true},
+		{"token revoked but not checked", tokenKey("token-1"), false, false},
+		{"other family revoked", familyKey("family-2"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryRevocationStore()
+			if tt.revoke != "" {
+				if _, err := store.Revoke(context.Background(), tt.revoke, until); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &Service{revocations: store}
+			claims := &Claims{
+				UserID:         1,
+				FamilyID:       "family-1",
+				IssuedAtMicro:  time.Now().UnixMicro(),
+				StandardClaims: jwt.StandardClaims{Id: "token-1"},
+			}
+
+			err := s.checkRevoked(context.Background(), claims, tt.includeToken)
+			if revoked := err != nil; revoked != tt.wantRevoked {
+				t.Fatalf("revoked = %t (%v), want %t", revoked, err, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestMemoryRevocationStoreReportsReplays(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	already, err := store.Revoke(ctx, "token:1", time.Now().Add(time.Hour))
+	if err != nil || already {
+		t.Fatalf("first Revoke = %t, %v; want false, nil", already, err)
+	}
+	already, err = store.Revoke(ctx, "token:1", time.Now().Add(time.Hour))
+	if err != nil || !already {
+		t.Fatalf("second Revoke = %t, %v; want true, nil", already, err)
+	}
+
+	// An expired revocation no longer counts
+	if _, err := store.Revoke(ctx, "token:2", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.IsRevoked(ctx, "token:2"); revoked {
+		t.Fatal("expired revocation still refuses the token")
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Membership gives a user a role in a store they do not own.
+type Membership struct {
+	StoreID   int       `json:"store_id"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StaffStore keeps store staff memberships. It is consulted on every permission check, so changing
+// or removing a role takes effect on the member's next request.
+type StaffStore interface {
+	// Role returns the user's role in the store, or "" if they are not staff there.
+	Role(ctx context.Context, storeID, userID int) (string, error)
+	List(ctx context.Context, storeID int) ([]Membership, error)
+	ListForUser(ctx context.Context, userID int) ([]Membership, error)
+	// Put adds the membership or replaces the member's role.
+	Put(ctx context.Context, membership Membership) error
+	// Remove deletes a membership and reports whether it existed.
+	Remove(ctx context.Context, storeID, userID int) (bool, error)
+}
+
+type staffKey struct {
+	storeID int
+	userID  int
+}
+
+// MemoryStaffStore keeps memberships in process memory; they are lost on restart.
+type MemoryStaffStore struct {
+	mu      sync.RWMutex
+	members map[staffKey]Membership
+}
+
+func NewMemoryStaffStore() *MemoryStaffStore {
+	return &MemoryStaffStore{members: make(map[staffKey]Membership)}
+}
+
+func (m *MemoryStaffStore) Role(_ context.Context, storeID, userID int) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.members[staffKey{storeID, userID}].Role, nil
+}
+
+func (m *MemoryStaffStore) List(_ context.Context, storeID int) ([]Membership, error) {
+	return m.filter(func(member Membership) bool { return member.StoreID == storeID }), nil
+}
+
+func (m *MemoryStaffStore) ListForUser(_ context.Context, userID int) ([]Membership, error) {
+	return m.filter(func(member Membership) bool { return member.UserID == userID }), nil
+}
+
+func (m *MemoryStaffStore) Put(_ context.Context, membership Membership) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.members[staffKey{membership.StoreID, membership.UserID}] = membership
+	return nil
+}
+
+func (m *MemoryStaffStore) Remove(_ context.Context, storeID, userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := staffKey{storeID, userID}
+	_, existed := m.members[key]
+	delete(m.members, key)
+	return existed, nil
+}
+
+// filter returns matching memberships ordered by store then user, like the postgres store.
+func (m *MemoryStaffStore) filter(match func(Membership) bool) []Membership {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := []Membership{}
+	for _, member := range m.members {
+		if match(member) {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].StoreID != members[j].StoreID {
+			return members[i].StoreID < members[j].StoreID
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members
+}
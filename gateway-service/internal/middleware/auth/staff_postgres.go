@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type staffMember struct {
+	StoreID   int       `gorm:"primaryKey;autoIncrement:false"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false;index"`
+	Role      string    `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+func (staffMember) TableName() string {
+	return "store_staff"
+}
+
+func (s staffMember) toMembership() Membership {
+	return Membership{
+		StoreID:   s.StoreID,
+		UserID:    s.UserID,
+		Role:      s.Role,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// PostgresStaffStore shares memberships between every gateway replica.
+type PostgresStaffStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStaffStore(db *gorm.DB) *PostgresStaffStore {
+	return &PostgresStaffStore{db: db}
+}
+
+func (p *PostgresStaffStore) Role(ctx context.Context, storeID, userID int) (string, error) {
+	var member staffMember
+	err := p.db.WithContext(ctx).
+		Where("store_id = ? AND user_id = ?", storeID, userID).
+		Limit(1).Find(&member).Error
+	return member.Role, err
+}
+
+func (p *PostgresStaffStore) List(ctx context.Context, storeID int) ([]Membership, error) {
+	return p.find(ctx, "store_id = ?", storeID)
+}
+
+func (p *PostgresStaffStore) ListForUser(ctx context.Context, userID int) ([]Membership, error) {
+	return p.find(ctx, "user_id = ?", userID)
+}
+
+func (p *PostgresStaffStore) Put(ctx context.Context, membership Membership) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "store_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).
+		Create(&staffMember{
+			StoreID:   membership.StoreID,
+			UserID:    membership.UserID,
+			Role:      membership.Role,
+			UpdatedAt: membership.UpdatedAt,
+		}).Error
+}
+
+func (p *PostgresStaffStore) Remove(ctx context.Context, storeID, userID int) (bool, error) {
+	result := p.db.WithContext(ctx).
+		Where("store_id = ? AND user_id = ?", storeID, userID).
+		Delete(&staffMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (p *PostgresStaffStore) find(ctx context.Context, query string, args ...any) ([]Membership, error) {
+	var members []staffMember
+	if err := p.db.WithContext(ctx).Where(query, args...).Order("store_id, user_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	memberships := make([]Membership, 0, len(members))
+	for _, member := range members {
+		memberships = append(memberships, member.toMembership())
+	}
+	return memberships, nil
+}
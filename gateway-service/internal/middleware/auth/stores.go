@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Stores bundles the state the auth middlewares keep outside the tokens: revocations, store staff,
+// store API keys, failed logins and the login audit log. It is opened once at
+// startup and shared by every router built on config reload.
+type Stores struct {
+	Revocations   RevocationStore
+	Staff         StaffStore
+	APIKeys       APIKeyStore
+	LoginAttempts LoginAttemptStore
+	LoginAudit    LoginAuditLog
+	close         func() error
+}
+
+// OpenStores opens the backend selected by cfg.Store. With postgres, the tables are created if
+// needed and expired entries are cleaned up in the background until Close.
+func OpenStores(cfg config.AuthConfig) (*Stores, error) {
+	switch cfg.Store {
+	case config.AuthStoreMemory:
+		return &Stores{
+			Revocations:   NewMemoryRevocationStore(),
+			Staff:         NewMemoryStaffStore(),
+			APIKeys:       NewMemoryAPIKeyStore(),
+			LoginAttempts: NewMemoryLoginAttemptStore(),
+			LoginAudit:    NewMemoryLoginAuditLog(),
+			close:         func() error { return nil },
+		}, nil
+	case config.AuthStorePostgres:
+	default:
+		return nil, fmt.Errorf("unknown auth store %q", cfg.Store)
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, errors.New("postgres auth store needs a database url")
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to auth store: %w", err)
+	}
+	if err := db.AutoMigrate(&revokedToken{}, &revokedUser{}, &staffMember{}, &apiKeyRecord{}, &loginAttempt{}, &loginAuditRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to run auth store migration: %w", err)
+	}
+
+	revocations := NewPostgresRevocationStore(db)
+	attempts := NewPostgresLoginAttemptStore(db)
+	audit := NewPostgresLoginAuditLog(db)
+	ctx, stopCleanup := context.WithCancel(context.Background())
+	go revocations.Cleanup(ctx)
+	go attempts.Cleanup(ctx)
+	go audit.Cleanup(ctx)
+
+	return &Stores{
+		Revocations:   revocations,
+		Staff:         NewPostgresStaffStore(db),
+		APIKeys:       NewPostgresAPIKeyStore(db),
+		LoginAttempts: attempts,
+		LoginAudit:    audit,
+		close: func() error {
+			stopCleanup()
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		},
+	}, nil
+}
+
+func (s *Stores) Close() error {
+	return s.close()
+}
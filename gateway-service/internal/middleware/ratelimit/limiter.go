@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long an untouched bucket is kept before being swept.
+const idleBucketTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// tokenBucket keeps one bucket per key. Each bucket holds up to burst tokens and refills at rate
+// tokens per second.
+type tokenBucket struct {
+	burst     float64
+	rate      float64
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// decision is the outcome of taking a token, with enough detail to fill the rate limit headers.
+type decision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+func newTokenBucket(requests int, per time.Duration, burst int) *tokenBucket {
+	return &tokenBucket{
+		burst:     float64(burst),
+		rate:      float64(requests) / per.Seconds(),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (tb *tokenBucket) take(key string, now time.Time) decision {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.sweep(now)
+
+	b, exists := tb.buckets[key]
+	if !exists {
+		b = &bucket{tokens: tb.burst, lastSeen: now}
+		tb.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(tb.burst, b.tokens+elapsed*tb.rate)
+	b.lastSeen = now
+
+	d := decision{limit: int(tb.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.allowed = true
+	} else {
+		d.retryAfter = tb.duration(1 - b.tokens)
+	}
+	d.remaining = int(math.Floor(b.tokens))
+	d.reset = tb.duration(tb.burst - b.tokens)
+	return d
+}
+
+// duration returns how long it takes to refill the given number of tokens.
+func (tb *tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / tb.rate * float64(time.Second))
+}
+
+// sweep drops buckets that have been idle long enough to be full again. It runs at most once per
+// idleBucketTTL so it stays cheap on the request path.
+func (tb *tokenBucket) sweep(now time.Time) {
+	if now.Sub(tb.lastSweep) < idleBucketTTL {
+		return
+	}
+	for key, b := range tb.buckets {
+		if now.Sub(b.lastSeen) > idleBucketTTL {
+			delete(tb.buckets, key)
+		}
+	}
+	tb.lastSweep = now
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// takes are the offsets from start at which tokens are taken; the last take is checked
+		takes          []time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:          "first request",
+			takes:         []time.Duration{0},
+			wantAllowed:   true,
+			wantRemaining: 2,
+		},
+		{
+			name:          "burst spent",
+			takes:         []time.Duration{0, 0, 0},
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+		{
+			name:           "over the burst",
+			takes:          []time.Duration{0, 0, 0, 0},
+			wantRetryAfter: time.Second,
+		},
+		{
+			name:           "partly refilled",
+			takes:          []time.Duration{0, 0, 0, 400 * time.Millisecond},
+			wantRetryAfter: 600 * time.Millisecond,
+		},
+		{
+			name:          "refilled a token",
+			takes:         []time.Duration{0, 0, 0, time.Second},
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+		{
+			name:          "refill stops at the burst",
+			takes:         []time.Duration{0, time.Hour},
+			wantAllowed:   true,
+			wantRemaining: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One request a second with a burst of three
+			tb := newTokenBucket(60, time.Minute, 3)
+			var d decision
+			for _, offset := range tt.takes {
+				d = tb.take("ip:10.0.0.1", start.Add(offset))
+			}
+			if d.allowed != tt.wantAllowed || d.remaining != tt.wantRemaining || d.retryAfter != tt.wantRetryAfter {
+				t.Fatalf("take = allowed %t, remaining %d, retry after %s; want %t, %d, %s",
+					d.allowed, d.remaining, d.retryAfter, tt.wantAllowed, tt.wantRemaining, tt.wantRetryAfter)
+			}
+			if d.limit != 3 {
+				t.Fatalf("limit = %d, want 3", d.limit)
+			}
+		})
+	}
+}
+
+func TestTokenBucketKeepsKeysApart(t *testing.T) {
+	now := time.Now()
+	tb := newTokenBucket(1, time.Minute, 1)
+
+	if d := tb.take("ip:10.0.0.1", now); !d.allowed {
+		t.Fatal("first request of 10.0.0.1 refused")
+	}
+	if d := tb.take("ip:10.0.0.1", now); d.allowed {
+		t.Fatal("second request of 10.0.0.1 allowed")
+	}
+	if d := tb.take("ip:10.0.0.2", now); !d.allowed {
+		t.Fatal("10.0.0.2 refused because of 10.0.0.1")
+	}
+}
+
+func TestTokenBucketSweepsIdleBuckets(t *testing.T) {
+	now := time.Now()
+	tb := newTokenBucket(1, time.Minute, 1)
+	tb.take("ip:10.0.0.1", now)
+	tb.take("ip:10.0.0.2", now.Add(idleBucketTTL))
+
+	tb.take("ip:10.0.0.2", now.Add(idleBucketTTL+time.Second))
+	if _, kept := tb.buckets["ip:10.0.0.1"]; kept {
+		t.Fatal("idle bucket was not swept")
+	}
+	if _, kept := tb.buckets["ip:10.0.0.2"]; !kept {
+		t.Fatal("bucket in use was swept")
+	}
+}
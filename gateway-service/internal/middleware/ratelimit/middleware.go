@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// Limiter applies the rate limit policies from the gateway config. The unnamed policy built from
+// RATE_LIMIT_MAX_REQUESTS and RATE_LIMIT_DURATION is used by a bare "rate-limit" middleware. It
+// outlives config reloads, so callers cannot reset their limits by waiting for one.
+type Limiter struct {
+	mu       sync.Mutex
+	policies map[string]config.RateLimitPolicy
+	buckets  map[string]*tokenBucket
+}
+
+func NewLimiter(cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{}
+	l.Configure(cfg)
+	return l
+}
+
+// Configure switches to the policies of a reloaded config. Policies that did not change keep their
+// buckets and with them what every caller has already spent; changed ones start over.
+func (l *Limiter) Configure(cfg config.RateLimitConfig) {
+	policies := map[string]config.RateLimitPolicy{
+		"": {
+			Requests: cfg.MaxRequests,
+			Per:      cfg.Duration,
+			Burst:    cfg.MaxRequests,
+			Key:      "ip",
+		},
+	}
+	for name, policy := range cfg.Policies {
+		policies[name] = policy
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	buckets := make(map[string]*tokenBucket, len(policies))
+	for name, policy := range policies {
+		if previous, exists := l.policies[name]; exists && previous == policy {
+			buckets[name] = l.buckets[name]
+			continue
+		}
+		buckets[name] = newTokenBucket(policy.Requests, policy.Per, policy.Burst)
+	}
+	l.policies = policies
+	l.buckets = buckets
+}
+
+// HasPolicy reports whether a policy with the given name is configured.
+func (l *Limiter) HasPolicy(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, exists := l.policies[name]
+	return exists
+}
+
+// Middleware limits requests using the named policy. Requests over the limit get a 429 with
+// Retry-After; every response carries the X-RateLimit-* headers.
+func (l *Limiter) Middleware(policyName string) func(http.Handler) http.Handler {
+	l.mu.Lock()
+	policy := l.policies[policyName]
+	tb := l.buckets[policyName]
+	l.mu.Unlock()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := tb.take(requestKey(r, policy.Key), time.Now())
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(seconds(d.reset)))
+
+			if !d.allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(d.retryAfter)))
+				_ = utils.ErrorJSON(w, apperrors.NewTooManyRequestsError("rate limit exceeded, try again later"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestKey picks the bucket key for the request. Keys by user or store fall back to the client
+// IP when the request carries no identity or store. The IP is the one realip.Middleware left in
+// RemoteAddr, so clients cannot pick their own bucket with X-Forwarded-For.
+func requestKey(r *http.Request, keyType string) string {
+	switch keyType {
+	case "user":
+		if claims, ok := r.Context().Value("user").(*auth.Claims); ok {
+			return "user:" + strconv.Itoa(claims.UserID)
+		}
+	case "store":
+		if storeID := chi.URLParam(r, "store_id"); storeID != "" {
+			return "store:" + storeID
+		}
+		if storeSlug := chi.URLParam(r, "store_slug"); storeSlug != "" {
+			return "store-slug:" + storeSlug
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/middleware/realip"
+)
+
+func TestMiddlewareIgnoresSpoofedForwardedFor(t *testing.T) {
+	l := NewLimiter(config.RateLimitConfig{
+		MaxRequests: 100,
+		Duration:    time.Minute,
+		Policies:    map[string]config.RateLimitPolicy{"login": {Requests: 2, Per: time.Minute, Burst: 2, Key: "ip"}},
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	// No trusted proxies, as when clients reach the gateway directly
+	handler := realip.Middleware(nil)(l.Middleware("login")(ok))
+
+	for i := range 3 {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.RemoteAddr = "203.0.113.7:52000"
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
+
+func TestConfigureKeepsUnchangedBuckets(t *testing.T) {
+	login := config.RateLimitPolicy{Requests: 5, Per: time.Minute, Burst: 5, Key: "ip"}
+	search := config.RateLimitPolicy{Requests: 50, Per: time.Minute, Burst: 50, Key: "ip"}
+	cfg := config.RateLimitConfig{
+		MaxRequests: 100,
+		Duration:    time.Minute,
+		Policies:    map[string]config.RateLimitPolicy{"login": login, "search": search},
+	}
+	l := NewLimiter(cfg)
+	before := map[string]*tokenBucket{"": l.buckets[""], "login": l.buckets["login"], "search": l.buckets["search"]}
+
+	search.Burst = 10
+	cfg.Policies = map[string]config.RateLimitPolicy{"login": login, "search": search}
+	l.Configure(cfg)
+
+	if l.buckets[""] != before[""] || l.buckets["login"] != before["login"] {
+		t.Fatal("unchanged policies lost their buckets on reload")
+	}
+	if l.buckets["search"] == before["search"] {
+		t.Fatal("changed policy kept its old bucket")
+	}
+
+	cfg.Policies = map[string]config.RateLimitPolicy{"login": login}
+	l.Configure(cfg)
+	if l.HasPolicy("search") {
+		t.Fatal("removed policy is still configured")
+	}
+}
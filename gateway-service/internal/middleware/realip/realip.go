@@ -0,0 +1,77 @@
+// Package realip finds the address of the client a request came from when the gateway runs behind
+// load balancers or other proxies.
+package realip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Middleware replaces RemoteAddr with the client's address as reported by the trusted proxies.
+// X-Forwarded-For and X-Real-IP are only read when the request comes from one of them: anyone else
+// could send the headers to pose as another client. X-Forwarded-For is read from the right, where
+// each proxy appends the address it got the request from, and the first address that is not a
+// trusted proxy is taken as the client's. With no trusted proxies the headers are ignored.
+func Middleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := ClientIP(r, trusted); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the client address the trusted proxies report for r, or "" if r did not come
+// through a trusted proxy or it reported no address.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok || !isTrusted(peer, trusted) {
+		return ""
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseAddr(strings.TrimSpace(hops[i]))
+			if !ok {
+				// Whatever is left of an address we cannot read was not written by a trusted proxy
+				return ""
+			}
+			if !isTrusted(hop, trusted) {
+				return hop.String()
+			}
+		}
+		// Every hop is a proxy of ours, so the request started inside our own network
+		return ""
+	}
+
+	if realIP, ok := parseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ok {
+		return realIP.String()
+	}
+	return ""
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr reads an address with or without a port, unmapping IPv4 addresses written as IPv6.
+func parseAddr(value string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
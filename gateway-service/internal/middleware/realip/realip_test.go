@@ -0,0 +1,108 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			trusted:    trusted,
+			remoteAddr: "203.0.113.7:52000",
+			want:       "",
+		},
+		{
+			name:       "spoofed headers from a client",
+			trusted:    trusted,
+			remoteAddr: "203.0.113.7:52000",
+			forwarded:  []string{"198.51.100.1"},
+			realIP:     "198.51.100.2",
+			want:       "",
+		},
+		{
+			name:       "headers ignored without trusted proxies",
+			remoteAddr: "10.0.0.2:52000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "",
+		},
+		{
+			name:       "forwarded by a trusted proxy",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:52000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "client-sent hops before the real client are skipped",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:52000",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1", "10.0.0.3"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "unreadable hop",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:52000",
+			forwarded:  []string{"1.2.3.4, not-an-ip"},
+			want:       "",
+		},
+		{
+			name:       "only trusted hops",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:52000",
+			forwarded:  []string{"10.0.0.9"},
+			want:       "",
+		},
+		{
+			name:       "X-Real-IP from a trusted proxy",
+			trusted:    trusted,
+			remoteAddr: "[fd00::1]:52000",
+			realIP:     "2001:db8::5",
+			want:       "2001:db8::5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r, tt.trusted); got != tt.want {
+				t.Fatalf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeepsUntrustedRemoteAddr(t *testing.T) {
+	var got string
+	handler := Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.RemoteAddr
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/login", nil)
+	r.RemoteAddr = "203.0.113.7:52000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got != "203.0.113.7:52000" {
+		t.Fatalf("RemoteAddr = %q, want the peer address", got)
+	}
+}
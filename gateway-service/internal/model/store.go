@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/robaa12/gatway-service/internal/middleware/auth"
@@ -33,6 +34,23 @@ type StoreResponse struct {
 	AccessToken *auth.TokenResponse `json:"tokens,omitempty"`
 }
 
+// StoreExport is a store's data as the services hold it, returned when it is deleted
+type StoreExport struct {
+	StoreID    uint            `json:"store_id"`
+	Products   json.RawMessage `json:"products"`
+	Orders     json.RawMessage `json:"orders"`
+	Customers  json.RawMessage `json:"customers"`
+	ExportedAt time.Time       `json:"exported_at"`
+}
+
+// DeleteStoreResponse is the response structure for store deletion
+type DeleteStoreResponse struct {
+	StoreID     uint                `json:"store_id"`
+	SagaID      string              `json:"saga_id"`
+	Export      *StoreExport        `json:"export,omitempty"`
+	AccessToken *auth.TokenResponse `json:"tokens,omitempty"`
+}
+
 func (s *Store) GetStoreResponse(accessToken *auth.TokenResponse) *StoreResponse {
 	return &StoreResponse{
 		Store:       *s,
@@ -0,0 +1,17 @@
+package model
+
+import "encoding/json"
+
+// StorefrontSection is one part of a storefront page: what a service returned for it, or why it
+// could not be loaded
+type StorefrontSection struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// StorefrontPage is a storefront page composed from several services. A section that failed
+// carries an error instead of data, so the rest of the page can still be rendered.
+type StorefrontPage struct {
+	Store    Store                        `json:"store"`
+	Sections map[string]StorefrontSection `json:"sections"`
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+var errNoHealthyInstance = errors.New("no healthy instance available")
+
+// instance is one replica of an upstream service.
+type instance struct {
+	target   *url.URL
+	healthy  atomic.Bool
+	inflight atomic.Int64
+}
+
+func newInstance(rawURL string) (*instance, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	inst := &instance{target: target}
+	// Instances start healthy so traffic flows before the first probe completes
+	inst.healthy.Store(true)
+	return inst, nil
+}
+
+// balancer picks a healthy instance for every outgoing request.
+type balancer struct {
+	strategy  string
+	instances []*instance
+	next      atomic.Uint64
+}
+
+func (b *balancer) pick() *instance {
+	switch b.strategy {
+	case config.LoadBalancingLeastConnections:
+		var best *instance
+		for _, inst := range b.instances {
+			if !inst.healthy.Load() {
+				continue
+			}
+			if best == nil || inst.inflight.Load() < best.inflight.Load() {
+				best = inst
+			}
+		}
+		return best
+	default:
+		start := b.next.Add(1)
+		for i := range b.instances {
+			inst := b.instances[(start+uint64(i))%uint64(len(b.instances))]
+			if inst.healthy.Load() {
+				return inst
+			}
+		}
+		return nil
+	}
+}
+
+// balancedTransport sends each attempt to the instance the balancer picks, so retries can land on
+// a different replica. An instance counts as busy until the response body is closed.
+type balancedTransport struct {
+	base     http.RoundTripper
+	balancer *balancer
+}
+
+func (t *balancedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	inst := t.balancer.pick()
+	if inst == nil {
+		return nil, errNoHealthyInstance
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = inst.target.Scheme
+	out.URL.Host = inst.target.Host
+
+	inst.inflight.Add(1)
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		inst.inflight.Add(-1)
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { inst.inflight.Add(-1) }}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	closed  atomic.Bool
+}
+
+func (b *releasingBody) Close() error {
+	if b.closed.CompareAndSwap(false, true) {
+		b.release()
+	}
+	return b.ReadCloser.Close()
+}
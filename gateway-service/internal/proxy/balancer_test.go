@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+func newTestInstances(t *testing.T, urls ...string) []*instance {
+	t.Helper()
+	instances := make([]*instance, 0, len(urls))
+	for _, rawURL := range urls {
+		inst, err := newInstance(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instances = append(instances, inst)
+	}
+	return instances
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	instances := newTestInstances(t, "http://a", "http://b", "http://c")
+	b := &balancer{strategy: config.LoadBalancingRoundRobin, instances: instances}
+
+	picked := map[string]int{}
+	for range 6 {
+		picked[b.pick().target.Host]++
+	}
+	if picked["a"] != 2 || picked["b"] != 2 || picked["c"] != 2 {
+		t.Fatalf("picks = %v, want two each", picked)
+	}
+
+	instances[1].healthy.Store(false)
+	for range 4 {
+		if host := b.pick().target.Host; host == "b" {
+			t.Fatal("picked an unhealthy instance")
+		}
+	}
+
+	for _, inst := range instances {
+		inst.healthy.Store(false)
+	}
+	if inst := b.pick(); inst != nil {
+		t.Fatalf("picked %s with every instance unhealthy", inst.target)
+	}
+}
+
+func TestBalancerLeastConnections(t *testing.T) {
+	instances := newTestInstances(t, "http://a", "http://b", "http://c")
+	b := &balancer{strategy: config.LoadBalancingLeastConnections, instances: instances}
+	instances[0].inflight.Store(3)
+	instances[1].inflight.Store(1)
+	instances[2].inflight.Store(2)
+
+	if host := b.pick().target.Host; host != "b" {
+		t.Fatalf("picked %s, want the least busy instance b", host)
+	}
+	instances[1].healthy.Store(false)
+	if host := b.pick().target.Host; host != "c" {
+		t.Fatalf("picked %s, want c once b is unhealthy", host)
+	}
+}
+
+func TestBalancedTransportReleasesInstances(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	instances := newTestInstances(t, upstream.URL)
+	transport := &balancedTransport{
+		base:     http.DefaultTransport,
+		balancer: &balancer{strategy: config.LoadBalancingLeastConnections, instances: instances},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://product-service/products", nil)
+	req.RequestURI = ""
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := instances[0].inflight.Load(); got != 1 {
+		t.Fatalf("in flight while the body is open = %d, want 1", got)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if got := instances[0].inflight.Load(); got != 0 {
+		t.Fatalf("in flight after closing the body = %d, want 0", got)
+	}
+
+	instances[0].healthy.Store(false)
+	if _, err := transport.RoundTrip(req); err != errNoHealthyInstance {
+		t.Fatalf("RoundTrip with no healthy instance = %v, want %v", err, errNoHealthyInstance)
+	}
+}
+
+func TestHealthChecker(t *testing.T) {
+	status := http.StatusOK
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("probed %s, want /health", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer upstream.Close()
+
+	inst := newTestInstances(t, upstream.URL)[0]
+	hc := newHealthChecker("product-service", config.HealthCheckConfig{
+		Path:               "/health",
+		UnhealthyThreshold: 2,
+		HealthyThreshold:   2,
+	}, []*instance{inst}, http.DefaultTransport)
+	ctx := context.Background()
+
+	// Each step probes once; the instance only changes state after enough probes in a row agree
+	steps := []struct {
+		status      int
+		wantHealthy bool
+	}{
+		{http.StatusServiceUnavailable, true},
+		{http.StatusOK, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusInternalServerError, false},
+		{http.StatusOK, false},
+		{http.StatusOK, true},
+	}
+	for i, step := range steps {
+		status = step.status
+		hc.probeAll(ctx)
+		if got := inst.healthy.Load(); got != step.wantHealthy {
+			t.Fatalf("after probe %d (%d): healthy = %t, want %t", i+1, step.status, got, step.wantHealthy)
+		}
+	}
+}
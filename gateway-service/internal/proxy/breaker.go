@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// breaker is a consecutive-failure circuit breaker. While open every call is rejected; once the
+// open period has passed a single probe is let through and its outcome closes or re-opens it.
+type breaker struct {
+	mu          sync.Mutex
+	state       string
+	failures    int
+	maxFailures int
+	openFor     time.Duration
+	openedAt    time.Time
+	probing     bool
+}
+
+func newBreaker(cfg config.BreakerConfig) *breaker {
+	return &breaker{
+		state:       breakerClosed,
+		maxFailures: cfg.Failures,
+		openFor:     cfg.OpenFor,
+	}
+}
+
+// allow reports whether a call may go through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.openFor {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record stores the outcome of a call that allow let through.
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.maxFailures {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// status returns the breaker's current state.
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state != breakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// release frees a half-open probe whose outcome is unknown, e.g. because the client went away.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+func TestBreaker(t *testing.T) {
+	// Steps: "ok" and "fail" are let through and recorded, "reject" must be refused, "wait" lets
+	// the open period pass and "release" frees a probe without an outcome.
+	tests := []struct {
+		name      string
+		steps     []string
+		wantState string
+	}{
+		{"stays closed below the limit", []string{"fail", "fail", "ok", "fail", "fail"}, breakerClosed},
+		{"opens at the limit", []string{"fail", "fail", "fail", "reject"}, breakerOpen},
+		{"lets one probe through after the open period", []string{"fail", "fail", "fail", "wait", "probe", "reject"}, breakerHalfOpen},
+		{"closes when the probe succeeds", []string{"fail", "fail", "fail", "wait", "ok", "ok"}, breakerClosed},
+		{"re-opens when the probe fails", []string{"fail", "fail", "fail", "wait", "fail", "reject"}, breakerOpen},
+		{"released probe can be retried", []string{"fail", "fail", "fail", "wait", "probe", "release", "ok"}, breakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(config.BreakerConfig{Failures: 3, OpenFor: time.Minute})
+			for i, step := range tt.steps {
+				switch step {
+				case "ok", "fail", "probe":
+					if !b.allow() {
+						t.Fatalf("step %d (%s): call refused in state %s", i, step, b.state)
+					}
+					if step != "probe" {
+						b.record(step == "ok")
+					}
+				case "reject":
+					if b.allow() {
+						t.Fatalf("step %d: call allowed in state %s", i, b.state)
+					}
+				case "wait":
+					b.openedAt = b.openedAt.Add(-b.openFor)
+				case "release":
+					b.release()
+				}
+			}
+			if status := b.status(); status.State != tt.wantState {
+				t.Fatalf("state = %s, want %s", status.State, tt.wantState)
+			}
+		})
+	}
+}
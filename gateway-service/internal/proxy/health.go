@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+// healthChecker probes every instance of a service on a fixed interval and flips its healthy flag
+// once enough consecutive probes agree.
+type healthChecker struct {
+	serviceName string
+	cfg         config.HealthCheckConfig
+	instances   []*instance
+	client      *http.Client
+	successes   []int
+	failures    []int
+}
+
+func newHealthChecker(serviceName string, cfg config.HealthCheckConfig, instances []*instance, transport http.RoundTripper) *healthChecker {
+	return &healthChecker{
+		serviceName: serviceName,
+		cfg:         cfg,
+		instances:   instances,
+		client:      &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		successes:   make([]int, len(instances)),
+		failures:    make([]int, len(instances)),
+	}
+}
+
+// run probes until ctx is cancelled.
+func (hc *healthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(hc.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		hc.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (hc *healthChecker) probeAll(ctx context.Context) {
+	for i, inst := range hc.instances {
+		if hc.probe(ctx, inst) {
+			hc.failures[i] = 0
+			hc.successes[i]++
+			if !inst.healthy.Load() && hc.successes[i] >= hc.cfg.HealthyThreshold {
+				inst.healthy.Store(true)
+				slog.InfoContext(ctx, "Instance is healthy again", "service", hc.serviceName, "target", inst.target.String())
+			}
+			continue
+		}
+
+		hc.successes[i] = 0
+		hc.failures[i]++
+		if inst.healthy.Load() && hc.failures[i] >= hc.cfg.UnhealthyThreshold {
+			inst.healthy.Store(false)
+			slog.WarnContext(ctx, "Instance failed health checks, removing it from rotation", "service", hc.serviceName, "target", inst.target.String(), "failures", hc.failures[i])
+		}
+	}
+}
+
+func (hc *healthChecker) probe(ctx context.Context, inst *instance) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(inst.target.String(), "/")+hc.cfg.Path, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := hc.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
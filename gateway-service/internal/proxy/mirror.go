@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/metrics"
+)
+
+// HeaderShadow marks the copies of requests sent to a mirror service.
+const HeaderShadow = "X-Shadow-Request"
+
+const (
+	// defaultMirrorMaxBody bounds the bodies copied when the route sets no max_body
+	defaultMirrorMaxBody = 1 << 20
+	// maxMirrorsInFlight bounds the copies of a route running at once; more are dropped
+	maxMirrorsInFlight = 100
+)
+
+// Reasons a request was not copied to its mirror service.
+const (
+	mirrorBodyTooLarge = "body_too_large"
+	mirrorBusy         = "busy"
+)
+
+// Mirror serves a route through next and copies a sample of its requests to a mirror service in
+// the background. The copies never delay the route's responses: they run in their own goroutines
+// once the body has been read, and are dropped when too many are running.
+type Mirror struct {
+	next       http.Handler
+	route      string
+	service    *Service
+	sampleRate float64
+	allMethods bool
+	maxBody    int64
+	inFlight   chan struct{}
+}
+
+// NewMirror wraps next, the handler of route, with the route's mirror from reg. The config has
+// been validated, so the mirror service is in reg.
+func (reg *Registry) NewMirror(route config.RouteConfig, next http.Handler) *Mirror {
+	maxBody := int64(defaultMirrorMaxBody)
+	if route.Mirror.MaxBody != "" {
+		maxBody, _ = config.ParseSize(route.Mirror.MaxBody)
+	}
+	return &Mirror{
+		next:       next,
+		route:      route.PublicPath(),
+		service:    reg.Service(route.Mirror.Service),
+		sampleRate: route.Mirror.SampleRate,
+		allMethods: route.Mirror.AllMethods,
+		maxBody:    maxBody,
+		inFlight:   make(chan struct{}, maxMirrorsInFlight),
+	}
+}
+
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !m.sampled(r) {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+	if r.ContentLength > m.maxBody {
+		metrics.MirrorDropped(m.route, m.service.name, mirrorBodyTooLarge)
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	// Read the body once for both requests; a body of unknown length may turn out too large, and
+	// is then still sent whole to the route's service
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.maxBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	switch {
+	case err != nil:
+		// Not copied; the route's service runs into the same error reading the rest
+	case int64(len(body)) > m.maxBody:
+		metrics.MirrorDropped(m.route, m.service.name, mirrorBodyTooLarge)
+	default:
+		m.send(r, body)
+	}
+
+	m.next.ServeHTTP(w, r)
+}
+
+func (m *Mirror) sampled(r *http.Request) bool {
+	if !m.allMethods && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return rand.Float64() < m.sampleRate
+}
+
+// send copies r to the mirror service in the background, unless too many copies are running. The
+// copy keeps the caller's identity but not their cancellation, and its response is discarded.
+func (m *Mirror) send(r *http.Request, body []byte) {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		metrics.MirrorDropped(m.route, m.service.name, mirrorBusy)
+		return
+	}
+
+	shadow := r.Clone(context.WithoutCancel(r.Context()))
+	shadow.Body = io.NopCloser(bytes.NewReader(body))
+	shadow.ContentLength = int64(len(body))
+	shadow.TransferEncoding = nil
+	shadow.Header.Set(HeaderShadow, "true")
+
+	go func() {
+		defer func() { <-m.inFlight }()
+
+		w := &discardWriter{header: http.Header{}}
+		m.service.ServeHTTP(w, shadow)
+		metrics.ObserveMirror(m.route, m.service.name, w.status)
+	}()
+}
+
+// discardWriter takes the mirror service's response and throws it away, keeping only its status.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
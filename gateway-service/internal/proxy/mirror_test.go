@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/identity"
+)
+
+// shadowRequest is what the mirror service received.
+type shadowRequest struct {
+	method, path, body, shadow string
+}
+
+// newMirror mirrors route to a fake staging service, whose requests arrive on the returned
+// channel, in front of a route handler that echoes the body it reads.
+func newMirror(t *testing.T, mirror config.MirrorConfig, staging http.HandlerFunc) (*Mirror, <-chan shadowRequest) {
+	t.Helper()
+	received := make(chan shadowRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- shadowRequest{r.Method, r.URL.Path, string(body), r.Header.Get(HeaderShadow)}
+		if staging != nil {
+			staging(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	reg := NewRegistry(identity.NewSigner("test-secret"))
+	t.Cleanup(reg.Close)
+	if err := reg.Sync(map[string]config.ServiceConfig{
+		"order-service-staging": {
+			Instances: []string{srv.URL},
+			Timeout:   5 * time.Second,
+			Breaker:   config.BreakerConfig{Failures: 5, OpenFor: time.Minute},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mirror.Service = "order-service-staging"
+	route := config.RouteConfig{Path: "/orders", Service: "order-service", Mirror: &mirror}
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})
+	return reg.NewMirror(route, echo), received
+}
+
+func TestMirror(t *testing.T) {
+	tests := []struct {
+		name       string
+		mirror     config.MirrorConfig
+		method     string
+		body       string
+		wantMirror bool
+	}{
+		{name: "GET", mirror: config.MirrorConfig{SampleRate: 1}, method: http.MethodGet, wantMirror: true},
+		{name: "not sampled", mirror: config.MirrorConfig{SampleRate: 0}, method: http.MethodGet},
+		{name: "POST on a GET-only mirror", mirror: config.MirrorConfig{SampleRate: 1}, method: http.MethodPost, body: `{"total":3}`},
+		{name: "POST", mirror: config.MirrorConfig{SampleRate: 1, AllMethods: true}, method: http.MethodPost, body: `{"total":3}`, wantMirror: true},
+		{name: "body within max_body", mirror: config.MirrorConfig{SampleRate: 1, AllMethods: true, MaxBody: "11B"}, method: http.MethodPost, body: `{"total":3}`, wantMirror: true},
+		{name: "body too large", mirror: config.MirrorConfig{SampleRate: 1, AllMethods: true, MaxBody: "4B"}, method: http.MethodPost, body: `{"total":3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, received := newMirror(t, tt.mirror, nil)
+
+			// A body of unknown length, as with chunked uploads, is measured as it is read
+			r := httptest.NewRequest(tt.method, "/orders", io.NopCloser(strings.NewReader(tt.body)))
+			r.ContentLength = -1
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, r)
+
+			if w.Body.String() != tt.body {
+				t.Fatalf("route read body %q, want %q", w.Body.String(), tt.body)
+			}
+			select {
+			case got := <-received:
+				if !tt.wantMirror {
+					t.Fatalf("mirrored %+v, want nothing mirrored", got)
+				}
+				want := shadowRequest{tt.method, "/orders", tt.body, "true"}
+				if got != want {
+					t.Fatalf("mirror received %+v, want %+v", got, want)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.wantMirror {
+					t.Fatal("nothing mirrored")
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorDoesNotDelayTheRoute(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m, received := newMirror(t, config.MirrorConfig{SampleRate: 1}, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("route answered after %s, waiting on its mirror", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	<-received
+}
@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+)
+
+// maxErrorBody bounds the upstream error bodies rewritten; larger ones are passed on unchanged
+const maxErrorBody = 64 << 10
+
+// fieldName matches the property a validation message starts with, as in "email must be an email"
+var fieldName = regexp.MustCompile(`^[A-Za-z_][\w.\[\]]*$`)
+
+// normalizeError rewrites an error response of a service that does not send problems, such as
+// user-service's {status, code, message}, into one, so clients get one error contract whichever
+// service answered. The gateway has already sent its own request ID, so the upstream's is dropped.
+func normalizeError(resp *http.Response) {
+	resp.Header.Del(middleware.RequestIDHeader)
+	if resp.StatusCode < http.StatusBadRequest || resp.Request.Method == http.MethodHead {
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == apperrors.ContentTypeProblem {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+	if err == nil && len(body) > maxErrorBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return
+	}
+	resp.Body.Close()
+
+	problem := upstreamProblem(resp.StatusCode, body)
+	problem.RequestID = middleware.GetReqID(resp.Request.Context())
+	out, _ := json.Marshal(problem)
+
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	resp.Header.Set("Content-Type", apperrors.ContentTypeProblem)
+	resp.Header.Del("Content-Encoding")
+}
+
+// upstreamProblem reads the message of an upstream error body. A list of messages, as sent for a
+// request failing validation, becomes the problem's field errors.
+func upstreamProblem(status int, body []byte) apperrors.Problem {
+	var payload struct {
+		Message json.RawMessage `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return apperrors.NewProblem(status, errors.New(""))
+	}
+
+	var messages []string
+	if json.Unmarshal(payload.Message, &messages) == nil && len(messages) > 0 {
+		fields := make([]apperrors.FieldError, 0, len(messages))
+		for _, message := range messages {
+			field, _, _ := strings.Cut(message, " ")
+			if !fieldName.MatchString(field) {
+				field = ""
+			}
+			fields = append(fields, apperrors.FieldError{Field: field, Message: message})
+		}
+		if status == http.StatusBadRequest {
+			return apperrors.NewProblem(status, apperrors.NewValidationError("request validation failed", fields...))
+		}
+		problem := apperrors.NewProblem(status, errors.New(messages[0]))
+		problem.Errors = fields
+		return problem
+	}
+
+	var detail string
+	if json.Unmarshal(payload.Message, &detail) != nil || detail == "" {
+		_ = json.Unmarshal(payload.Error, &detail)
+	}
+	return apperrors.NewProblem(status, errors.New(detail))
+}
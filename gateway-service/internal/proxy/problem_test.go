@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+)
+
+func TestNormalizeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		status      int
+		contentType string
+		body        string
+		want        *apperrors.Problem
+	}{
+		{
+			name:        "user-service error",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			body:        `{"status":"error","code":404,"message":"user not found"}`,
+			want:        &apperrors.Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "user not found", Code: "NOT_FOUND", RequestID: "req-1"},
+		},
+		{
+			name:        "validation messages",
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":["email must be an email","password should not be empty"]}`,
+			want: &apperrors.Problem{
+				Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "request validation failed",
+				Code: apperrors.CodeValidationFailed, RequestID: "req-1",
+				Errors: []apperrors.FieldError{
+					{Field: "email", Message: "email must be an email"},
+					{Field: "password", Message: "password should not be empty"},
+				},
+			},
+		},
+		{
+			name:        "list of messages on another status",
+			status:      http.StatusConflict,
+			contentType: "application/json",
+			body:        `{"message":["store slug already taken"]}`,
+			want: &apperrors.Problem{
+				Type: "about:blank", Title: "Conflict", Status: 409, Detail: "store slug already taken",
+				Code: "CONFLICT", RequestID: "req-1",
+				Errors: []apperrors.FieldError{{Field: "store", Message: "store slug already taken"}},
+			},
+		},
+		{
+			name:        "error field",
+			status:      http.StatusUnauthorized,
+			contentType: "application/json",
+			body:        `{"error":"token expired"}`,
+			want:        &apperrors.Problem{Type: "about:blank", Title: "Unauthorized", Status: 401, Detail: "token expired", Code: "UNAUTHORIZED", RequestID: "req-1"},
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusBadGateway,
+			contentType: "text/plain",
+			body:        "upstream connect error",
+			want:        &apperrors.Problem{Type: "about:blank", Title: "Bad Gateway", Status: 502, Code: "BAD_GATEWAY", RequestID: "req-1"},
+		},
+		{
+			name:        "already a problem",
+			status:      http.StatusNotFound,
+			contentType: apperrors.ContentTypeProblem,
+			body:        `{"type":"about:blank","title":"Not Found","status":404,"code":"PRODUCT_NOT_FOUND"}`,
+		},
+		{
+			name:        "success",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"message":"ok"}`,
+		},
+		{
+			name:   "head request",
+			method: http.MethodHead,
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/users/1", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Content-Type": {tt.contentType}, middleware.RequestIDHeader: {"upstream-id"}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    req,
+			}
+
+			normalizeError(resp)
+
+			if resp.Header.Get(middleware.RequestIDHeader) != "" {
+				t.Error("upstream request id was kept")
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if string(body) != tt.body || resp.Header.Get("Content-Type") != tt.contentType {
+					t.Fatalf("response rewritten to %s %s", resp.Header.Get("Content-Type"), body)
+				}
+				return
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != apperrors.ContentTypeProblem {
+				t.Fatalf("Content-Type = %s, want %s", ct, apperrors.ContentTypeProblem)
+			}
+			if resp.ContentLength != int64(len(body)) {
+				t.Fatalf("ContentLength = %d, body has %d bytes", resp.ContentLength, len(body))
+			}
+			var got apperrors.Problem
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("body %s: %v", body, err)
+			}
+			if !reflect.DeepEqual(got, *tt.want) {
+				t.Fatalf("problem = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeErrorPassesLargeBodiesOn(t *testing.T) {
+	body := `{"message":"` + strings.Repeat("x", maxErrorBody) + `"}`
+	resp := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    httptest.NewRequest(http.MethodGet, "/users", nil),
+	}
+
+	normalizeError(resp)
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Fatalf("large body changed: got %d bytes, want %d", len(got), len(body))
+	}
+}
@@ -1,44 +1,145 @@
 package proxy
 
 import (
-	"github.com/robaa12/gatway-service/internal/config"
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/metrics"
+	"github.com/robaa12/gatway-service/internal/tracing"
+	"github.com/robaa12/gatway-service/utils"
 )
 
+// Service is a long-lived reverse proxy to one upstream service. Requests are balanced across the
+// service's healthy instances, bounded by the service timeout and guarded by its circuit breaker.
 type Service struct {
-	serviceConfig *config.ServiceConfig
-}
-
-func NewProxyService(serviceConfig *config.ServiceConfig) http.Handler {
-	return &Service{serviceConfig: serviceConfig}
-}
-
-func (proxyService *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-
-	proxy := proxyService.createProxy()
-
-	proxy.ServeHTTP(w, r)
+	name          string
+	serviceConfig config.ServiceConfig
+	proxy         *httputil.ReverseProxy
+	breaker       *breaker
+	balancer      *balancer
+	stopChecks    context.CancelFunc
+	maintenance   atomic.Pointer[Maintenance]
+	errors        errorWindow
 }
 
-func (proxyService *Service) createProxy() http.Handler {
-	target, err := url.Parse(proxyService.serviceConfig.URL)
-	log.Println(proxyService.serviceConfig.URL)
-	if err != nil {
-		panic(err)
+// NewProxyService builds the proxy for a service and starts probing its instances when a health
+// check is configured. Close stops the probes. Client-supplied identity headers are replaced by ones
+// signed with signer.
+func NewProxyService(name string, serviceConfig config.ServiceConfig, transport http.RoundTripper, signer *identity.Signer) (*Service, error) {
+	instances := make([]*instance, 0, len(serviceConfig.Instances))
+	for _, rawURL := range serviceConfig.Instances {
+		inst, err := newInstance(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: invalid instance url %q: %w", name, rawURL, err)
+		}
+		instances = append(instances, inst)
 	}
 
-	return &httputil.ReverseProxy{
+	proxyService := &Service{
+		name:          name,
+		serviceConfig: serviceConfig,
+		breaker:       newBreaker(serviceConfig.Breaker),
+		balancer: &balancer{
+			strategy:  serviceConfig.LoadBalancing,
+			instances: instances,
+		},
+		stopChecks: func() {},
+	}
+	proxyService.proxy = &httputil.ReverseProxy{
 		Director: func(r *http.Request) {
 			r.Header.Add("X-Forwarded-Host", r.Host)
-			r.URL.Scheme = target.Scheme
-			r.URL.Host = target.Host
 			r.URL.RawPath = r.URL.Path
+			signer.Sign(r)
+		},
+		Transport: &retryTransport{
+			base: &balancedTransport{
+				base:     tracing.Transport(transport),
+				balancer: proxyService.balancer,
+			},
+			retries: serviceConfig.Retries,
 		},
 		ModifyResponse: func(r *http.Response) error {
+			proxyService.breaker.record(!isUpstreamFailure(r.StatusCode))
+			normalizeError(r)
 			return nil
 		},
+		ErrorHandler: proxyService.handleError,
+	}
+
+	if serviceConfig.HealthCheck.Path != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		proxyService.stopChecks = cancel
+		go newHealthChecker(name, serviceConfig.HealthCheck, instances, transport).run(ctx)
+	}
+
+	return proxyService, nil
+}
+
+// Close stops the service's health checks.
+func (proxyService *Service) Close() {
+	proxyService.stopChecks()
+}
+
+func (proxyService *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m := proxyService.maintenance.Load(); m != nil {
+		m.write(w, proxyService.name)
+		return
+	}
+	if !proxyService.breaker.allow() {
+		_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError(fmt.Sprintf("%s is temporarily unavailable", proxyService.name)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeoutCause(r.Context(), proxyService.serviceConfig.Timeout, errUpstreamTimeout)
+	defer cancel()
+
+	start := time.Now()
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	proxyService.proxy.ServeHTTP(ww, r.WithContext(ctx))
+	metrics.ObserveUpstream(proxyService.name, r.Method, ww.Status(), time.Since(start))
+	proxyService.errors.record(time.Now(), ww.Status())
+}
+
+// errUpstreamTimeout is the cause of a request running past its service's timeout, telling it apart
+// from a request whose own deadline ran out first. The transport may return it in place of
+// context.DeadlineExceeded, so it wraps that.
+var errUpstreamTimeout = fmt.Errorf("service timeout exceeded: %w", context.DeadlineExceeded)
+
+func (proxyService *Service) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	// The client gave up; that says nothing about the upstream's health
+	if errors.Is(err, context.Canceled) {
+		proxyService.breaker.release()
+		w.WriteHeader(499)
+		return
+	}
+
+	if errors.Is(err, errNoHealthyInstance) {
+		proxyService.breaker.release()
+		_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError(fmt.Sprintf("%s has no healthy instances", proxyService.name)))
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) && context.Cause(r.Context()) != errUpstreamTimeout {
+		// The request ran out of its own time, e.g. a route timeout shorter than the service's, so
+		// the service was not given as long as it may take
+		proxyService.breaker.release()
+		_ = utils.ErrorJSON(w, apperrors.NewGatewayTimeoutError(fmt.Sprintf("%s did not respond in time", proxyService.name)))
+		return
+	}
+
+	proxyService.breaker.record(false)
+	if errors.Is(err, context.DeadlineExceeded) {
+		_ = utils.ErrorJSON(w, apperrors.NewGatewayTimeoutError(fmt.Sprintf("%s did not respond in time", proxyService.name)))
+		return
 	}
+	_ = utils.ErrorJSON(w, apperrors.NewBadGatewayError(fmt.Sprintf("%s is unreachable", proxyService.name)))
 }
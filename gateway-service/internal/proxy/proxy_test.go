@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/identity"
+)
+
+func TestTimeoutsAndTheBreaker(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name           string
+		serviceTimeout time.Duration
+		// requestTimeout is the deadline the request already has, e.g. from a route's timeout
+		requestTimeout time.Duration
+		wantState      string
+	}{
+		{name: "service too slow", serviceTimeout: 20 * time.Millisecond, wantState: breakerOpen},
+		{name: "route deadline ran out first", serviceTimeout: time.Minute, requestTimeout: 20 * time.Millisecond, wantState: breakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := NewProxyService("product-service", config.ServiceConfig{
+				Instances: []string{slow.URL},
+				Timeout:   tt.serviceTimeout,
+				Breaker:   config.BreakerConfig{Failures: 1, OpenFor: time.Minute},
+			}, http.DefaultTransport, identity.NewSigner("test-secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer svc.Close()
+
+			r := httptest.NewRequest(http.MethodGet, "/products", nil)
+			if tt.requestTimeout > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), tt.requestTimeout)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			w := httptest.NewRecorder()
+			svc.ServeHTTP(w, r)
+
+			if w.Code != http.StatusGatewayTimeout {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+			}
+			if got := svc.breaker.status().State; got != tt.wantState {
+				t.Fatalf("breaker is %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+}
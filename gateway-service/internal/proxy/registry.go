@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/identity"
+)
+
+// Registry owns one proxy Service per upstream and outlives config reloads, so a reload that does
+// not touch a service keeps its connections and breaker state.
+type Registry struct {
+	mu        sync.RWMutex
+	transport *http.Transport
+	services  map[string]*Service
+	signer    *identity.Signer
+	// maintenance is kept apart from services so it carries over to services a reload rebuilds
+	maintenance map[string]*Maintenance
+}
+
+// NewRegistry creates an empty registry whose proxies sign the caller's identity with signer.
+func NewRegistry(signer *identity.Signer) *Registry {
+	return &Registry{
+		transport:   newSharedTransport(),
+		services:    make(map[string]*Service),
+		signer:      signer,
+		maintenance: make(map[string]*Maintenance),
+	}
+}
+
+// Sync makes the registry match the given service configs, rebuilding only the services whose
+// config changed and dropping those no longer configured.
+func (reg *Registry) Sync(services map[string]config.ServiceConfig) error {
+	next := make(map[string]*Service, len(services))
+
+	reg.mu.RLock()
+	for name, cfg := range services {
+		if existing, ok := reg.services[name]; ok && reflect.DeepEqual(existing.serviceConfig, cfg) {
+			next[name] = existing
+		}
+	}
+	reg.mu.RUnlock()
+
+	for name, cfg := range services {
+		if _, kept := next[name]; kept {
+			continue
+		}
+		svc, err := NewProxyService(name, cfg, reg.transport, reg.signer)
+		if err != nil {
+			for newName, created := range next {
+				if reg.Service(newName) != created {
+					created.Close()
+				}
+			}
+			return err
+		}
+		next[name] = svc
+	}
+
+	reg.mu.Lock()
+	previous := reg.services
+	reg.services = next
+	for name, m := range reg.maintenance {
+		if svc, ok := next[name]; ok {
+			svc.maintenance.Store(m)
+		} else {
+			delete(reg.maintenance, name)
+		}
+	}
+	reg.mu.Unlock()
+
+	for name, svc := range previous {
+		if next[name] != svc {
+			svc.Close()
+		}
+	}
+	return nil
+}
+
+// Close stops every service's health checks.
+func (reg *Registry) Close() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, svc := range reg.services {
+		svc.Close()
+	}
+}
+
+// Service returns the proxy for the named upstream, or nil if it is not configured.
+func (reg *Registry) Service(name string) *Service {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return reg.services[name]
+}
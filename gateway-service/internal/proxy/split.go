@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"hash/fnv"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/metrics"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+)
+
+// Reasons a split route sent a request where it did.
+const (
+	SplitPinned   = "pinned"
+	SplitWeighted = "weighted"
+	SplitDefault  = "default"
+)
+
+// Split sends the requests of one route to several versions of its service, such as a canary
+// build next to the stable one.
+type Split struct {
+	route    string
+	fallback *Service
+	backends []splitBackend
+	total    int
+	pins     []splitPin
+}
+
+type splitBackend struct {
+	service *Service
+	weight  int
+}
+
+type splitPin struct {
+	config.SplitPin
+	service *Service
+}
+
+// NewSplit builds the split of route from the proxies in reg. The config has been validated, so
+// every service it names is in reg.
+func (reg *Registry) NewSplit(route config.RouteConfig) *Split {
+	split := &Split{
+		route:    route.PublicPath(),
+		fallback: reg.Service(route.Service),
+	}
+	for _, backend := range route.Split.Backends {
+		split.backends = append(split.backends, splitBackend{service: reg.Service(backend.Service), weight: backend.Weight})
+		split.total += backend.Weight
+	}
+	for _, pin := range route.Split.Pins {
+		split.pins = append(split.pins, splitPin{SplitPin: pin, service: reg.Service(pin.Service)})
+	}
+	return split
+}
+
+func (s *Split) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service, reason := s.pick(r)
+
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	service.ServeHTTP(ww, r)
+	metrics.ObserveRouteBackend(s.route, service.name, reason, ww.Status())
+}
+
+// pick chooses the version for r: the first pin it matches, otherwise one by weight. The weighted
+// choice hashes the caller, so a user stays on one version instead of switching between requests.
+func (s *Split) pick(r *http.Request) (*Service, string) {
+	for _, pin := range s.pins {
+		if pin.matches(r) {
+			return pin.service, SplitPinned
+		}
+	}
+	if s.total == 0 {
+		return s.fallback, SplitDefault
+	}
+
+	n := int(callerHash(r) % uint32(s.total))
+	for _, backend := range s.backends {
+		if n < backend.weight {
+			return backend.service, SplitWeighted
+		}
+		n -= backend.weight
+	}
+	return s.fallback, SplitDefault
+}
+
+func (p splitPin) matches(r *http.Request) bool {
+	switch {
+	case p.Header != "":
+		return r.Header.Get(p.Header) == p.Value
+	case p.Cookie != "":
+		cookie, err := r.Cookie(p.Cookie)
+		return err == nil && cookie.Value == p.Value
+	default:
+		storeID, err := strconv.Atoi(chi.URLParam(r, "store_id"))
+		return err == nil && slices.Contains(p.StoreIDs, storeID)
+	}
+}
+
+// callerHash identifies who sent r: their user or API key, or their address if anonymous.
+func callerHash(r *http.Request) uint32 {
+	caller := auth.CallerScope(r.Context())
+	if caller == "anonymous" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		caller = "ip:" + host
+	}
+	h := fnv.New32a()
+	h.Write([]byte(caller))
+	return h.Sum32()
+}
@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+func TestSplitPick(t *testing.T) {
+	stable := &Service{name: "product-service"}
+	canary := &Service{name: "product-service-canary"}
+	beta := &Service{name: "product-service-beta"}
+
+	pinned := &Split{
+		fallback: stable,
+		backends: []splitBackend{{service: canary, weight: 0}},
+		pins: []splitPin{
+			{SplitPin: config.SplitPin{Header: "X-Canary", Value: "1"}, service: canary},
+			{SplitPin: config.SplitPin{Cookie: "beta", Value: "yes"}, service: beta},
+			{SplitPin: config.SplitPin{StoreIDs: []int{5, 6}}, service: beta},
+		},
+	}
+	allCanary := &Split{fallback: stable, backends: []splitBackend{{service: canary, weight: 100}}, total: 100}
+	noWeights := &Split{fallback: stable}
+
+	tests := []struct {
+		name       string
+		split      *Split
+		request    func(r *http.Request) *http.Request
+		wantName   string
+		wantReason string
+	}{
+		{
+			name:  "header pin",
+			split: pinned,
+			request: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Canary", "1")
+				return r
+			},
+			wantName:   "product-service-canary",
+			wantReason: SplitPinned,
+		},
+		{
+			name:  "header pin with another value",
+			split: pinned,
+			request: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Canary", "0")
+				return r
+			},
+			wantName:   "product-service",
+			wantReason: SplitDefault,
+		},
+		{
+			name:  "cookie pin",
+			split: pinned,
+			request: func(r *http.Request) *http.Request {
+				r.AddCookie(&http.Cookie{Name: "beta", Value: "yes"})
+				return r
+			},
+			wantName:   "product-service-beta",
+			wantReason: SplitPinned,
+		},
+		{
+			name:       "store pin",
+			split:      pinned,
+			request:    func(r *http.Request) *http.Request { return withStoreID(r, "6") },
+			wantName:   "product-service-beta",
+			wantReason: SplitPinned,
+		},
+		{
+			name:       "store not pinned",
+			split:      pinned,
+			request:    func(r *http.Request) *http.Request { return withStoreID(r, "7") },
+			wantName:   "product-service",
+			wantReason: SplitDefault,
+		},
+		{
+			name:  "first matching pin wins",
+			split: pinned,
+			request: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Canary", "1")
+				return withStoreID(r, "5")
+			},
+			wantName:   "product-service-canary",
+			wantReason: SplitPinned,
+		},
+		{
+			name:       "all weight on one backend",
+			split:      allCanary,
+			request:    func(r *http.Request) *http.Request { return r },
+			wantName:   "product-service-canary",
+			wantReason: SplitWeighted,
+		},
+		{
+			name:       "no backends",
+			split:      noWeights,
+			request:    func(r *http.Request) *http.Request { return r },
+			wantName:   "product-service",
+			wantReason: SplitDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.request(httptest.NewRequest(http.MethodGet, "/stores/1/products", nil))
+			service, reason := tt.split.pick(r)
+			if service.name != tt.wantName || reason != tt.wantReason {
+				t.Fatalf("pick = %s (%s), want %s (%s)", service.name, reason, tt.wantName, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSplitPickSpreadsCallersByWeight(t *testing.T) {
+	stable := &Service{name: "stable"}
+	canary := &Service{name: "canary"}
+	split := &Split{
+		fallback: stable,
+		backends: []splitBackend{{service: stable, weight: 90}, {service: canary, weight: 10}},
+		total:    100,
+	}
+
+	const callers = 5000
+	onCanary := 0
+	for i := range callers {
+		r := httptest.NewRequest(http.MethodGet, "/products", nil)
+		r.RemoteAddr = fmt.Sprintf("10.%d.%d.%d:1234", i>>16&0xff, i>>8&0xff, i&0xff)
+
+		first, _ := split.pick(r)
+		// A caller stays on the version it was given
+		if again, _ := split.pick(r); again != first {
+			t.Fatalf("caller %s moved from %s to %s", r.RemoteAddr, first.name, again.name)
+		}
+		if first == canary {
+			onCanary++
+		}
+	}
+
+	if share := float64(onCanary) / callers; share < 0.07 || share > 0.13 {
+		t.Fatalf("canary got %.1f%% of callers, want about 10%%", share*100)
+	}
+}
+
+// withStoreID returns r as routed with the given {store_id}.
+func withStoreID(r *http.Request, storeID string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("store_id", storeID)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
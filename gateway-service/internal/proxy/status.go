@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+const (
+	// errorBucket and errorBuckets make the window recent upstream errors are counted over
+	errorBucket  = time.Minute
+	errorBuckets = 5
+)
+
+// Maintenance takes a service out of service: its proxy answers every request with a 503 instead
+// of calling it. Body, when set, is the JSON sent; otherwise a standard error with Message is.
+type Maintenance struct {
+	Message    string          `json:"message,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	RetryAfter time.Duration   `json:"-"`
+	Since      time.Time       `json:"since"`
+}
+
+// MarshalJSON lists RetryAfter as a duration such as "10m0s" rather than in nanoseconds.
+func (m *Maintenance) MarshalJSON() ([]byte, error) {
+	type fields Maintenance
+	out := struct {
+		*fields
+		RetryAfter string `json:"retry_after,omitempty"`
+	}{fields: (*fields)(m)}
+	if m.RetryAfter > 0 {
+		out.RetryAfter = m.RetryAfter.String()
+	}
+	return json.Marshal(out)
+}
+
+// write answers a request to service while it is in maintenance.
+func (m *Maintenance) write(w http.ResponseWriter, service string) {
+	if m.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(m.RetryAfter.Seconds())))
+	}
+	if len(m.Body) > 0 {
+		_ = utils.WriteJSON(w, http.StatusServiceUnavailable, m.Body)
+		return
+	}
+	message := m.Message
+	if message == "" {
+		message = fmt.Sprintf("%s is under maintenance", service)
+	}
+	_ = utils.ErrorJSON(w, apperrors.NewServiceUnavailableError(message))
+}
+
+type (
+	// ServiceStatus is what a service's proxy currently knows about it.
+	ServiceStatus struct {
+		Name          string           `json:"name"`
+		LoadBalancing string           `json:"load_balancing"`
+		Timeout       string           `json:"timeout"`
+		Instances     []InstanceStatus `json:"instances"`
+		Breaker       BreakerStatus    `json:"breaker"`
+		Maintenance   *Maintenance     `json:"maintenance,omitempty"`
+		RecentErrors  ErrorCounts      `json:"recent_errors"`
+	}
+
+	// InstanceStatus is the health of one instance as last seen by the health checks.
+	InstanceStatus struct {
+		URL      string `json:"url"`
+		Healthy  bool   `json:"healthy"`
+		InFlight int64  `json:"in_flight"`
+	}
+
+	BreakerStatus struct {
+		State    string     `json:"state"`
+		Failures int        `json:"failures"`
+		OpenedAt *time.Time `json:"opened_at,omitempty"`
+	}
+
+	// ErrorCounts are the requests proxied to a service over Window and those it failed with a 5xx,
+	// by status code.
+	ErrorCounts struct {
+		Window   string         `json:"window"`
+		Requests int            `json:"requests"`
+		Errors   int            `json:"errors"`
+		ByStatus map[string]int `json:"by_status"`
+	}
+)
+
+// Status returns the status of every service, sorted by name.
+func (reg *Registry) Status() []ServiceStatus {
+	reg.mu.RLock()
+	services := make([]*Service, 0, len(reg.services))
+	for _, svc := range reg.services {
+		services = append(services, svc)
+	}
+	reg.mu.RUnlock()
+
+	sort.Slice(services, func(i, j int) bool { return services[i].name < services[j].name })
+	statuses := make([]ServiceStatus, 0, len(services))
+	for _, svc := range services {
+		statuses = append(statuses, svc.Status())
+	}
+	return statuses
+}
+
+// SetMaintenance puts the named service in maintenance, or takes it out when m is nil. It lasts
+// across config reloads until turned off, but only on this replica. It reports false if the
+// service is not configured.
+func (reg *Registry) SetMaintenance(name string, m *Maintenance) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	svc, ok := reg.services[name]
+	if !ok {
+		return false
+	}
+	if m == nil {
+		delete(reg.maintenance, name)
+	} else {
+		reg.maintenance[name] = m
+	}
+	svc.maintenance.Store(m)
+	return true
+}
+
+// Status returns what the proxy currently knows about its service.
+func (proxyService *Service) Status() ServiceStatus {
+	status := ServiceStatus{
+		Name:          proxyService.name,
+		LoadBalancing: proxyService.serviceConfig.LoadBalancing,
+		Timeout:       proxyService.serviceConfig.Timeout.String(),
+		Breaker:       proxyService.breaker.status(),
+		Maintenance:   proxyService.maintenance.Load(),
+		RecentErrors:  proxyService.errors.counts(time.Now()),
+	}
+	for _, inst := range proxyService.balancer.instances {
+		status.Instances = append(status.Instances, InstanceStatus{
+			URL:      inst.target.String(),
+			Healthy:  inst.healthy.Load(),
+			InFlight: inst.inflight.Load(),
+		})
+	}
+	return status
+}
+
+// errorWindow counts a service's responses over the last few minutes, in one bucket per minute.
+type errorWindow struct {
+	mu      sync.Mutex
+	buckets [errorBuckets]errorBucketCounts
+}
+
+type errorBucketCounts struct {
+	minute   int64
+	requests int
+	byStatus map[int]int
+}
+
+func (ew *errorWindow) record(now time.Time, status int) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	minute := now.Unix() / int64(errorBucket.Seconds())
+	bucket := &ew.buckets[minute%errorBuckets]
+	if bucket.minute != minute {
+		*bucket = errorBucketCounts{minute: minute}
+	}
+	bucket.requests++
+	if status >= http.StatusInternalServerError {
+		if bucket.byStatus == nil {
+			bucket.byStatus = make(map[int]int)
+		}
+		bucket.byStatus[status]++
+	}
+}
+
+func (ew *errorWindow) counts(now time.Time) ErrorCounts {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	counts := ErrorCounts{
+		Window:   (errorBucket * errorBuckets).String(),
+		ByStatus: make(map[string]int),
+	}
+	minute := now.Unix() / int64(errorBucket.Seconds())
+	for _, bucket := range ew.buckets {
+		if minute-bucket.minute >= errorBuckets {
+			continue
+		}
+		counts.Requests += bucket.requests
+		for status, n := range bucket.byStatus {
+			counts.Errors += n
+			counts.ByStatus[strconv.Itoa(status)] += n
+		}
+	}
+	return counts
+}
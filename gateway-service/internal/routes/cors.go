@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/idempotency"
+)
+
+// defaultCORS is the policy of routes that reference no "cors:<name>" policy.
+var defaultCORS = cors.Options{
+	AllowedOrigins:   []string{"*"},
+	AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+	AllowedHeaders:   []string{"Authorization", "Content-Type", "X-API-Key", idempotency.HeaderKey},
+	ExposedHeaders:   []string{"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", idempotency.HeaderReplayed, "Deprecation", "Sunset", "Link"},
+	AllowCredentials: true,
+	MaxAge:           300,
+}
+
+func corsOptions(policy config.CORSPolicy) cors.Options {
+	return cors.Options{
+		AllowedOrigins:   policy.AllowedOrigins,
+		AllowedMethods:   policy.AllowedMethods,
+		AllowedHeaders:   policy.AllowedHeaders,
+		ExposedHeaders:   policy.ExposedHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           int(policy.MaxAge.Seconds()),
+	}
+}
+
+// corsMiddleware answers CORS requests with the policy of the route they are for. It runs before
+// routing, as preflight requests are answered here without reaching a route, so the route is found
+// by matching the request, with the method a preflight asks about, against the router.
+func (rm *RouteManager) corsMiddleware(next http.Handler) http.Handler {
+	fallback := cors.Handler(defaultCORS)(next)
+	policies := make(map[string]http.Handler, len(rm.Cfg.CORS.Policies))
+	for name, policy := range rm.Cfg.CORS.Policies {
+		policies[name] = cors.Handler(corsOptions(policy))(next)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			if handler, ok := policies[rm.corsPolicy(r)]; ok {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// corsPolicy returns the name of the CORS policy of the route r is for, or "" for the default.
+func (rm *RouteManager) corsPolicy(r *http.Request) string {
+	if len(rm.corsRoutes) == 0 {
+		return ""
+	}
+	method := r.Method
+	if requested := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && requested != "" {
+		method = requested
+	}
+	rctx := chi.NewRouteContext()
+	if !rm.Router.Match(rctx, method, r.URL.Path) {
+		return ""
+	}
+	return rm.corsRoutes[method+" "+rctx.RoutePattern()]
+}
@@ -3,154 +3,266 @@ package routes
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
+	"github.com/robaa12/gatway-service/internal/access"
+	"github.com/robaa12/gatway-service/internal/cache"
 	"github.com/robaa12/gatway-service/internal/config"
 	store "github.com/robaa12/gatway-service/internal/handlers"
 	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
+	"github.com/robaa12/gatway-service/internal/idempotency"
+	"github.com/robaa12/gatway-service/internal/logging"
+	"github.com/robaa12/gatway-service/internal/metrics"
 	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/internal/middleware/ratelimit"
+	"github.com/robaa12/gatway-service/internal/middleware/realip"
 	"github.com/robaa12/gatway-service/internal/proxy"
 	"github.com/robaa12/gatway-service/internal/service"
+	"github.com/robaa12/gatway-service/internal/storeslug"
+	"github.com/robaa12/gatway-service/internal/tracing"
+	"github.com/robaa12/gatway-service/utils"
 )
 
 type RouteManager struct {
-	Router       *chi.Mux
-	Cfg          *config.Config
-	Auth         *auth.Service
-	StoreHandler *store.StoreHandler
-	UserHandler  *store.UserHandler
+	Router            *chi.Mux
+	Cfg               *config.Config
+	Auth              *auth.Service
+	StoreService      *service.StoreService
+	StoreHandler      *store.StoreHandler
+	UserHandler       *store.UserHandler
+	HealthHandler     *store.HealthHandler
+	StaffHandler      *store.StaffHandler
+	APIKeyHandler     *store.APIKeyHandler
+	AdminHandler      *store.AdminHandler
+	StorefrontHandler *store.StorefrontHandler
+	RateLimiter       *ratelimit.Limiter
+	Idempotency       *idempotency.Guard
+	Cache             *cache.Cache
+	Upstreams         *proxy.Registry
+	// corsRoutes maps "METHOD /path" of the routes with their own CORS policy to its name
+	corsRoutes map[string]string
 }
 
-func NewRouter(cfg *config.Config) *RouteManager {
+func NewRouter(cfg *config.Config, shared *Shared) (*RouteManager, error) {
+	if err := cfg.Validate(MiddlewareNames()); err != nil {
+		return nil, fmt.Errorf("invalid gateway config: %w", err)
+	}
+	// Keys are read on every reload, so rotating them only needs new key files and a SIGHUP
+	keys, err := auth.LoadKeySet(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("loading JWT keys: %w", err)
+	}
+	storeService, storefrontService, jwtService := setupServices(cfg, keys, shared)
+	shared.RateLimiter.Configure(cfg.RateLimit)
 
-	storeService, jwtService := setupServices(cfg)
+	authService := auth.NewAuthService(cfg, jwtService, shared.Auth, storeService.Slugs)
 
 	rm := RouteManager{
-		Router:       chi.NewRouter(),
-		Cfg:          cfg,
-		Auth:         auth.NewAuthService(cfg),
-		StoreHandler: store.NewStoreHandler(storeService, jwtService),
-		UserHandler:  store.NewUserHandler(cfg, jwtService),
+		Router:            chi.NewRouter(),
+		Cfg:               cfg,
+		Auth:              authService,
+		StoreService:      storeService,
+		StoreHandler:      store.NewStoreHandler(storeService, jwtService),
+		UserHandler:       store.NewUserHandler(cfg, jwtService),
+		HealthHandler:     store.NewHealthHandler(cfg),
+		StaffHandler:      store.NewStaffHandler(authService, shared.Auth.Staff),
+		APIKeyHandler:     store.NewAPIKeyHandler(authService, shared.Auth.APIKeys),
+		AdminHandler:      store.NewAdminHandler(storeService, shared.Auth.LoginAudit, shared.Upstreams, describeRoutes(cfg.Routes)),
+		StorefrontHandler: store.NewStorefrontHandler(storefrontService),
+		RateLimiter:       shared.RateLimiter,
+		Idempotency:       idempotency.NewGuard(shared.Idempotency, cfg.Idempotency),
+		Cache:             shared.Cache,
+		Upstreams:         shared.Upstreams,
+		corsRoutes:        make(map[string]string),
+	}
+
+	// Built before the upstreams are synced, so a route with a bad middleware leaves them untouched
+	middlewares, err := rm.buildMiddlewares()
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway config: %w", err)
+	}
+	if err := shared.Upstreams.Sync(cfg.Services); err != nil {
+		return nil, err
 	}
+
 	rm.setupRouter()
 	rm.coreRoutes()
-	rm.registerRoutes()
-	return &rm
+	rm.registerRoutes(middlewares)
+	return &rm, nil
 }
-func setupServices(cfg *config.Config) (*service.StoreService, *auth.JWTService) {
+
+func setupServices(cfg *config.Config, keys *auth.KeySet, shared *Shared) (*service.StoreService, *service.StorefrontService, *auth.JWTService) {
 	client := httpcient.NewClient(cfg.Services["user-service"].URL,
 		cfg.Services["product-service"].URL,
-		cfg.Services["order-service"].URL)
-	storeService := service.NewStoreService(client)
-	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenExp, cfg.Auth.RefreshTokenExp)
-	return storeService, jwtService
+		cfg.Services["order-service"].URL,
+		shared.Signer,
+		cfg.CoreServiceTimeout())
+	slugs := storeslug.NewResolver(client.StoreIDBySlug, cfg.Auth.StoreSlugTTL)
+	storeService := service.NewStoreService(client, shared.Sagas, slugs, shared.Auth, cfg.Saga)
+	storefrontService := service.NewStorefrontService(client, cfg.Storefront)
+	jwtService := auth.NewJWTService(keys, cfg.Auth.AccessTokenExp, cfg.Auth.RefreshTokenExp)
+	return storeService, storefrontService, jwtService
 }
 
 func (rm *RouteManager) setupRouter() {
 	// Middleware
-	rm.Router.Use(middleware.Logger)
-	rm.Router.Use(middleware.Recoverer)
+	rm.Router.Use(tracing.Middleware)
+	rm.Router.Use(metrics.Middleware)
 	rm.Router.Use(middleware.RequestID)
-	rm.Router.Use(middleware.RealIP)
+	rm.Router.Use(utils.ExposeRequestID)
+	// Only the configured proxies may say which client a request is for; the login guard and the
+	// per-IP rate limits key on the address this leaves in RemoteAddr
+	rm.Router.Use(realip.Middleware(rm.Cfg.Server.TrustedProxies))
+	rm.Router.Use(logging.Middleware)
+	rm.Router.Use(middleware.Recoverer)
+	rm.Router.Use(middleware.SetHeader("X-Service-Version", rm.Cfg.Server.Version))
 	rm.Router.Use(middleware.ThrottleBacklog(100, 50, 60000)) // Rate limiting
-	rm.Router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	rm.Router.Use(rm.corsMiddleware)
+	rm.Router.NotFound(utils.NotFound)
+	rm.Router.MethodNotAllowed(utils.MethodNotAllowed)
 }
 
-func (rm *RouteManager) registerRoutes() {
+// registerRoutes proxies the routes of the config, each wrapped in the middlewares built for it.
+func (rm *RouteManager) registerRoutes(middlewares []func(http.Handler) http.Handler) {
 	// API Routes
 	rm.Router.Route("/", func(r chi.Router) {
-		for _, route := range rm.Cfg.Routes {
-			// Skip the store POST route since we're handling it separately
-			if route.Path == "/store" && contains(route.Methods, "POST") {
-				// For store POST, we'll handle it in coreRoutes()
-				// But we still need to register other methods for this path
-				methods := []string{}
-				for _, method := range route.Methods {
-					if method != "POST" {
-						methods = append(methods, method)
-					}
-				}
-
-				if len(methods) > 0 {
-					routeCopy := route
-					routeCopy.Methods = methods
-					handler := rm.createRouteHandler(routeCopy)
-					for _, method := range methods {
-						r.Method(method, route.Path, handler)
-					}
+		for i, route := range rm.Cfg.Routes {
+			// Skip the methods handled in coreRoutes, but still register the others for this path.
+			// Validate made sure a core route is only reached where coreRoutes serves it.
+			methods := []string{}
+			path := route.PublicPath()
+			for _, method := range route.Methods {
+				if _, core := route.CoreRoute(method); !core {
+					methods = append(methods, method)
 				}
-				continue
 			}
-
-			handler := rm.createRouteHandler(route)
-
-			// Ensure methods are provided
-			if len(route.Methods) == 0 {
-				log.Printf("Warning: No methods defined for route %s", route.Path)
+			if len(methods) == 0 {
 				continue
 			}
+			route.Methods = methods
+
+			// Outermost, so responses the route's middlewares reject are marked deprecated too
+			handler := deprecationMiddleware(route)(middlewares[i](rm.createRouteHandler(route)))
 
 			// add methods to router
 			for _, method := range route.Methods {
-				r.Method(method, route.Path, handler)
+				r.Method(method, path, handler)
 			}
 		}
 	})
 }
 
-func (rm *RouteManager) createRouteHandler(route config.RouteConfig) http.Handler {
-	service, exists := rm.Cfg.Services[route.Service]
-	if !exists {
-		log.Printf("Warning: Service not found for route %s", route.Path)
-		return http.NotFoundHandler()
-	}
-
-	// Create base handler
-	handler := proxy.NewProxyService(&service)
-
-	middlewareMap := map[string]func(http.Handler) http.Handler{
-		"auth":            rm.Auth.AuthMiddleware,
-		"store-ownership": rm.Auth.StoreOwnershipMiddleware,
-	}
-
-	middlewareOrder := []string{
-		"auth",
-		"store-ownership",
-	}
-
-	for i := len(middlewareOrder) - 1; i >= 0; i-- {
-		mwName := middlewareOrder[i]
-		if contains(route.Middlewares, mwName) {
-			if mw, exists := middlewareMap[mwName]; exists {
-				handler = mw(handler)
+// describeRoutes lists the routes of the config as registerRoutes serves them.
+func describeRoutes(routes []config.RouteConfig) []store.RouteInfo {
+	infos := make([]store.RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		info := store.RouteInfo{
+			Path:         route.PublicPath(),
+			Service:      route.Service,
+			UpstreamPath: route.UpstreamPath(),
+			Middlewares:  []string{},
+			Split:        route.Split,
+			Mirror:       route.Mirror,
+			Deprecation:  route.Deprecation,
+		}
+		for _, method := range route.Methods {
+			if _, core := route.CoreRoute(method); core {
+				info.GatewayMethods = append(info.GatewayMethods, method)
+			} else {
+				info.Methods = append(info.Methods, method)
+			}
+		}
+		for _, registered := range routeMiddlewares {
+			if param, ok := findMiddleware(route.Middlewares, registered.name); ok {
+				if param != "" {
+					info.Middlewares = append(info.Middlewares, registered.name+":"+param)
+				} else {
+					info.Middlewares = append(info.Middlewares, registered.name)
+				}
 			}
 		}
+		infos = append(infos, info)
 	}
+	return infos
+}
 
-	return handler
+// createRouteHandler returns the proxy of the route's service, or the split over its versions,
+// along with its mirror if it has one and its path rewrite, before its middlewares are added.
+func (rm *RouteManager) createRouteHandler(route config.RouteConfig) http.Handler {
+	// Services are checked by Validate before any route is registered
+	var handler http.Handler = rm.Upstreams.Service(route.Service)
+	if route.Split != nil {
+		handler = rm.Upstreams.NewSplit(route)
+	}
+	if route.Mirror != nil {
+		handler = rm.Upstreams.NewMirror(route, handler)
+	}
+	handler = rewriteMiddleware(route)(handler)
+	// Innermost, so retries are matched to the caller the other middlewares authenticated
+	return rm.Idempotency.Middleware(handler)
 }
 
 func (rm *RouteManager) coreRoutes() {
-	rm.Router.Post("/login", rm.Auth.Login)
-	rm.Router.Post("/register", rm.Auth.Register)
+	authLimit := rm.optionalRateLimit("login")
+	rm.Router.With(authLimit).Post("/login", rm.Auth.Login)
+	rm.Router.With(authLimit).Post("/register", rm.Auth.Register)
 	rm.Router.Get("/", rm.sayHello())
-	rm.Router.Post("/refresh", rm.Auth.RefreshToken)
+	rm.Router.Get("/healthz", rm.HealthHandler.Healthz)
+	rm.Router.Get("/readyz", rm.HealthHandler.Readyz)
+	rm.Router.Handle("/metrics", metrics.Handler(rm.Cfg.Admin.MetricsToken))
+	rm.Router.Get("/.well-known/jwks.json", rm.Auth.JWKS)
+	rm.Router.With(authLimit).Post("/refresh", rm.Auth.RefreshToken)
 
-	// Custom store creation route with auth middleware
-	rm.Router.With(rm.Auth.AuthMiddleware).Post("/store", rm.StoreHandler.CreateStore)
+	// Custom store creation and deletion routes with auth middleware
+	rm.Router.With(rm.Auth.AuthMiddleware, rm.Idempotency.Middleware).Post("/store", rm.StoreHandler.CreateStore)
+	rm.Router.With(rm.Auth.AuthMiddleware, rm.Auth.RequirePermission(access.StoreDelete)).
+		Delete("/store/{store_id}", rm.StoreHandler.DeleteStore)
 
 	// Add User routes
 	rm.Router.With(rm.Auth.AuthMiddleware).Get("/user/me", rm.UserHandler.GetUser)
+	rm.Router.With(rm.Auth.AuthMiddleware).Post("/logout", rm.Auth.Logout)
+	rm.Router.With(rm.Auth.AuthMiddleware).Post("/logout/all", rm.Auth.LogoutAll)
+
+	// Store staff routes
+	rm.Router.With(rm.Auth.AuthMiddleware).Get("/user/me/staff", rm.StaffHandler.ListMyStores)
+	rm.Router.Route("/stores/{store_id}/staff", func(r chi.Router) {
+		r.Use(rm.Auth.AuthMiddleware)
+		r.With(rm.Auth.RequirePermission(access.StaffRead)).Get("/", rm.StaffHandler.ListStaff)
+		r.With(rm.Auth.RequirePermission(access.StaffWrite)).Put("/{user_id}", rm.StaffHandler.PutStaff)
+		r.With(rm.Auth.RequirePermission(access.StaffWrite)).Delete("/{user_id}", rm.StaffHandler.RemoveStaff)
+	})
+
+	// Storefront pages composed from several services
+	rm.Router.Route("/storefront/{store_slug}", func(r chi.Router) {
+		r.Use(rm.optionalRateLimit("storefront"))
+		r.Get("/home", rm.StorefrontHandler.Home)
+		r.Get("/products/{slug}", rm.StorefrontHandler.ProductPage)
+	})
+
+	// Admin routes
+	rm.Router.Route("/admin", func(r chi.Router) {
+		r.Use(auth.AdminMiddleware(rm.Cfg.Admin.Token))
+		r.Get("/sagas", rm.AdminHandler.ListSagas)
+		r.Get("/sagas/{saga_id}", rm.AdminHandler.GetSaga)
+		r.Post("/sagas/{saga_id}/retry", rm.AdminHandler.RetrySaga)
+		r.Get("/login-audit", rm.AdminHandler.ListLoginAudit)
+		r.Get("/routes", rm.AdminHandler.ListRoutes)
+		r.Get("/services", rm.AdminHandler.ListServices)
+		r.Get("/services/{service}", rm.AdminHandler.GetService)
+		r.Put("/services/{service}/maintenance", rm.AdminHandler.StartMaintenance)
+		r.Delete("/services/{service}/maintenance", rm.AdminHandler.StopMaintenance)
+	})
+
+	// Store API key routes
+	rm.Router.Route("/stores/{store_id}/api-keys", func(r chi.Router) {
+		r.Use(rm.Auth.AuthMiddleware)
+		r.With(rm.Auth.RequirePermission(access.APIKeysRead)).Get("/", rm.APIKeyHandler.ListAPIKeys)
+		r.With(rm.Auth.RequirePermission(access.APIKeysWrite)).Post("/", rm.APIKeyHandler.CreateAPIKey)
+		r.With(rm.Auth.RequirePermission(access.APIKeysWrite)).Delete("/{key_id}", rm.APIKeyHandler.RevokeAPIKey)
+	})
 }
 
 func (rm *RouteManager) sayHello() http.HandlerFunc {
@@ -159,11 +271,22 @@ func (rm *RouteManager) sayHello() http.HandlerFunc {
 	}
 }
 
-func contains(slice []string, str string) bool {
-	for _, v := range slice {
-		if v == str {
-			return true
+// optionalRateLimit returns the named rate limit middleware, or a pass-through when the policy is
+// not configured.
+func (rm *RouteManager) optionalRateLimit(policy string) func(http.Handler) http.Handler {
+	if !rm.RateLimiter.HasPolicy(policy) {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return rm.RateLimiter.Middleware(policy)
+}
+
+// findMiddleware looks for name, with or without a ":param" suffix, in a route's middleware list.
+func findMiddleware(middlewares []string, name string) (string, bool) {
+	for _, mw := range middlewares {
+		base, param, _ := strings.Cut(mw, ":")
+		if base == name {
+			return param, true
 		}
 	}
-	return false
+	return "", false
 }
@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	store "github.com/robaa12/gatway-service/internal/handlers"
+	"github.com/robaa12/gatway-service/internal/proxy"
+)
+
+const adminToken = "admin-token"
+
+// adminRequest sends a request to the gateway with token as its bearer token, if given.
+func adminRequest(h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = "203.0.113.7:52000"
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func newAdminRouter(t *testing.T, token string) http.Handler {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(upstream.Close)
+
+	cfg := testConfig(upstream.URL,
+		config.RouteConfig{Path: "/products", Methods: []string{"GET"}, Service: "product-service"},
+		config.RouteConfig{Path: "/categories", Methods: []string{"GET"}, Service: "product-service", Middlewares: []string{"rate-limit:once"}},
+		config.RouteConfig{Path: "/orders/broken", Methods: []string{"GET"}, Service: "order-service"},
+	)
+	cfg.Admin.Token = token
+	reloader, err := NewReloader(cfg, func() (*config.Config, error) { return cfg, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(reloader.Close)
+	return reloader
+}
+
+func TestAdminAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		given      string
+		wantCode   int
+	}{
+		{"disabled", "", adminToken, http.StatusNotFound},
+		{"no token", adminToken, "", http.StatusUnauthorized},
+		{"wrong token", adminToken, "guess", http.StatusUnauthorized},
+		{"right token", adminToken, adminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newAdminRouter(t, tt.configured)
+			if w := adminRequest(h, http.MethodGet, "/admin/routes", tt.given, ""); w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAdminListRoutes(t *testing.T) {
+	h := newAdminRouter(t, adminToken)
+	w := adminRequest(h, http.MethodGet, "/admin/routes", adminToken, "")
+
+	var body struct {
+		Routes []store.RouteInfo `json:"routes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Routes) != 3 {
+		t.Fatalf("listed %d routes, want 3", len(body.Routes))
+	}
+	categories := body.Routes[1]
+	if categories.Path != "/categories" || categories.Service != "product-service" || !slices.Equal(categories.Middlewares, []string{"rate-limit:once"}) {
+		t.Fatalf("route = %+v, want /categories to product-service behind rate-limit:once", categories)
+	}
+}
+
+func TestAdminServices(t *testing.T) {
+	h := newAdminRouter(t, adminToken)
+	if code := get(h, "/orders/broken"); code != http.StatusInternalServerError {
+		t.Fatalf("GET /orders/broken = %d, want 500", code)
+	}
+
+	w := adminRequest(h, http.MethodGet, "/admin/services", adminToken, "")
+	var body struct {
+		Services []proxy.ServiceStatus `json:"services"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(body.Services))
+	for _, svc := range body.Services {
+		names = append(names, svc.Name)
+	}
+	if want := []string{"order-service", "product-service", "user-service"}; !slices.Equal(names, want) {
+		t.Fatalf("services = %v, want %v", names, want)
+	}
+
+	w = adminRequest(h, http.MethodGet, "/admin/services/order-service", adminToken, "")
+	var orders proxy.ServiceStatus
+	if err := json.NewDecoder(w.Body).Decode(&orders); err != nil {
+		t.Fatal(err)
+	}
+	if orders.RecentErrors.Errors != 1 || orders.RecentErrors.ByStatus["500"] != 1 {
+		t.Fatalf("recent errors = %+v, want the one 500", orders.RecentErrors)
+	}
+	if len(orders.Instances) != 1 || !orders.Instances[0].Healthy {
+		t.Fatalf("instances = %+v, want one healthy instance", orders.Instances)
+	}
+
+	if w := adminRequest(h, http.MethodGet, "/admin/services/missing-service", adminToken, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET an unknown service = %d, want 404", w.Code)
+	}
+}
+
+func TestAdminMaintenance(t *testing.T) {
+	h := newAdminRouter(t, adminToken)
+
+	w := adminRequest(h, http.MethodPut, "/admin/services/product-service/maintenance", adminToken,
+		`{"body":{"message":"back soon"},"retry_after":"2m"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT maintenance = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	w = adminRequest(h, http.MethodGet, "/products", "", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /products in maintenance = %d, want 503", w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != `{"message":"back soon"}` {
+		t.Fatalf("body = %s, want the configured maintenance body", got)
+	}
+	if got := w.Header().Get("Retry-After"); got != "120" {
+		t.Fatalf("Retry-After = %q, want 120", got)
+	}
+
+	if w := adminRequest(h, http.MethodDelete, "/admin/services/product-service/maintenance", adminToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE maintenance = %d, want 200", w.Code)
+	}
+	if code := get(h, "/products"); code != http.StatusOK {
+		t.Fatalf("GET /products after maintenance = %d, want 200", code)
+	}
+
+	invalid := []struct{ path, body string }{
+		{"/admin/services/missing-service/maintenance", `{}`},
+		{"/admin/services/product-service/maintenance", `{"retry_after":"soon"}`},
+	}
+	for _, req := range invalid {
+		if w := adminRequest(h, http.MethodPut, req.path, adminToken, req.body); w.Code < http.StatusBadRequest {
+			t.Fatalf("PUT %s %s = %d, want it rejected", req.path, req.body, w.Code)
+		}
+	}
+}
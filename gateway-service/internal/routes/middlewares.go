@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/utils"
+)
+
+// MiddlewareFactory builds the middleware a route references as "name" or "name:param" in
+// routes.json; param is "" when the route gives none. Policies and permissions named by param are
+// checked by Config.Validate, the factory checks the rest. An error rejects the config, so a route
+// is never served without a middleware it asked for.
+type MiddlewareFactory func(rm *RouteManager, route config.RouteConfig, param string) (func(http.Handler) http.Handler, error)
+
+type registeredMiddleware struct {
+	name    string
+	factory MiddlewareFactory
+}
+
+// routeMiddlewares are the middlewares routes may reference, in the order they wrap a route's
+// proxy, outermost first, whatever order the route lists them in.
+var routeMiddlewares = []registeredMiddleware{
+	{"logging", passThrough},
+	{"cors", corsMiddleware},
+	{"max-body", maxBodyMiddleware},
+	{"timeout", timeoutMiddleware},
+	{"auth", authMiddleware},
+	{"rate-limit", rateLimitMiddleware},
+	{"store-ownership", storeOwnershipMiddleware},
+	{"permission", permissionMiddleware},
+	{"cache", cacheMiddleware},
+}
+
+// RegisterMiddleware lets routes reference a middleware by name. Middlewares registered this way
+// wrap a route inside the built-in ones, in the order they were registered. It must be called
+// before the first router is built, and panics if the name is taken.
+func RegisterMiddleware(name string, factory MiddlewareFactory) {
+	if _, exists := lookupMiddleware(name); exists {
+		panic(fmt.Sprintf("routes: middleware %q registered twice", name))
+	}
+	routeMiddlewares = append(routeMiddlewares, registeredMiddleware{name: name, factory: factory})
+}
+
+// MiddlewareNames lists every middleware name a route may reference.
+func MiddlewareNames() []string {
+	names := make([]string, 0, len(routeMiddlewares))
+	for _, mw := range routeMiddlewares {
+		names = append(names, mw.name)
+	}
+	return names
+}
+
+func lookupMiddleware(name string) (MiddlewareFactory, bool) {
+	for _, mw := range routeMiddlewares {
+		if mw.name == name {
+			return mw.factory, true
+		}
+	}
+	return nil, false
+}
+
+// buildMiddlewares builds the middlewares of every route in the config, reporting every problem
+// found rather than stopping at the first one.
+func (rm *RouteManager) buildMiddlewares() ([]func(http.Handler) http.Handler, error) {
+	middlewares := make([]func(http.Handler) http.Handler, len(rm.Cfg.Routes))
+	var errs []error
+	for i, route := range rm.Cfg.Routes {
+		mw, routeErrs := rm.routeMiddleware(route)
+		for _, err := range routeErrs {
+			errs = append(errs, fmt.Errorf("route #%d (%s): %w", i+1, route.PublicPath(), err))
+		}
+		middlewares[i] = mw
+	}
+	return middlewares, errors.Join(errs...)
+}
+
+// routeMiddleware builds the middlewares route references into one, reporting every invalid
+// parameter. Unknown names have already been rejected by Config.Validate.
+func (rm *RouteManager) routeMiddleware(route config.RouteConfig) (func(http.Handler) http.Handler, []error) {
+	var errs []error
+	var chain []func(http.Handler) http.Handler
+	for _, registered := range routeMiddlewares {
+		param, ok := findMiddleware(route.Middlewares, registered.name)
+		if !ok {
+			continue
+		}
+		mw, err := registered.factory(rm, route, param)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("middleware %q: %w", registered.name, err))
+			continue
+		}
+		chain = append(chain, mw)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return func(handler http.Handler) http.Handler {
+		for i := len(chain) - 1; i >= 0; i-- {
+			handler = chain[i](handler)
+		}
+		return handler
+	}, nil
+}
+
+// passThrough is used for "logging", which setupRouter already applies to every request.
+func passThrough(*RouteManager, config.RouteConfig, string) (func(http.Handler) http.Handler, error) {
+	return func(next http.Handler) http.Handler { return next }, nil
+}
+
+// corsMiddleware records the route's CORS policy. Preflight requests never reach the route, so the
+// policy is applied by the router's CORS middleware rather than here.
+func corsMiddleware(rm *RouteManager, route config.RouteConfig, policy string) (func(http.Handler) http.Handler, error) {
+	for _, method := range route.Methods {
+		rm.corsRoutes[method+" "+route.PublicPath()] = policy
+	}
+	return func(next http.Handler) http.Handler { return next }, nil
+}
+
+// maxBodyMiddleware rejects request bodies larger than param, e.g. "512KB" or "10MB", with a 413
+// before they reach the service.
+func maxBodyMiddleware(_ *RouteManager, _ config.RouteConfig, param string) (func(http.Handler) http.Handler, error) {
+	limit, err := config.ParseSize(param)
+	if err != nil {
+		return nil, err
+	}
+	tooLarge := apperrors.NewRequestEntityTooLargeError(fmt.Sprintf("request body is larger than %s", param))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				_ = utils.ErrorJSON(w, tooLarge)
+				return
+			}
+			// A body of unknown length is read first, so the service never gets half of one
+			if r.ContentLength < 0 {
+				body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+				if err != nil {
+					_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("could not read request body"))
+					return
+				}
+				if int64(len(body)) > limit {
+					_ = utils.ErrorJSON(w, tooLarge)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// timeoutMiddleware bounds the whole request, checks included, by the duration in param. It can
+// only shorten the service's own timeout.
+func timeoutMiddleware(_ *RouteManager, _ config.RouteConfig, param string) (func(http.Handler) http.Handler, error) {
+	timeout, err := parsePositiveDuration(param)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+func authMiddleware(rm *RouteManager, route config.RouteConfig, _ string) (func(http.Handler) http.Handler, error) {
+	// Store API keys carry no user, so they are only accepted where a permission check follows
+	if _, ok := findMiddleware(route.Middlewares, "permission"); ok {
+		return rm.Auth.AuthOrAPIKeyMiddleware, nil
+	}
+	return rm.Auth.AuthMiddleware, nil
+}
+
+func rateLimitMiddleware(rm *RouteManager, _ config.RouteConfig, policy string) (func(http.Handler) http.Handler, error) {
+	return rm.RateLimiter.Middleware(policy), nil
+}
+
+func storeOwnershipMiddleware(rm *RouteManager, _ config.RouteConfig, _ string) (func(http.Handler) http.Handler, error) {
+	return rm.Auth.StoreOwnershipMiddleware, nil
+}
+
+func permissionMiddleware(rm *RouteManager, _ config.RouteConfig, permission string) (func(http.Handler) http.Handler, error) {
+	return rm.Auth.RequirePermission(permission), nil
+}
+
+// cacheMiddleware keeps the route's GET responses for the duration in param.
+func cacheMiddleware(rm *RouteManager, _ config.RouteConfig, param string) (func(http.Handler) http.Handler, error) {
+	ttl, err := parsePositiveDuration(param)
+	if err != nil {
+		return nil, err
+	}
+	return rm.Cache.Middleware(ttl), nil
+}
+
+func parsePositiveDuration(param string) (time.Duration, error) {
+	d, err := time.ParseDuration(param)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", param)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", param)
+	}
+	return d, nil
+}
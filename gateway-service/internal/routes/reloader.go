@@ -0,0 +1,182 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/cache"
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/idempotency"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/internal/middleware/ratelimit"
+	"github.com/robaa12/gatway-service/internal/proxy"
+	"github.com/robaa12/gatway-service/internal/saga"
+)
+
+// Shared holds the state that outlives a config reload: upstream proxies keep their health and
+// breaker state, revoked tokens stay revoked, staff memberships are kept, sagas are recorded in
+// one log, idempotent responses keep being replayed, rate limits keep what callers have spent and
+// cached responses are still served.
+type Shared struct {
+	Upstreams   *proxy.Registry
+	Auth        *auth.Stores
+	Signer      *identity.Signer
+	Sagas       saga.Log
+	Idempotency idempotency.Store
+	RateLimiter *ratelimit.Limiter
+	Cache       *cache.Cache
+}
+
+// Reloader serves requests through the current RouteManager and swaps in a freshly built one when
+// the config file changes. Requests already running keep the router they started on, so a reload
+// never drops in-flight traffic.
+type Reloader struct {
+	load    func() (*config.Config, error)
+	shared  *Shared
+	current atomic.Pointer[RouteManager]
+	mu      sync.Mutex
+}
+
+// NewReloader builds the initial router from cfg. load is called on every reload to read the
+// config again.
+func NewReloader(cfg *config.Config, load func() (*config.Config, error)) (*Reloader, error) {
+	stores, err := auth.OpenStores(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	sagas, err := saga.OpenLog(cfg.Saga)
+	if err != nil {
+		_ = stores.Close()
+		return nil, err
+	}
+	responses, err := idempotency.OpenStore(cfg.Idempotency)
+	if err != nil {
+		_ = stores.Close()
+		_ = sagas.Close()
+		return nil, err
+	}
+	signer := identity.NewSigner(cfg.Auth.InternalSecret)
+	shared := &Shared{
+		Upstreams:   proxy.NewRegistry(signer),
+		Auth:        stores,
+		Signer:      signer,
+		Sagas:       sagas,
+		Idempotency: responses,
+		RateLimiter: ratelimit.NewLimiter(cfg.RateLimit),
+		Cache:       cache.New(),
+	}
+	rm, err := NewRouter(cfg, shared)
+	if err != nil {
+		shared.Upstreams.Close()
+		_ = stores.Close()
+		_ = sagas.Close()
+		_ = responses.Close()
+		return nil, err
+	}
+
+	reloader := &Reloader{
+		load:   load,
+		shared: shared,
+	}
+	reloader.current.Store(rm)
+	return reloader, nil
+}
+
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.current.Load().Router.ServeHTTP(w, req)
+}
+
+// Current returns the route manager currently serving requests.
+func (r *Reloader) Current() *RouteManager {
+	return r.current.Load()
+}
+
+// Reload re-reads and validates the config and swaps the router. On error the running router is
+// kept untouched.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+	rm, err := NewRouter(cfg, r.shared)
+	if err != nil {
+		return err
+	}
+
+	r.current.Store(rm)
+	slog.Info("Gateway config reloaded", "routes", len(cfg.Routes), "services", len(cfg.Services))
+	return nil
+}
+
+// Close stops the background work owned by the upstream proxies and closes the auth, saga and
+// idempotency stores.
+func (r *Reloader) Close() {
+	r.shared.Upstreams.Close()
+	if err := r.shared.Auth.Close(); err != nil {
+		slog.Error("Failed to close auth store", "error", err)
+	}
+	if err := r.shared.Sagas.Close(); err != nil {
+		slog.Error("Failed to close saga store", "error", err)
+	}
+	if err := r.shared.Idempotency.Close(); err != nil {
+		slog.Error("Failed to close idempotency store", "error", err)
+	}
+}
+
+// ResumeSagas picks up unfinished sagas once at startup and then every interval, using the current
+// router's services. It returns when ctx is cancelled.
+func (r *Reloader) ResumeSagas(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Current().StoreService.ResumeSagas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Watch polls the config file and reloads whenever its modification time or size changes. It
+// returns when ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, path string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				slog.Error("Error checking gateway config", "path", path, "error", err)
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+
+			if err := r.Reload(); err != nil {
+				slog.Error("Gateway config reload failed, keeping previous routes", "error", err)
+			}
+		}
+	}
+}
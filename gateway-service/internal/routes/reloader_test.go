@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+)
+
+// testConfig is a valid gateway config sending every service to upstream.
+func testConfig(upstream string, routes ...config.RouteConfig) *config.Config {
+	service := config.ServiceConfig{
+		URL:           upstream,
+		Instances:     []string{upstream},
+		LoadBalancing: config.LoadBalancingRoundRobin,
+		Timeout:       5 * time.Second,
+		Breaker:       config.BreakerConfig{Failures: 5, OpenFor: time.Minute},
+	}
+	return &config.Config{
+		Server: config.ServerConfig{Environment: config.EnvironmentDevelopment},
+		Services: map[string]config.ServiceConfig{
+			"user-service":    service,
+			"product-service": service,
+			"order-service":   service,
+		},
+		Routes: routes,
+		Auth: config.AuthConfig{
+			Algorithm:      config.JWTAlgorithmHS256,
+			JWTSecret:      config.DefaultJWTSecret,
+			InternalSecret: config.DefaultInternalSecret,
+			Store:          config.AuthStoreMemory,
+			StoreSlugTTL:   time.Minute,
+		},
+		RateLimit: config.RateLimitConfig{
+			MaxRequests: 100,
+			Duration:    time.Minute,
+			Policies:    map[string]config.RateLimitPolicy{"once": {Requests: 1, Per: time.Hour, Burst: 1, Key: "ip"}},
+		},
+		Saga:        config.SagaConfig{Store: config.AuthStoreMemory, RetryInterval: time.Minute, MaxAttempts: 3, StaleAfter: time.Minute},
+		Idempotency: config.IdempotencyConfig{Store: config.AuthStoreMemory, TTL: time.Hour, Wait: time.Second},
+		Login:       config.LoginConfig{MaxAttempts: 5, AccountMaxAttempts: 20, IPMaxAttempts: 20, Backoff: time.Second, Lockout: time.Minute, FailureWindow: time.Hour, AuditRetention: time.Hour},
+		Storefront:  config.StorefrontConfig{SectionTimeout: time.Second, HomeProducts: 12},
+	}
+}
+
+func get(h http.Handler, path string) int {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = "203.0.113.7:52000"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestReload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	products := config.RouteConfig{Path: "/products", Methods: []string{"GET"}, Service: "product-service"}
+	orders := config.RouteConfig{Path: "/orders", Methods: []string{"GET"}, Service: "order-service"}
+	next := testConfig(upstream.URL, products)
+	var loadErr error
+	reloader, err := NewReloader(next, func() (*config.Config, error) { return next, loadErr })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloader.Close()
+	first := reloader.Current()
+
+	if code := get(reloader, "/orders"); code != http.StatusNotFound {
+		t.Fatalf("GET /orders before the reload = %d, want 404", code)
+	}
+
+	next = testConfig(upstream.URL, products, orders)
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(reloader, "/orders"); code != http.StatusOK {
+		t.Fatalf("GET /orders after the reload = %d, want 200", code)
+	}
+	if reloader.Current() == first {
+		t.Fatal("Reload kept the old router")
+	}
+
+	// A config that does not load or validate leaves the running routes alone
+	running := reloader.Current()
+	loadErr = errors.New("bad file")
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Reload accepted a config that failed to load")
+	}
+	loadErr = nil
+	next = testConfig(upstream.URL, config.RouteConfig{Path: "/products", Methods: []string{"GET"}, Service: "missing-service"})
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Reload accepted a route to an unknown service")
+	}
+	if reloader.Current() != running || get(reloader, "/orders") != http.StatusOK {
+		t.Fatal("a failed reload replaced the running routes")
+	}
+}
+
+func TestReloadKeepsSharedState(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	limited := config.RouteConfig{Path: "/products", Methods: []string{"GET"}, Service: "product-service", Middlewares: []string{"rate-limit:once"}}
+	cfg := testConfig(upstream.URL, limited)
+	reloader, err := NewReloader(cfg, func() (*config.Config, error) { return testConfig(upstream.URL, limited), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloader.Close()
+	before := reloader.Current()
+
+	if code := get(reloader, "/products"); code != http.StatusOK {
+		t.Fatalf("first GET /products = %d, want 200", code)
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	after := reloader.Current()
+
+	if after.RateLimiter != before.RateLimiter || after.Cache != before.Cache || after.Upstreams != before.Upstreams {
+		t.Fatal("Reload rebuilt state that is meant to outlive it")
+	}
+	// The caller already spent the policy's one request, reload or not
+	if code := get(reloader, "/products"); code != http.StatusTooManyRequests {
+		t.Fatalf("GET /products after the reload = %d, want 429", code)
+	}
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/robaa12/gatway-service/internal/config"
+	"github.com/robaa12/gatway-service/internal/metrics"
+)
+
+// rewriteMiddleware sends the route's requests to its service on the path the service expects:
+// the route's rewrite filled in with the request's parameters, or the request's path without the
+// route's prefix. Routes with neither are sent their path unchanged.
+func rewriteMiddleware(route config.RouteConfig) func(http.Handler) http.Handler {
+	if route.Prefix == "" && route.Rewrite == "" {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	params := config.PathParams(route.Rewrite)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, route.Prefix)
+			if route.Rewrite != "" {
+				replacements := make([]string, 0, 2*len(params))
+				for _, param := range params {
+					token := "{" + param + "}"
+					if param == "*" {
+						token = "*"
+					}
+					replacements = append(replacements, token, chi.URLParam(r, param))
+				}
+				path = strings.NewReplacer(replacements...).Replace(route.Rewrite)
+			}
+
+			// The route's other middlewares have already matched the public path, only the
+			// service sees this one
+			r.URL.Path = path
+			r.URL.RawPath = ""
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deprecationMiddleware announces on every response of a deprecated route that it is deprecated,
+// when it goes away and what replaces it. The config has been validated, so its dates parse.
+func deprecationMiddleware(route config.RouteConfig) func(http.Handler) http.Handler {
+	deprecation := route.Deprecation
+	if deprecation == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	since, _ := config.ParseDate(deprecation.Since)
+	header := http.Header{}
+	header.Set("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+	if deprecation.Sunset != "" {
+		sunset, _ := config.ParseDate(deprecation.Sunset)
+		header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		header.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", deprecation.Link))
+	}
+	if deprecation.Successor != "" {
+		header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", deprecation.Successor))
+	}
+	publicPath := route.PublicPath()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, values := range header {
+				w.Header()[name] = append(w.Header()[name], values...)
+			}
+			metrics.ObserveDeprecated(publicPath)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
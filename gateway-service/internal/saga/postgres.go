@@ -0,0 +1,148 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type sagaRecord struct {
+	ID        string `gorm:"primaryKey"`
+	Kind      string `gorm:"not null"`
+	Status    string `gorm:"not null;index"`
+	UserID    int    `gorm:"not null"`
+	Payload   string `gorm:"type:text;not null"`
+	Steps     string `gorm:"type:text;not null"`
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (sagaRecord) TableName() string {
+	return "sagas"
+}
+
+func toRecord(s *Saga) (*sagaRecord, error) {
+	steps, err := json.Marshal(s.Steps)
+	if err != nil {
+		return nil, err
+	}
+	return &sagaRecord{
+		ID:        s.ID,
+		Kind:      s.Kind,
+		Status:    s.Status,
+		UserID:    s.UserID,
+		Payload:   string(s.Payload),
+		Steps:     string(steps),
+		LastError: s.LastError,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}, nil
+}
+
+func (r sagaRecord) toSaga() (Saga, error) {
+	s := Saga{
+		ID:        r.ID,
+		Kind:      r.Kind,
+		Status:    r.Status,
+		UserID:    r.UserID,
+		Payload:   json.RawMessage(r.Payload),
+		LastError: r.LastError,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	err := json.Unmarshal([]byte(r.Steps), &s.Steps)
+	return s, err
+}
+
+// PostgresLog keeps sagas in the gateway database, so they survive restarts.
+type PostgresLog struct {
+	db *gorm.DB
+}
+
+func NewPostgresLog(db *gorm.DB) *PostgresLog {
+	return &PostgresLog{db: db}
+}
+
+func (p *PostgresLog) Create(ctx context.Context, s *Saga) error {
+	record, err := toRecord(s)
+	if err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Create(record).Error
+}
+
+func (p *PostgresLog) Save(ctx context.Context, s *Saga) error {
+	s.UpdatedAt = time.Now()
+	record, err := toRecord(s)
+	if err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Save(record).Error
+}
+
+func (p *PostgresLog) Get(ctx context.Context, id string) (*Saga, error) {
+	var records []sagaRecord
+	if err := p.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	s, err := records[0].toSaga()
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (p *PostgresLog) List(ctx context.Context, statuses ...string) ([]Saga, error) {
+	query := p.db.WithContext(ctx).Order("created_at")
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	var records []sagaRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	sagas := make([]Saga, 0, len(records))
+	for _, record := range records {
+		s, err := record.toSaga()
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, s)
+	}
+	return sagas, nil
+}
+
+// Claim checks and bumps UpdatedAt in one statement, so two replicas cannot both claim a saga.
+func (p *PostgresLog) Claim(ctx context.Context, id string, staleBefore time.Time) (*Saga, error) {
+	var records []sagaRecord
+	err := p.db.WithContext(ctx).Raw(
+		"UPDATE sagas SET updated_at = ? WHERE id = ? AND status IN ? AND updated_at < ? RETURNING *",
+		time.Now(), id, unfinished, staleBefore,
+	).Scan(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	s, err := records[0].toSaga()
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (p *PostgresLog) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
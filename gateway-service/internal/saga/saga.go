@@ -0,0 +1,257 @@
+// Package saga records multi-service operations step by step, so an operation interrupted by a
+// failure or a gateway restart can be finished or rolled back later instead of being left half done.
+package saga
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Saga statuses. A saga is running while its steps are being applied, and ends completed,
+// compensated once every applied step has been undone, or failed when that could not be done and
+// an operator has to look at it.
+const (
+	StatusRunning      = "running"
+	StatusCompensating = "compensating"
+	StatusCompleted    = "completed"
+	StatusCompensated  = "compensated"
+	StatusFailed       = "failed"
+)
+
+// unfinished are the statuses of sagas that still have work left for whoever resumes them.
+var unfinished = []string{StatusRunning, StatusCompensating}
+
+// Step statuses.
+const (
+	StepPending            = "pending"
+	StepDone               = "done"
+	StepFailed             = "failed"
+	StepCompensated        = "compensated"
+	StepCompensationFailed = "compensation_failed"
+)
+
+type (
+	Saga struct {
+		ID        string          `json:"id"`
+		Kind      string          `json:"kind"`
+		Status    string          `json:"status"`
+		UserID    int             `json:"user_id"`
+		Payload   json.RawMessage `json:"payload"`
+		Steps     []Step          `json:"steps"`
+		LastError string          `json:"last_error,omitempty"`
+		CreatedAt time.Time       `json:"created_at"`
+		UpdatedAt time.Time       `json:"updated_at"`
+	}
+
+	Step struct {
+		Name string `json:"name"`
+		// Status of the step; Attempts counts compensation attempts once it is being undone
+		Status    string    `json:"status"`
+		Attempts  int       `json:"attempts"`
+		LastError string    `json:"last_error,omitempty"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+)
+
+// NewID returns a random saga id.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// New starts a running saga with the given steps, all pending.
+func New(id, kind string, userID int, payload any, steps ...string) (*Saga, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding saga payload: %w", err)
+	}
+	now := time.Now()
+	s := &Saga{
+		ID:        id,
+		Kind:      kind,
+		Status:    StatusRunning,
+		UserID:    userID,
+		Payload:   data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, name := range steps {
+		s.Steps = append(s.Steps, Step{Name: name, Status: StepPending, UpdatedAt: now})
+	}
+	return s, nil
+}
+
+// Step returns the named step, or nil.
+func (s *Saga) Step(name string) *Step {
+	for i := range s.Steps {
+		if s.Steps[i].Name == name {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+// SetStep records a step's new status and error.
+func (s *Saga) SetStep(name, status string, err error) {
+	step := s.Step(name)
+	if step == nil {
+		return
+	}
+	step.Status = status
+	step.LastError = ""
+	if err != nil {
+		step.LastError = err.Error()
+	}
+	step.UpdatedAt = time.Now()
+}
+
+// Decode reads the saga's payload into v.
+func (s *Saga) Decode(v any) error {
+	return json.Unmarshal(s.Payload, v)
+}
+
+// Encode replaces the saga's payload with v.
+func (s *Saga) Encode(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.Payload = data
+	return nil
+}
+
+// Log persists sagas.
+type Log interface {
+	Create(ctx context.Context, s *Saga) error
+	// Save writes the saga's current state and bumps UpdatedAt.
+	Save(ctx context.Context, s *Saga) error
+	// Get returns the saga, or nil if there is none with that id.
+	Get(ctx context.Context, id string) (*Saga, error)
+	// List returns sagas with any of the statuses, or all sagas when none are given, oldest first.
+	List(ctx context.Context, statuses ...string) ([]Saga, error)
+	// Claim takes over an unfinished saga nobody has saved since staleBefore by bumping UpdatedAt,
+	// and returns it as it is now. It returns nil if the saga was finished or saved in the meantime,
+	// e.g. claimed by another gateway replica, so only one of them resumes it.
+	Claim(ctx context.Context, id string, staleBefore time.Time) (*Saga, error)
+	Close() error
+}
+
+// OpenLog opens the saga log selected by cfg.Store.
+func OpenLog(cfg config.SagaConfig) (Log, error) {
+	switch cfg.Store {
+	case config.AuthStoreMemory:
+		return NewMemoryLog(), nil
+	case config.AuthStorePostgres:
+	default:
+		return nil, fmt.Errorf("unknown saga store %q", cfg.Store)
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, errors.New("postgres saga store needs a database url")
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to saga store: %w", err)
+	}
+	if err := db.AutoMigrate(&sagaRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to run saga store migration: %w", err)
+	}
+	return NewPostgresLog(db), nil
+}
+
+// MemoryLog keeps sagas in process memory, so it cannot resume them after a restart.
+type MemoryLog struct {
+	mu    sync.RWMutex
+	sagas map[string]Saga
+}
+
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{sagas: make(map[string]Saga)}
+}
+
+func (m *MemoryLog) Create(_ context.Context, s *Saga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sagas[s.ID]; exists {
+		return fmt.Errorf("saga %s already exists", s.ID)
+	}
+	m.sagas[s.ID] = clone(s)
+	return nil
+}
+
+func (m *MemoryLog) Save(_ context.Context, s *Saga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.UpdatedAt = time.Now()
+	m.sagas[s.ID] = clone(s)
+	return nil
+}
+
+func (m *MemoryLog) Get(_ context.Context, id string) (*Saga, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.sagas[id]
+	if !ok {
+		return nil, nil
+	}
+	found := clone(&s)
+	return &found, nil
+}
+
+func (m *MemoryLog) List(_ context.Context, statuses ...string) ([]Saga, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sagas := []Saga{}
+	for _, s := range m.sagas {
+		if len(statuses) == 0 || slices.Contains(statuses, s.Status) {
+			sagas = append(sagas, clone(&s))
+		}
+	}
+	sort.Slice(sagas, func(i, j int) bool { return sagas[i].CreatedAt.Before(sagas[j].CreatedAt) })
+	return sagas, nil
+}
+
+func (m *MemoryLog) Claim(_ context.Context, id string, staleBefore time.Time) (*Saga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sagas[id]
+	if !ok || !slices.Contains(unfinished, s.Status) || !s.UpdatedAt.Before(staleBefore) {
+		return nil, nil
+	}
+	s.UpdatedAt = time.Now()
+	m.sagas[id] = s
+	claimed := clone(&s)
+	return &claimed, nil
+}
+
+func (m *MemoryLog) Close() error {
+	return nil
+}
+
+// clone copies a saga so callers never share its steps with the log.
+func clone(s *Saga) Saga {
+	c := *s
+	c.Steps = slices.Clone(s.Steps)
+	c.Payload = slices.Clone(s.Payload)
+	return c
+}
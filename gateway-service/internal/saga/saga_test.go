@@ -0,0 +1,53 @@
+package saga
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLogClaim(t *testing.T) {
+	ctx := context.Background()
+	staleBefore := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		status    string
+		updatedAt time.Time
+		want      bool
+	}{
+		{name: "stale running saga", status: StatusRunning, updatedAt: staleBefore.Add(-time.Second), want: true},
+		{name: "stale compensation", status: StatusCompensating, updatedAt: staleBefore.Add(-time.Second), want: true},
+		{name: "saved since", status: StatusRunning, updatedAt: time.Now()},
+		{name: "finished", status: StatusCompleted, updatedAt: staleBefore.Add(-time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewMemoryLog()
+			s, err := New("saga-1", "test", 1, nil, "step")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Status = tt.status
+			s.UpdatedAt = tt.updatedAt
+			if err := log.Create(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			claimed, err := log.Claim(ctx, "saga-1", staleBefore)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (claimed != nil) != tt.want {
+				t.Fatalf("Claim = %v, want claimed %t", claimed, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			// The claim counts as a save, so nobody else can claim the saga until it goes stale again
+			if again, _ := log.Claim(ctx, "saga-1", staleBefore); again != nil {
+				t.Fatal("saga was claimed twice")
+			}
+		})
+	}
+}
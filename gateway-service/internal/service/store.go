@@ -1,63 +1,301 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
+	"github.com/robaa12/gatway-service/internal/config"
 	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/middleware/auth"
+	"github.com/robaa12/gatway-service/internal/saga"
+	"github.com/robaa12/gatway-service/internal/storeslug"
 
 	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
 	"github.com/robaa12/gatway-service/internal/model"
 )
 
+// SagaCreateStore is the saga kind recorded for each store creation.
+const SagaCreateStore = "create_store"
+
 type StoreService struct {
-	Client *httpcient.Client
+	Client  *httpcient.Client
+	Sagas   saga.Log
+	Slugs   *storeslug.Resolver
+	access  *auth.Stores
+	sagaCfg config.SagaConfig
+}
+
+// createStorePayload is what a store creation saga needs to be resumed: the original request and,
+// once user-service has created it, the store.
+type createStorePayload struct {
+	Request model.StoreRequest `json:"request"`
+	Store   *model.Store       `json:"store,omitempty"`
 }
 
-func NewStoreService(client *httpcient.Client) *StoreService {
+func NewStoreService(client *httpcient.Client, sagas saga.Log, slugs *storeslug.Resolver, access *auth.Stores, sagaCfg config.SagaConfig) *StoreService {
 	return &StoreService{
-		Client: client,
+		Client:  client,
+		Sagas:   sagas,
+		Slugs:   slugs,
+		access:  access,
+		sagaCfg: sagaCfg,
 	}
 }
 
-func (s *StoreService) CreateStore(storeRequest *model.StoreRequest) (*model.Store, error) {
+// CreateStore creates the store in every service as a saga. Each step is logged before the next
+// one starts, so if any service fails, or the gateway stops half way, the steps already done can be
+// undone later by ResumeSagas.
+func (s *StoreService) CreateStore(ctx context.Context, storeRequest *model.StoreRequest) (*model.Store, error) {
+	sg, err := saga.New(saga.NewID(), SagaCreateStore, int(storeRequest.UserID),
+		createStorePayload{Request: *storeRequest},
+		httpcient.ServiceUser, httpcient.ServiceProduct, httpcient.ServiceOrder)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Sagas.Create(ctx, sg); err != nil {
+		slog.ErrorContext(ctx, "Failed to record store creation saga", "error", err)
+		return nil, apperrors.NewServiceUnavailableError("store creation is unavailable, try again later")
+	}
 
 	// Step 1: Create store in user service
-	storeUserResponse, err := s.Client.CreateStoreInUserService(storeRequest)
-
+	storeUserResponse, err := s.Client.CreateStoreInUserService(ctx, storeRequest)
 	if err != nil {
-
+		sg.SetStep(httpcient.ServiceUser, saga.StepFailed, err)
+		if !errors.Is(err, httpcient.ErrRejected) {
+			// user-service may have created the store before failing or timing out, like in abandon
+			sg.Status = saga.StatusFailed
+			sg.LastError = fmt.Sprintf("user-service did not confirm the store creation; the store may exist there: %v", err)
+			slog.ErrorContext(ctx, "Saga failed", "saga_id", sg.ID, "reason", sg.LastError)
+			s.save(ctx, sg)
+			return nil, apperrors.NewBadGatewayError("store creation could not be confirmed, check your stores before trying again")
+		}
+		// user-service refused the store, so nothing was created and there is nothing to undo
+		sg.Status = saga.StatusCompensated
+		sg.LastError = err.Error()
+		s.save(ctx, sg)
 		return nil, errors.New(err.Error())
 	}
 
 	// Parse the user service response
 	store := storeUserResponse.GetStore()
+	sg.SetStep(httpcient.ServiceUser, saga.StepDone, nil)
+	// The slug may be cached as unknown from before the store existed
+	s.Slugs.Forget(store.Slug)
+	if err := sg.Encode(createStorePayload{Request: *storeRequest, Store: &store}); err != nil {
+		slog.ErrorContext(ctx, "Failed to encode store creation saga", "saga_id", sg.ID, "error", err)
+	}
+	s.save(ctx, sg)
 
 	// Extract the store ID from the response
 	storeServicesRequest := store.ToServiceCreateStoreRequest()
 
 	// Step 2: Create store in product and order services concurrently
-	servicesResponse, successfulServices := s.Client.CreateStoreInServices(&storeServicesRequest)
+	servicesResponse, _ := s.Client.CreateStoreInServices(ctx, &storeServicesRequest)
 
 	// Step 3: Check if all services succeeded
 	allSucceeded := true
-	for _, result := range servicesResponse {
-		if !result.Success {
-			allSucceeded = false
-			break
+	for name, result := range servicesResponse {
+		if result.Success {
+			sg.SetStep(name, saga.StepDone, nil)
+			continue
 		}
+		allSucceeded = false
+		sg.SetStep(name, saga.StepFailed, errors.New(result.Error))
+	}
+
+	if allSucceeded {
+		sg.Status = saga.StatusCompleted
+		s.save(ctx, sg)
+		return &store, nil
 	}
 
-	// Step 4: Handle compensating transactions if necessary
-	if !allSucceeded {
-		log.Printf("Store creation failed in some services. Initiating compensating transactions.")
-		s.Client.CompensateStoreCreation(successfulServices, store.ID)
+	// Step 4: Undo the steps that succeeded; whatever fails now is retried by ResumeSagas
+	slog.WarnContext(ctx, "Store creation failed in some services. Initiating compensating transactions.", "saga_id", sg.ID)
+	sg.Status = saga.StatusCompensating
+	sg.LastError = fmt.Sprintf("store creation failed in some services: %v", servicesResponse)
+	s.save(ctx, sg)
+	s.compensate(context.WithoutCancel(ctx), sg)
 
-		// Respond with error
-		return nil, apperrors.NewInternalServerError(fmt.Sprintf("store creation failed in some services: %v", servicesResponse))
+	// Respond with error
+	return nil, apperrors.NewInternalServerError(fmt.Sprintf("store creation failed in some services: %v", servicesResponse))
+}
+
+// ResumeSagas finishes store sagas left unfinished: it retries compensations that failed, rolls
+// back creations whose gateway stopped before they completed and carries such deletions through.
+// Only sagas nobody has saved for StaleAfter are picked up, as the request, or a retry, may still
+// be running or compensating them; a compensation that failed is therefore retried StaleAfter later.
+// Each saga is claimed first, so with several gateways only one of them resumes it.
+func (s *StoreService) ResumeSagas(ctx context.Context) {
+	sagas, err := s.Sagas.List(ctx, saga.StatusRunning, saga.StatusCompensating)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list unfinished sagas", "error", err)
+		return
 	}
 
-	return &store, nil
+	staleBefore := time.Now().Add(-s.sagaCfg.StaleAfter)
+	for i := range sagas {
+		if sagas[i].Kind != SagaCreateStore && sagas[i].Kind != SagaDeleteStore {
+			continue
+		}
+		if !sagas[i].UpdatedAt.Before(staleBefore) {
+			continue
+		}
+		// Other gateway replicas resume the same sagas, so only go on with those this one claims
+		sg, err := s.Sagas.Claim(ctx, sagas[i].ID, staleBefore)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim saga", "saga_id", sagas[i].ID, "error", err)
+			continue
+		}
+		if sg == nil {
+			continue
+		}
+		if sg.Status == saga.StatusRunning {
+			if sg.Kind == SagaDeleteStore {
+				s.resumeDeletion(ctx, sg)
+				continue
+			}
+			if !s.abandon(ctx, sg) {
+				continue
+			}
+		}
+		s.compensate(ctx, sg)
+	}
+}
 
+// RetrySaga restarts the compensation of a failed saga with fresh attempts.
+func (s *StoreService) RetrySaga(ctx context.Context, id string) (*saga.Saga, error) {
+	sg, err := s.Sagas.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sg == nil {
+		return nil, apperrors.NewNotFoundError("saga not found")
+	}
+	if sg.Status != saga.StatusFailed {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("saga is %s, only failed sagas can be retried", sg.Status))
+	}
+
+	for i := range sg.Steps {
+		if sg.Steps[i].Status == saga.StepCompensationFailed {
+			sg.Steps[i].Status = saga.StepDone
+			sg.Steps[i].Attempts = 0
+		}
+	}
+	sg.Status = saga.StatusCompensating
+	// Saved first, so ResumeSagas sees it was just picked up and leaves it to this retry
+	s.save(ctx, sg)
+	s.compensate(ctx, sg)
+	return sg, nil
+}
+
+// abandon marks a stale running saga for rollback. If user-service never answered, the gateway
+// cannot know whether the store exists there, so the saga is failed for an operator to check.
+func (s *StoreService) abandon(ctx context.Context, sg *saga.Saga) bool {
+	if sg.Step(httpcient.ServiceUser).Status != saga.StepDone {
+		sg.Status = saga.StatusFailed
+		sg.LastError = "gateway stopped before user-service answered; the store may exist there"
+		slog.ErrorContext(ctx, "Saga failed", "saga_id", sg.ID, "reason", sg.LastError)
+		s.save(ctx, sg)
+		return false
+	}
+
+	slog.WarnContext(ctx, "Saga was interrupted, rolling back the store creation", "saga_id", sg.ID)
+	sg.Status = saga.StatusCompensating
+	sg.LastError = "gateway stopped before the store was created everywhere"
+	s.save(ctx, sg)
+	return true
+}
+
+// compensate undoes the steps of a store saga that were done and records the outcome. Steps that
+// fail keep their attempts and are retried on the next run until the attempts run out, which fails
+// the saga.
+func (s *StoreService) compensate(ctx context.Context, sg *saga.Saga) {
+	if sg.Kind == SagaDeleteStore {
+		s.compensateDeletion(ctx, sg)
+		return
+	}
+	s.compensateCreation(ctx, sg)
+}
+
+// compensateCreation deletes the store from every service it was created in, user-service last.
+func (s *StoreService) compensateCreation(ctx context.Context, sg *saga.Saga) {
+	var payload createStorePayload
+	if err := sg.Decode(&payload); err != nil || payload.Store == nil {
+		sg.Status = saga.StatusFailed
+		sg.LastError = "saga has no store to compensate"
+		s.save(ctx, sg)
+		return
+	}
+	storeID := payload.Store.ID
+	// Compensation may run long after the request, so act as the user who created the store
+	ctx = identity.With(ctx, identity.Identity{UserID: sg.UserID, StoreIDs: []int{int(storeID)}})
+
+	order := []string{httpcient.ServiceProduct, httpcient.ServiceOrder, httpcient.ServiceUser}
+	remaining := false
+	for _, name := range order {
+		step := sg.Step(name)
+		if step == nil || step.Status != saga.StepDone {
+			continue
+		}
+		// user-service owns the store, so it is only deleted once nothing else references it
+		if name == httpcient.ServiceUser && remaining {
+			continue
+		}
+
+		err := s.Client.DeleteStoreFromService(ctx, name, storeID)
+		if errors.Is(err, httpcient.ErrStoreNotFound) {
+			// Already gone, e.g. an earlier attempt succeeded but its answer was lost
+			err = nil
+		}
+		if !s.recordCompensation(ctx, sg, step, err) {
+			remaining = true
+		}
+	}
+
+	sg.Status = compensationStatus(sg)
+	s.save(ctx, sg)
+}
+
+// recordCompensation records one attempt at undoing a step and reports whether it succeeded.
+func (s *StoreService) recordCompensation(ctx context.Context, sg *saga.Saga, step *saga.Step, err error) bool {
+	name := step.Name
+	step.Attempts++
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "Successfully performed compensating transaction", "saga_id", sg.ID, "service", name)
+		sg.SetStep(name, saga.StepCompensated, nil)
+		return true
+	case step.Attempts >= s.sagaCfg.MaxAttempts:
+		slog.ErrorContext(ctx, "Compensation transaction gave up", "saga_id", sg.ID, "service", name, "attempts", step.Attempts, "error", err)
+		sg.SetStep(name, saga.StepCompensationFailed, err)
+	default:
+		slog.WarnContext(ctx, "Compensation transaction failed, will retry", "saga_id", sg.ID, "service", name, "error", err)
+		sg.SetStep(name, saga.StepDone, err)
+	}
+	return false
+}
+
+// compensationStatus is compensated once no step is left done, failed if a step ran out of
+// attempts, and compensating otherwise.
+func compensationStatus(sg *saga.Saga) string {
+	status := saga.StatusCompensated
+	for _, step := range sg.Steps {
+		switch step.Status {
+		case saga.StepCompensationFailed:
+			return saga.StatusFailed
+		case saga.StepDone:
+			status = saga.StatusCompensating
+		}
+	}
+	return status
+}
+
+func (s *StoreService) save(ctx context.Context, sg *saga.Saga) {
+	if err := s.Sagas.Save(context.WithoutCancel(ctx), sg); err != nil {
+		slog.ErrorContext(ctx, "Failed to save saga", "saga_id", sg.ID, "error", err)
+	}
 }
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/model"
+	"github.com/robaa12/gatway-service/internal/saga"
+)
+
+// SagaDeleteStore is the saga kind recorded for each store deletion.
+const SagaDeleteStore = "delete_store"
+
+// deletionOrder archives the store in the services that hold its data before user-service, which
+// owns it, deletes it for good. Only the archiving can be undone, so user-service goes last.
+var deletionOrder = []string{httpcient.ServiceProduct, httpcient.ServiceOrder, httpcient.ServiceUser}
+
+// deleteStorePayload is what a store deletion saga needs to be resumed.
+type deleteStorePayload struct {
+	StoreID uint `json:"store_id"`
+}
+
+// ExportStore collects the store's data from the services, so the owner can keep it once the store
+// is deleted.
+func (s *StoreService) ExportStore(ctx context.Context, storeID uint) (*model.StoreExport, error) {
+	export, err := s.Client.ExportStore(ctx, storeID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export store", "store_id", storeID, "error", err)
+		return nil, apperrors.NewBadGatewayError("could not export the store's data, so it was not deleted")
+	}
+	return export, nil
+}
+
+// DeleteStore deletes the store from every service as a saga, mirroring CreateStore. If a service
+// fails, the store is restored where it was already archived.
+func (s *StoreService) DeleteStore(ctx context.Context, userID int, storeID uint) (*saga.Saga, error) {
+	sg, err := saga.New(saga.NewID(), SagaDeleteStore, userID, deleteStorePayload{StoreID: storeID}, deletionOrder...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Sagas.Create(ctx, sg); err != nil {
+		slog.ErrorContext(ctx, "Failed to record store deletion saga", "error", err)
+		return nil, apperrors.NewServiceUnavailableError("store deletion is unavailable, try again later")
+	}
+
+	if err := s.runDeletion(ctx, sg, storeID); err != nil {
+		return nil, err
+	}
+	return sg, nil
+}
+
+// runDeletion applies the steps not done yet, in order. A service that no longer has the store
+// counts as done, so an interrupted deletion can simply be run again.
+func (s *StoreService) runDeletion(ctx context.Context, sg *saga.Saga, storeID uint) error {
+	for _, name := range deletionOrder {
+		if sg.Step(name).Status == saga.StepDone {
+			continue
+		}
+
+		err := s.Client.DeleteStoreFromService(ctx, name, storeID)
+		if err != nil && !errors.Is(err, httpcient.ErrStoreNotFound) {
+			slog.WarnContext(ctx, "Store deletion failed. Initiating compensating transactions.", "saga_id", sg.ID, "service", name, "error", err)
+			sg.SetStep(name, saga.StepFailed, err)
+			sg.Status = saga.StatusCompensating
+			sg.LastError = fmt.Sprintf("store deletion failed in %s: %v", name, err)
+			s.save(ctx, sg)
+			s.compensate(context.WithoutCancel(ctx), sg)
+			return apperrors.NewInternalServerError(sg.LastError)
+		}
+		sg.SetStep(name, saga.StepDone, nil)
+		s.save(ctx, sg)
+	}
+
+	sg.Status = saga.StatusCompleted
+	s.save(ctx, sg)
+	s.Slugs.ForgetStore(int(storeID))
+	s.revokeStoreAccess(context.WithoutCancel(ctx), storeID)
+	return nil
+}
+
+// resumeDeletion carries a deletion interrupted by a gateway restart through. The owner asked for
+// it and every step can be repeated, so it is finished rather than rolled back.
+func (s *StoreService) resumeDeletion(ctx context.Context, sg *saga.Saga) {
+	var payload deleteStorePayload
+	if err := sg.Decode(&payload); err != nil || payload.StoreID == 0 {
+		sg.Status = saga.StatusFailed
+		sg.LastError = "saga has no store to delete"
+		s.save(ctx, sg)
+		return
+	}
+
+	slog.WarnContext(ctx, "Saga was interrupted, finishing the store deletion", "saga_id", sg.ID)
+	ctx = identity.With(ctx, identity.Identity{UserID: sg.UserID, StoreIDs: []int{int(payload.StoreID)}})
+	if err := s.runDeletion(ctx, sg, payload.StoreID); err != nil {
+		slog.ErrorContext(ctx, "Saga could not finish the store deletion", "saga_id", sg.ID, "error", err)
+	}
+}
+
+// compensateDeletion restores the store where it was archived. user-service is the last step, so a
+// deletion being compensated never removed the store there.
+func (s *StoreService) compensateDeletion(ctx context.Context, sg *saga.Saga) {
+	var payload deleteStorePayload
+	if err := sg.Decode(&payload); err != nil || payload.StoreID == 0 {
+		sg.Status = saga.StatusFailed
+		sg.LastError = "saga has no store to compensate"
+		s.save(ctx, sg)
+		return
+	}
+	ctx = identity.With(ctx, identity.Identity{UserID: sg.UserID, StoreIDs: []int{int(payload.StoreID)}})
+
+	for _, name := range []string{httpcient.ServiceOrder, httpcient.ServiceProduct} {
+		step := sg.Step(name)
+		if step == nil || step.Status != saga.StepDone {
+			continue
+		}
+		err := s.Client.RestoreStoreInService(ctx, name, payload.StoreID)
+		if errors.Is(err, httpcient.ErrStoreNotFound) {
+			// The service never had the store, so there is nothing to restore
+			err = nil
+		}
+		s.recordCompensation(ctx, sg, step, err)
+	}
+
+	sg.Status = compensationStatus(sg)
+	s.save(ctx, sg)
+}
+
+// revokeStoreAccess removes the staff and API keys of a deleted store. They could no longer reach
+// it anyway, so failures are only logged.
+func (s *StoreService) revokeStoreAccess(ctx context.Context, storeID uint) {
+	id := int(storeID)
+
+	members, err := s.access.Staff.List(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list staff of deleted store", "store_id", storeID, "error", err)
+	}
+	for _, member := range members {
+		if _, err := s.access.Staff.Remove(ctx, id, member.UserID); err != nil {
+			slog.ErrorContext(ctx, "Failed to remove user from deleted store", "user_id", member.UserID, "store_id", storeID, "error", err)
+		}
+	}
+
+	keys, err := s.access.APIKeys.List(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list API keys of deleted store", "store_id", storeID, "error", err)
+	}
+	for _, key := range keys {
+		if key.RevokedAt != nil {
+			continue
+		}
+		if _, err := s.access.APIKeys.Revoke(ctx, id, key.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to revoke API key of deleted store", "key_id", key.ID, "store_id", storeID, "error", err)
+		}
+	}
+}
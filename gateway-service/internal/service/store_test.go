@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
+	"github.com/robaa12/gatway-service/internal/identity"
+	"github.com/robaa12/gatway-service/internal/model"
+	"github.com/robaa12/gatway-service/internal/saga"
+)
+
+// fakeServices stands in for user-, product- and order-service, recording the store deletions
+// they are sent and failing those of the services in failing.
+type fakeServices struct {
+	mu      sync.Mutex
+	deleted []string
+	failing []string
+}
+
+func (f *fakeServices) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, r.Method+" "+r.URL.Path)
+	for _, prefix := range f.failing {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestResumeSagas(t *testing.T) {
+	const staleAfter = 2 * time.Minute
+	stale := time.Now().Add(-2 * staleAfter)
+	fresh := time.Now()
+
+	tests := []struct {
+		name         string
+		kind         string
+		status       string
+		updatedAt    time.Time
+		steps        map[string]string
+		attempts     int
+		failing      []string
+		wantStatus   string
+		wantRequests []string
+	}{
+		{
+			name:       "running creation still in progress",
+			status:     saga.StatusRunning,
+			updatedAt:  fresh,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone},
+			wantStatus: saga.StatusRunning,
+		},
+		{
+			name:       "interrupted creation is rolled back",
+			status:     saga.StatusRunning,
+			updatedAt:  stale,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone, httpcient.ServiceProduct: saga.StepDone},
+			wantStatus: saga.StatusCompensated,
+			wantRequests: []string{
+				"DELETE /product/stores/7",
+				"DELETE /user/store/7",
+			},
+		},
+		{
+			name:       "interrupted before user-service answered",
+			status:     saga.StatusRunning,
+			updatedAt:  stale,
+			steps:      map[string]string{},
+			wantStatus: saga.StatusFailed,
+		},
+		{
+			name:       "compensation still running inline",
+			status:     saga.StatusCompensating,
+			updatedAt:  fresh,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone, httpcient.ServiceOrder: saga.StepDone},
+			wantStatus: saga.StatusCompensating,
+		},
+		{
+			name:       "stale compensation is retried",
+			status:     saga.StatusCompensating,
+			updatedAt:  stale,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone, httpcient.ServiceOrder: saga.StepDone},
+			wantStatus: saga.StatusCompensated,
+			wantRequests: []string{
+				"DELETE /order/stores/7",
+				"DELETE /user/store/7",
+			},
+		},
+		{
+			name:       "failing compensation keeps user-service",
+			status:     saga.StatusCompensating,
+			updatedAt:  stale,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone, httpcient.ServiceOrder: saga.StepDone},
+			failing:    []string{"/order/"},
+			wantStatus: saga.StatusCompensating,
+			wantRequests: []string{
+				"DELETE /order/stores/7",
+			},
+		},
+		{
+			name:       "compensation out of attempts",
+			status:     saga.StatusCompensating,
+			updatedAt:  stale,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone, httpcient.ServiceOrder: saga.StepDone},
+			attempts:   2,
+			failing:    []string{"/order/"},
+			wantStatus: saga.StatusFailed,
+			wantRequests: []string{
+				"DELETE /order/stores/7",
+			},
+		},
+		{
+			name:       "other kinds are left alone",
+			kind:       "something_else",
+			status:     saga.StatusRunning,
+			updatedAt:  stale,
+			steps:      map[string]string{httpcient.ServiceUser: saga.StepDone},
+			wantStatus: saga.StatusRunning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeServices{failing: tt.failing}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			client := httpcient.NewClient(srv.URL+"/user", srv.URL+"/product", srv.URL+"/order", identity.NewSigner("test-secret"), time.Second)
+			sagas := saga.NewMemoryLog()
+			s := NewStoreService(client, sagas, nil, nil, config.SagaConfig{MaxAttempts: 3, StaleAfter: staleAfter})
+
+			kind := tt.kind
+			if kind == "" {
+				kind = SagaCreateStore
+			}
+			sg, err := saga.New("saga-1", kind, 1,
+				createStorePayload{Store: &model.Store{ID: 7}},
+				httpcient.ServiceUser, httpcient.ServiceProduct, httpcient.ServiceOrder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sg.Status = tt.status
+			sg.UpdatedAt = tt.updatedAt
+			for name, status := range tt.steps {
+				sg.SetStep(name, status, nil)
+				sg.Step(name).Attempts = tt.attempts
+			}
+			if err := sagas.Create(context.Background(), sg); err != nil {
+				t.Fatal(err)
+			}
+
+			s.ResumeSagas(context.Background())
+
+			got, err := sagas.Get(context.Background(), "saga-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %s (%s), want %s", got.Status, got.LastError, tt.wantStatus)
+			}
+			if !slices.Equal(fake.deleted, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", fake.deleted, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCompensationStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []string
+		want  string
+	}{
+		{"all undone", []string{saga.StepCompensated, saga.StepPending, saga.StepFailed}, saga.StatusCompensated},
+		{"one left", []string{saga.StepCompensated, saga.StepDone}, saga.StatusCompensating},
+		{"one gave up", []string{saga.StepDone, saga.StepCompensationFailed}, saga.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := &saga.Saga{}
+			for _, status := range tt.steps {
+				sg.Steps = append(sg.Steps, saga.Step{Status: status})
+			}
+			if got := compensationStatus(sg); got != tt.want {
+				t.Fatalf("compensationStatus = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResumeSagasClaimsEachSagaOnce(t *testing.T) {
+	fake := &fakeServices{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow enough for both gateways to have listed the saga before either finishes it
+		time.Sleep(20 * time.Millisecond)
+		fake.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	sagas := saga.NewMemoryLog()
+	sg, err := saga.New("saga-1", SagaCreateStore, 1,
+		createStorePayload{Store: &model.Store{ID: 7}},
+		httpcient.ServiceUser, httpcient.ServiceProduct, httpcient.ServiceOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg.Status = saga.StatusCompensating
+	sg.SetStep(httpcient.ServiceUser, saga.StepDone, nil)
+	sg.UpdatedAt = time.Now().Add(-time.Hour)
+	if err := sagas.Create(context.Background(), sg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two gateway replicas sharing the saga log
+	var wg sync.WaitGroup
+	for range 2 {
+		client := httpcient.NewClient(srv.URL+"/user", srv.URL+"/product", srv.URL+"/order", identity.NewSigner("test-secret"), time.Second)
+		s := NewStoreService(client, sagas, nil, nil, config.SagaConfig{MaxAttempts: 3, StaleAfter: time.Minute})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.ResumeSagas(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if want := []string{"DELETE /user/store/7"}; !slices.Equal(fake.deleted, want) {
+		t.Fatalf("requests = %v, want %v", fake.deleted, want)
+	}
+}
+
+func TestCompensationGivesUpOnHungService(t *testing.T) {
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer srv.Close()
+	defer close(hung)
+
+	client := httpcient.NewClient(srv.URL+"/user", srv.URL+"/product", srv.URL+"/order", identity.NewSigner("test-secret"), 50*time.Millisecond)
+	sagas := saga.NewMemoryLog()
+	s := NewStoreService(client, sagas, nil, nil, config.SagaConfig{MaxAttempts: 3, StaleAfter: time.Minute})
+	sg, err := saga.New("saga-1", SagaCreateStore, 1,
+		createStorePayload{Store: &model.Store{ID: 7}},
+		httpcient.ServiceUser, httpcient.ServiceProduct, httpcient.ServiceOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg.Status = saga.StatusCompensating
+	sg.SetStep(httpcient.ServiceOrder, saga.StepDone, nil)
+	sg.UpdatedAt = time.Now().Add(-time.Hour)
+	if err := sagas.Create(context.Background(), sg); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.ResumeSagas(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ResumeSagas is stuck on a service that does not answer")
+	}
+
+	got, err := sagas.Get(context.Background(), "saga-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step := got.Step(httpcient.ServiceOrder); step.Status != saga.StepDone || step.Attempts != 1 || step.LastError == "" {
+		t.Fatalf("order step = %+v, want a failed attempt left to retry", step)
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
+	"github.com/robaa12/gatway-service/internal/model"
+)
+
+// Sections of the storefront pages.
+const (
+	SectionTheme       = "theme"
+	SectionProduct     = "product"
+	SectionProducts    = "products"
+	SectionCategories  = "categories"
+	SectionCollections = "collections"
+)
+
+// section loads one part of a storefront page.
+type section func(ctx context.Context) (json.RawMessage, error)
+
+// StorefrontService composes the storefront's pages from user-service and product-service, so a
+// storefront renders a page with one call instead of one per service.
+type StorefrontService struct {
+	Client *httpcient.Client
+	cfg    config.StorefrontConfig
+}
+
+// NewStorefrontService creates a new storefront service
+func NewStorefrontService(client *httpcient.Client, cfg config.StorefrontConfig) *StorefrontService {
+	return &StorefrontService{
+		Client: client,
+		cfg:    cfg,
+	}
+}
+
+// HomePage composes a store's home page: its theme, first products, categories and collections.
+func (s *StorefrontService) HomePage(ctx context.Context, storeSlug string) (*model.StorefrontPage, error) {
+	store, err := s.store(ctx, storeSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	page, _ := s.compose(ctx, store, map[string]section{
+		SectionTheme: func(ctx context.Context) (json.RawMessage, error) {
+			return s.Client.GetActiveTheme(ctx, store.ID)
+		},
+		SectionProducts: func(ctx context.Context) (json.RawMessage, error) {
+			return s.Client.GetStoreProducts(ctx, store.ID, s.cfg.HomeProducts, 0)
+		},
+		SectionCategories:  s.list(s.Client.GetCategories, store.ID),
+		SectionCollections: s.list(s.Client.GetCollections, store.ID),
+	})
+	return page, nil
+}
+
+// ProductPage composes a product's page: the product with its review statistics and related
+// products, and the store's theme, categories and collections for the surrounding layout.
+func (s *StorefrontService) ProductPage(ctx context.Context, storeSlug, productSlug string) (*model.StorefrontPage, error) {
+	store, err := s.store(ctx, storeSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	page, errs := s.compose(ctx, store, map[string]section{
+		SectionProduct: func(ctx context.Context) (json.RawMessage, error) {
+			return s.Client.GetProductBySlug(ctx, store.ID, productSlug)
+		},
+		SectionTheme: func(ctx context.Context) (json.RawMessage, error) {
+			return s.Client.GetActiveTheme(ctx, store.ID)
+		},
+		SectionCategories:  s.list(s.Client.GetCategories, store.ID),
+		SectionCollections: s.list(s.Client.GetCollections, store.ID),
+	})
+	// The rest of the page is no use without the product, unlike when product-service is down
+	if errors.Is(errs[SectionProduct], httpcient.ErrNotFound) {
+		return nil, apperrors.NewNotFoundError("product not found")
+	}
+	return page, nil
+}
+
+// store resolves the store every section of a page belongs to. Nothing can be loaded without it,
+// so unlike a section it fails the whole page.
+func (s *StorefrontService) store(ctx context.Context, storeSlug string) (*model.Store, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.SectionTimeout)
+	defer cancel()
+
+	store, err := s.Client.GetStoreBySlug(ctx, storeSlug)
+	if errors.Is(err, httpcient.ErrStoreNotFound) {
+		return nil, apperrors.NewNotFoundError("store not found")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load storefront store", "store_slug", storeSlug, "error", err)
+		return nil, apperrors.NewBadGatewayError("could not load the store")
+	}
+	return store, nil
+}
+
+// list loads a list that product-service answers with a 404 when it is empty.
+func (s *StorefrontService) list(fetch func(context.Context, uint) (json.RawMessage, error), storeID uint) section {
+	return func(ctx context.Context) (json.RawMessage, error) {
+		data, err := fetch(ctx, storeID)
+		if errors.Is(err, httpcient.ErrNotFound) {
+			return json.RawMessage("[]"), nil
+		}
+		return data, err
+	}
+}
+
+// compose loads the sections of a page concurrently, each within the section timeout. Sections
+// that fail are reported on the page and returned with their errors.
+func (s *StorefrontService) compose(ctx context.Context, store *model.Store, sections map[string]section) (*model.StorefrontPage, map[string]error) {
+	page := &model.StorefrontPage{
+		Store:    *store,
+		Sections: make(map[string]model.StorefrontSection, len(sections)),
+	}
+	errs := make(map[string]error)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, load := range sections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sectionCtx, cancel := context.WithTimeout(ctx, s.cfg.SectionTimeout)
+			defer cancel()
+
+			data, err := load(sectionCtx)
+			result := model.StorefrontSection{Data: data}
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to load storefront section", "section", name, "store_id", store.ID, "error", err)
+				result = model.StorefrontSection{Error: sectionError(err)}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			page.Sections[name] = result
+			if err != nil {
+				errs[name] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	return page, errs
+}
+
+// sectionError tells the client why a section is missing without exposing the services behind it.
+func sectionError(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	case errors.Is(err, httpcient.ErrNotFound):
+		return "not found"
+	default:
+		return "unavailable"
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robaa12/gatway-service/internal/config"
+	apperrors "github.com/robaa12/gatway-service/internal/errors"
+	httpcient "github.com/robaa12/gatway-service/internal/http-cient"
+	"github.com/robaa12/gatway-service/internal/identity"
+)
+
+// fakeResponse is what a fake storefront service answers one path with.
+type fakeResponse struct {
+	status int
+	body   string
+	delay  time.Duration
+}
+
+// newStorefrontService serves the paths in responses from a fake user- and product-service and
+// answers every other path with a 404.
+func newStorefrontService(t *testing.T, responses map[string]fakeResponse) *StorefrontService {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		select {
+		case <-time.After(resp.delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(resp.status)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := httpcient.NewClient(srv.URL+"/user", srv.URL+"/product", srv.URL+"/order", identity.NewSigner("test-secret"), time.Second)
+	return NewStorefrontService(client, config.StorefrontConfig{SectionTimeout: 100 * time.Millisecond, HomeProducts: 12})
+}
+
+var storefrontStore = fakeResponse{status: http.StatusOK, body: `{"data":{"id":7,"name":"Shop","slug":"shop"}}`}
+
+func TestHomePage(t *testing.T) {
+	s := newStorefrontService(t, map[string]fakeResponse{
+		"/user/store/slug/shop":      storefrontStore,
+		"/user/store/theme/7/active": {status: http.StatusOK, body: `{"data":{"name":"dark"}}`},
+		"/product/stores/7/products": {status: http.StatusOK, body: `[{"id":1}]`, delay: time.Second},
+		// Categories are left out, so product-service answers them with a 404
+		"/product/stores/7/collections": {status: http.StatusInternalServerError},
+	})
+
+	page, err := s.HomePage(context.Background(), "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Store.ID != 7 {
+		t.Fatalf("store ID = %d, want 7", page.Store.ID)
+	}
+
+	want := map[string]struct{ data, err string }{
+		SectionTheme:       {data: `{"name":"dark"}`},
+		SectionProducts:    {err: "timed out"},
+		SectionCategories:  {data: `[]`},
+		SectionCollections: {err: "unavailable"},
+	}
+	if len(page.Sections) != len(want) {
+		t.Fatalf("sections = %v, want %d of them", page.Sections, len(want))
+	}
+	for name, w := range want {
+		got := page.Sections[name]
+		if string(got.Data) != w.data || got.Error != w.err {
+			t.Errorf("section %s = {%s %q}, want {%s %q}", name, got.Data, got.Error, w.data, w.err)
+		}
+	}
+}
+
+func TestStorefrontPageErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  map[string]fakeResponse
+		wantStatus int
+	}{
+		{
+			name:       "unknown store",
+			responses:  map[string]fakeResponse{},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "user-service down",
+			responses:  map[string]fakeResponse{"/user/store/slug/shop": {status: http.StatusInternalServerError}},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "unknown product",
+			responses:  map[string]fakeResponse{"/user/store/slug/shop": storefrontStore},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorefrontService(t, tt.responses)
+			_, err := s.ProductPage(context.Background(), "shop", "hat")
+
+			var appErr apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.StatusCode != tt.wantStatus {
+				t.Fatalf("ProductPage error = %v, want a %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestProductPageWithoutProductService(t *testing.T) {
+	s := newStorefrontService(t, map[string]fakeResponse{
+		"/user/store/slug/shop":               storefrontStore,
+		"/user/store/theme/7/active":          {status: http.StatusOK, body: `{"data":null}`},
+		"/product/stores/7/products/slug/hat": {status: http.StatusServiceUnavailable},
+	})
+
+	// Unlike a product that does not exist, one product-service cannot load still renders the page
+	page, err := s.ProductPage(context.Background(), "shop", "hat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := page.Sections[SectionProduct]; got.Error != "unavailable" {
+		t.Fatalf("product section = {%s %q}, want it unavailable", got.Data, got.Error)
+	}
+	if got := page.Sections[SectionTheme]; string(got.Data) != "null" || got.Error != "" {
+		t.Fatalf("theme section = {%s %q}, want null for a store without a theme", got.Data, got.Error)
+	}
+}
@@ -0,0 +1,105 @@
+// Package storeslug resolves store slugs to store IDs, so routes that address a store by its slug
+// can be checked like those that use its ID.
+package storeslug
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no store has the slug.
+var ErrNotFound = errors.New("store not found")
+
+const (
+	// missTTL bounds how long an unknown slug is remembered, so a store created through another
+	// gateway replica is found soon after.
+	missTTL = 10 * time.Second
+	// maxEntries bounds the cache; it is emptied when full, as slugs are cheap to look up again.
+	maxEntries = 10000
+)
+
+// LookupFunc asks the service that owns store slugs for the ID of the store with slug. It returns
+// ErrNotFound when there is none.
+type LookupFunc func(ctx context.Context, slug string) (int, error)
+
+// Resolver caches slug lookups. Slugs change rarely, but they do change, so the gateway forgets a
+// store's slug whenever it creates, changes or deletes that store.
+type Resolver struct {
+	lookup LookupFunc
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// entry is a cached lookup; id is 0 for a slug no store has.
+type entry struct {
+	id        int
+	expiresAt time.Time
+}
+
+func NewResolver(lookup LookupFunc, ttl time.Duration) *Resolver {
+	return &Resolver{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+}
+
+// Resolve returns the ID of the store with slug.
+func (r *Resolver) Resolve(ctx context.Context, slug string) (int, error) {
+	now := time.Now()
+	r.mu.Lock()
+	e, ok := r.entries[slug]
+	r.mu.Unlock()
+	if ok && e.expiresAt.After(now) {
+		if e.id == 0 {
+			return 0, ErrNotFound
+		}
+		return e.id, nil
+	}
+
+	id, err := r.lookup(ctx, slug)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		r.store(slug, entry{expiresAt: now.Add(min(missTTL, r.ttl))})
+		return 0, ErrNotFound
+	case err != nil:
+		// Not cached, so a failing lookup is retried by the next request
+		return 0, err
+	}
+	r.store(slug, entry{id: id, expiresAt: now.Add(r.ttl)})
+	return id, nil
+}
+
+// Forget drops slug, so the next request looks it up again.
+func (r *Resolver) Forget(slug string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, slug)
+}
+
+// ForgetStore drops every slug cached for the store with id, such as its old slug after a rename.
+func (r *Resolver) ForgetStore(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for slug, e := range r.entries {
+		if e.id == id {
+			delete(r.entries, slug)
+		}
+	}
+}
+
+func (r *Resolver) store(slug string, e entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) >= maxEntries {
+		r.entries = make(map[string]entry)
+	}
+	r.entries[slug] = e
+}
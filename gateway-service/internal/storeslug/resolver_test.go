@@ -0,0 +1,115 @@
+package storeslug
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeLookup answers from stores and counts the lookups it is asked for.
+type fakeLookup struct {
+	stores map[string]int
+	err    error
+	calls  int
+}
+
+func (f *fakeLookup) lookup(ctx context.Context, slug string) (int, error) {
+	f.calls++
+	if f.err != nil {
+		return 0, f.err
+	}
+	id, ok := f.stores[slug]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return id, nil
+}
+
+func TestResolve(t *testing.T) {
+	f := &fakeLookup{stores: map[string]int{"shop": 7}}
+	r := NewResolver(f.lookup, time.Minute)
+	ctx := context.Background()
+
+	for range 3 {
+		id, err := r.Resolve(ctx, "shop")
+		if err != nil || id != 7 {
+			t.Fatalf("Resolve(shop) = %d, %v, want 7", id, err)
+		}
+	}
+	if f.calls != 1 {
+		t.Fatalf("looked shop up %d times, want once", f.calls)
+	}
+
+	// Unknown slugs are cached too, so they cannot be used to flood the lookup
+	for range 2 {
+		if _, err := r.Resolve(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Resolve(missing) error = %v, want ErrNotFound", err)
+		}
+	}
+	if f.calls != 2 {
+		t.Fatalf("looked slugs up %d times, want 2", f.calls)
+	}
+}
+
+func TestResolveDoesNotCacheFailures(t *testing.T) {
+	f := &fakeLookup{stores: map[string]int{"shop": 7}, err: errors.New("user-service down")}
+	r := NewResolver(f.lookup, time.Minute)
+	ctx := context.Background()
+
+	if _, err := r.Resolve(ctx, "shop"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Resolve error = %v, want the lookup's error", err)
+	}
+	f.err = nil
+	if id, err := r.Resolve(ctx, "shop"); err != nil || id != 7 {
+		t.Fatalf("Resolve after the lookup recovered = %d, %v, want 7", id, err)
+	}
+}
+
+func TestResolveExpires(t *testing.T) {
+	f := &fakeLookup{stores: map[string]int{"shop": 7}}
+	r := NewResolver(f.lookup, time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := r.Resolve(ctx, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := r.Resolve(ctx, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 2 {
+		t.Fatalf("looked shop up %d times, want it looked up again once expired", f.calls)
+	}
+}
+
+func TestForget(t *testing.T) {
+	f := &fakeLookup{stores: map[string]int{"shop": 7, "old-shop": 7, "other": 8}}
+	r := NewResolver(f.lookup, time.Minute)
+	ctx := context.Background()
+	for _, slug := range []string{"shop", "old-shop", "other", "new-shop"} {
+		_, _ = r.Resolve(ctx, slug)
+	}
+
+	// A store created with a slug that was looked up before it existed
+	f.stores["new-shop"] = 9
+	r.Forget("new-shop")
+	if id, err := r.Resolve(ctx, "new-shop"); err != nil || id != 9 {
+		t.Fatalf("Resolve(new-shop) after Forget = %d, %v, want 9", id, err)
+	}
+
+	// A store renamed from old-shop to shop
+	delete(f.stores, "old-shop")
+	r.ForgetStore(7)
+	if _, err := r.Resolve(ctx, "old-shop"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Resolve(old-shop) after ForgetStore error = %v, want ErrNotFound", err)
+	}
+
+	calls := f.calls
+	if id, err := r.Resolve(ctx, "other"); err != nil || id != 8 {
+		t.Fatalf("Resolve(other) = %d, %v, want 8", id, err)
+	}
+	if f.calls != calls {
+		t.Fatal("ForgetStore dropped another store's slug")
+	}
+}
@@ -29,7 +29,7 @@ func (customerHandler *CustomerHandler) CreateNewCustomer(w http.ResponseWriter,
 		return
 	}
 	// Get Customer Response
-	customerResponse, err := customerHandler.CustomerService.CreateNewCustomer(&customerRequest, storeId)
+	customerResponse, err := customerHandler.CustomerService.CreateNewCustomer(r.Context(), &customerRequest, storeId)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusConflict)
 		return
@@ -44,7 +44,7 @@ func (customerHandler *CustomerHandler) GetAllCustomers(w http.ResponseWriter, r
 		return
 	}
 	// Get All Customers Response
-	customersResponse, err := customerHandler.CustomerService.GetAllCustomers(storeId)
+	customersResponse, err := customerHandler.CustomerService.GetAllCustomers(r.Context(), storeId)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusNotFound)
 		return
@@ -65,7 +65,7 @@ func (customerHandler *CustomerHandler) GetCustomer(w http.ResponseWriter, r *ht
 		return
 	}
 	// Get Customer Response
-	customerResponse, err := customerHandler.CustomerService.GetCustomer(storeId, customerId)
+	customerResponse, err := customerHandler.CustomerService.GetCustomer(r.Context(), storeId, customerId)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusNotFound)
 		return
@@ -88,7 +88,7 @@ func (customerHandler *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r
 		return
 	}
 	// Delete Customer
-	err = customerHandler.CustomerService.DeleteCustomer(storeId, customerId)
+	err = customerHandler.CustomerService.DeleteCustomer(r.Context(), storeId, customerId)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err, http.StatusNotFound)
 		return
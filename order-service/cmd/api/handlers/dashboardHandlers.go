@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"order-service/cmd/service"
 	"order-service/cmd/utils"
@@ -52,9 +52,9 @@ func (h *DashBoardHandler) GetDashboardInfo(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	dashboardInfo, err := h.DashBoardService.GetDashboardInfo(storeId, startDate, endDate)
+	dashboardInfo, err := h.DashBoardService.GetDashboardInfo(r.Context(), storeId, startDate, endDate)
 	if err != nil {
-		log.Println("Error getting dashboard info:", err)
+		slog.ErrorContext(r.Context(), "Error getting dashboard info", "store_id", storeId, "error", err)
 		_ = utils.ErrorJSON(w, errors.New("failed to get dashboard info"))
 		return
 	}
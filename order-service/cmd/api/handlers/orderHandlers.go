@@ -31,7 +31,7 @@ func (orderHandler *OrderHandler) AddNewOrder(w http.ResponseWriter, r *http.Req
 	}
 
 	// give order item response from service layer
-	orderResponse, err := orderHandler.OrderService.AddNewOrder(storeId, &orderRequest)
+	orderResponse, err := orderHandler.OrderService.AddNewOrder(r.Context(), storeId, &orderRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -55,7 +55,7 @@ func (orderHandler *OrderHandler) GetAllOrder(w http.ResponseWriter, r *http.Req
 		return
 	}
 	// get Order response from service layer
-	orderResponse, err := orderHandler.OrderService.GetAllOrder(utils.ItoS(storeId))
+	orderResponse, err := orderHandler.OrderService.GetAllOrder(r.Context(), utils.ItoS(storeId))
 	if err != nil {
 		if err.Error() == "no orders found" {
 			_ = utils.ErrorJSON(w, errors.New("no orders found"), http.StatusNotFound)
@@ -82,7 +82,7 @@ func (orderHandler *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http
 	}
 
 	// get Order item response from service layer
-	orderDetailsResponse, err := orderHandler.OrderService.GetOrderDetails(utils.ItoS(orderId))
+	orderDetailsResponse, err := orderHandler.OrderService.GetOrderDetails(r.Context(), utils.ItoS(orderId))
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -104,7 +104,7 @@ func (orderHandler *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Reques
 	}
 
 	// get Order item response from service layer
-	orderResponse, err := orderHandler.OrderService.GetOrder(utils.ItoS(orderId))
+	orderResponse, err := orderHandler.OrderService.GetOrder(r.Context(), utils.ItoS(orderId))
 	if err != nil {
 		_ = utils.ErrorJSON(w, errors.New("order not found"), 404)
 		return
@@ -136,7 +136,7 @@ func (orderHandler *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *ht
 		_ = utils.ErrorJSON(w, errors.New("invalid status: "+status), http.StatusBadRequest)
 		return
 	}
-	err = orderHandler.OrderService.ChangeOrderStatus(orderId, status)
+	err = orderHandler.OrderService.ChangeOrderStatus(r.Context(), orderId, status)
 	if err != nil {
 
 		_ = utils.ErrorJSON(w, err, http.StatusNotModified)
@@ -167,7 +167,7 @@ func (orderHandler *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Req
 		return
 	}
 	// get Order response from service layer
-	err = orderHandler.OrderService.UpdateOrder(orderId, orderRequest)
+	err = orderHandler.OrderService.UpdateOrder(r.Context(), orderId, orderRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -190,7 +190,7 @@ func (orderHandler *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Req
 	}
 
 	// get Order item response from service layer
-	err = orderHandler.OrderService.DeleteOrder(utils.ItoS(orderId))
+	err = orderHandler.OrderService.DeleteOrder(r.Context(), utils.ItoS(orderId))
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
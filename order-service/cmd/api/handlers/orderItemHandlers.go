@@ -15,6 +15,21 @@ type OrderItemsHandler struct {
 func NewOrderItemsHandler(orderItemService *service.OrderItemService) *OrderItemsHandler {
 	return &OrderItemsHandler{OrderItemService: orderItemService}
 }
+
+// StoreOf finds the store of the {order_id} and {item_id} in the path, for identity.RequireStoreOf.
+func (orderItemsHandler *OrderItemsHandler) StoreOf(r *http.Request) (int, error) {
+	orderId, err := utils.GetID(r, "order_id")
+	if err != nil {
+		return 0, err
+	}
+	itemId, err := utils.GetID(r, "item_id")
+	if err != nil {
+		return 0, err
+	}
+	storeID, err := orderItemsHandler.OrderItemService.StoreOfOrderItem(r.Context(), orderId, itemId)
+	return int(storeID), err
+}
+
 func (orderItemsHandler *OrderItemsHandler) AddOrderItem(w http.ResponseWriter, r *http.Request) {
 	// get order_id from Query parameter
 	orderId, err := utils.GetID(r, "order_id")
@@ -31,7 +46,7 @@ func (orderItemsHandler *OrderItemsHandler) AddOrderItem(w http.ResponseWriter,
 	}
 
 	// give order item response from service layer
-	orderItemResponse, err := orderItemsHandler.OrderItemService.AddOrderItem(orderId, &orderItemRequest)
+	orderItemResponse, err := orderItemsHandler.OrderItemService.AddOrderItem(r.Context(), orderId, &orderItemRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -55,7 +70,7 @@ func (orderItemsHandler *OrderItemsHandler) GetAllOrderItems(w http.ResponseWrit
 		return
 	}
 	// get Order item response from service layer
-	orderItemsResponse, err := orderItemsHandler.OrderItemService.GetAllOrderItems(utils.ItoS(orderId))
+	orderItemsResponse, err := orderItemsHandler.OrderItemService.GetAllOrderItems(r.Context(), utils.ItoS(orderId))
 	if err != nil {
 
 		_ = utils.ErrorJSON(w, err)
@@ -79,7 +94,7 @@ func (orderItemsHandler *OrderItemsHandler) GetOrderItem(w http.ResponseWriter,
 	}
 
 	// get Order item response from service layer
-	orderItemResponse, err := orderItemsHandler.OrderItemService.GetOrderItem(utils.ItoS(orderItemId))
+	orderItemResponse, err := orderItemsHandler.OrderItemService.GetOrderItem(r.Context(), utils.ItoS(orderItemId))
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -108,7 +123,7 @@ func (orderItemsHandler *OrderItemsHandler) UpdateOrderItem(w http.ResponseWrite
 		return
 	}
 	// get Order item response from service layer
-	err = orderItemsHandler.OrderItemService.UpdateOrderItem(utils.ItoS(orderItemId), orderItemRequest)
+	err = orderItemsHandler.OrderItemService.UpdateOrderItem(r.Context(), utils.ItoS(orderItemId), orderItemRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -130,7 +145,7 @@ func (orderItemsHandler *OrderItemsHandler) DeleteOrderItem(w http.ResponseWrite
 		return
 	}
 	// get Order item response from service layer
-	err = orderItemsHandler.OrderItemService.DeleteOrderItem(utils.ItoS(orderItemId))
+	err = orderItemsHandler.OrderItemService.DeleteOrderItem(r.Context(), utils.ItoS(orderItemId))
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
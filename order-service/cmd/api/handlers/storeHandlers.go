@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	apperrors "order-service/cmd/errors"
 	"order-service/cmd/model"
@@ -28,13 +28,12 @@ func (h *StoreHandler) CreateStore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the CreateStore method from the service layer
-	storeResponse, err := h.service.CreateStore(&storeRequest)
+	storeResponse, err := h.service.CreateStore(r.Context(), &storeRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
 	}
-	log.Printf("Store request created successfully: %+v", storeRequest)
-	log.Printf("Store created successfully: %+v", storeResponse)
+	slog.InfoContext(r.Context(), "Store created successfully", "store", storeResponse)
 	// Return the created store response
 	_ = utils.WriteJSON(w, http.StatusCreated, storeResponse)
 
@@ -49,12 +48,27 @@ func (h *StoreHandler) DeleteStore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Call the DeleteStore method from the service layer
-	err = h.service.DeleteStore(storeID)
+	err = h.service.DeleteStore(r.Context(), storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
 	}
-	log.Printf("Store deleted successfully")
+	slog.InfoContext(r.Context(), "Store deleted successfully", "store_id", storeID)
 	// Return a success response
 	_ = utils.WriteJSON(w, http.StatusNoContent, nil)
 }
+
+// RestoreStore restores an archived store by ID
+func (h *StoreHandler) RestoreStore(w http.ResponseWriter, r *http.Request) {
+	storeID, err := utils.GetID(r, "store_id")
+	if err != nil {
+		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid store id"))
+		return
+	}
+	if err := h.service.RestoreStore(r.Context(), storeID); err != nil {
+		_ = utils.ErrorJSON(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Store restored successfully", "store_id", storeID)
+	_ = utils.WriteJSON(w, http.StatusOK, map[string]uint{"id": storeID})
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"order-service/cmd/database"
+	"order-service/cmd/logging"
+	"order-service/cmd/tracing"
 
 	"gorm.io/gorm"
 )
@@ -17,11 +21,21 @@ type Config struct {
 }
 
 func main() {
-	log.Printf("Starting Order Service On Port %s...\n", webPort)
+	if err := logging.Setup("order-service"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.Info("Starting Order Service", "port", webPort)
+
+	shutdownTracing, err := tracing.Init(context.Background(), "order-service", serviceVersion())
+	if err != nil {
+		fatal(err)
+	}
+	defer shutdownTracing(context.Background())
 
 	db, err := database.New()
 	if err != nil {
-		log.Panic(err)
+		fatal(err)
 	}
 	err = db.SetupDatabase()
 	if err != nil {
@@ -37,8 +51,12 @@ func main() {
 
 	err = server.ListenAndServe()
 	if err != nil {
-		log.Panic(err)
-
+		fatal(err)
 	}
 
 }
+
+func fatal(err error) {
+	slog.Error("Order Service stopped", "error", err)
+	os.Exit(1)
+}
@@ -3,11 +3,17 @@ package main
 import (
 	"net/http"
 	"order-service/cmd/api/handlers"
+	"order-service/cmd/identity"
+	"order-service/cmd/logging"
+	"order-service/cmd/metrics"
 	"order-service/cmd/repository"
 	"order-service/cmd/service"
+	"order-service/cmd/tracing"
+	"order-service/cmd/utils"
+	"os"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"gorm.io/gorm"
 )
 
@@ -16,7 +22,19 @@ var db *gorm.DB
 func (app *Config) routes() http.Handler {
 	db = app.db
 	mux := chi.NewRouter()
+	mux.Use(middleware.SetHeader("X-Service-Version", serviceVersion()))
 	mux.Use(middleware.Heartbeat("/ping"))
+	mux.Use(tracing.Middleware)
+	mux.Use(metrics.Middleware)
+	mux.Use(middleware.RequestID)
+	mux.Use(utils.ExposeRequestID)
+	mux.Use(middleware.RealIP)
+	mux.Use(logging.Middleware)
+	mux.Use(middleware.Recoverer)
+	mux.Use(identity.NewVerifier().Middleware)
+	mux.NotFound(utils.NotFound)
+	mux.MethodNotAllowed(utils.MethodNotAllowed)
+	mux.Handle("/metrics", metrics.Handler(os.Getenv("METRICS_TOKEN")))
 	mux.Route("/orders/{order_id}/items", orderItems)
 	mux.Route("/stores/{store_id}/orders", order)
 	mux.Route("/stores/{store_id}/customers", customer)
@@ -25,14 +43,24 @@ func (app *Config) routes() http.Handler {
 
 	return mux
 }
+
+// serviceVersion is reported to the gateway's readiness checks
+func serviceVersion() string {
+	if version := os.Getenv("SERVICE_VERSION"); version != "" {
+		return version
+	}
+	return "dev"
+}
+
 func store(r chi.Router) {
 	storeRepo := repository.NewStoreRepository(db)
 	storeService := service.NewStoreService(storeRepo)
 	storeHandler := handlers.NewStoreHandler(storeService)
 
-	r.Post("/", storeHandler.CreateStore)
+	r.With(identity.RequireGateway).Post("/", storeHandler.CreateStore)
 	r.Route("/{store_id}", func(r chi.Router) {
-		r.Delete("/", storeHandler.DeleteStore)
+		r.With(identity.RequireStore).Delete("/", storeHandler.DeleteStore)
+		r.With(identity.RequireStore).Post("/restore", storeHandler.RestoreStore)
 	})
 }
 func order(r chi.Router) {
@@ -40,14 +68,19 @@ func order(r chi.Router) {
 	orderService := service.NewOrderService(orderRepo)
 	orderHandler := handlers.NewOrderHandler(orderService)
 
-	r.Post("/", orderHandler.AddNewOrder)
+	// Checkout is open to shoppers, so it only has to come through the gateway
+	r.With(identity.RequireGateway).Post("/", orderHandler.AddNewOrder)
 	r.Get("/", orderHandler.GetAllOrder)
 	r.Route("/{order_id}", func(r chi.Router) {
 		r.Get("/", orderHandler.GetOrder)
 		r.Get("/details", orderHandler.GetOrderDetails)
-		r.Put("/", orderHandler.UpdateOrder)
-		r.Put("/status/{status}", orderHandler.UpdateOrderStatus)
-		r.Delete("/", orderHandler.DeleteOrder)
+
+		r.Group(func(r chi.Router) {
+			r.Use(identity.RequireStore)
+			r.Put("/", orderHandler.UpdateOrder)
+			r.Put("/status/{status}", orderHandler.UpdateOrderStatus)
+			r.Delete("/", orderHandler.DeleteOrder)
+		})
 	})
 
 }
@@ -56,13 +89,14 @@ func orderItems(r chi.Router) {
 	orderItemService := service.NewOrderItemService(orderItemsRepo)
 	orderItemsHandler := handlers.NewOrderItemsHandler(orderItemService)
 
-	r.Post("/", orderItemsHandler.AddOrderItem)
+	r.With(identity.RequireGateway).Post("/", orderItemsHandler.AddOrderItem)
 	r.Get("/", orderItemsHandler.GetAllOrderItems)
 
 	r.Route("/{item_id}", func(r chi.Router) {
 		r.Get("/", orderItemsHandler.GetOrderItem)
-		r.Put("/", orderItemsHandler.UpdateOrderItem)
-		r.Delete("/", orderItemsHandler.DeleteOrderItem)
+		// The path does not name the store, so it is looked up through the item's order
+		r.With(identity.RequireStoreOf(orderItemsHandler.StoreOf)).Put("/", orderItemsHandler.UpdateOrderItem)
+		r.With(identity.RequireStoreOf(orderItemsHandler.StoreOf)).Delete("/", orderItemsHandler.DeleteOrderItem)
 
 	})
 
@@ -72,12 +106,12 @@ func customer(r chi.Router) {
 	customerService := service.NewCustomerService(customerrRepo)
 	customerHandler := handlers.NewCustomerHandler(customerService)
 
-	r.Post("/", customerHandler.CreateNewCustomer)
+	r.With(identity.RequireStore).Post("/", customerHandler.CreateNewCustomer)
 	r.Get("/", customerHandler.GetAllCustomers)
 	r.Route("/{customer_id}", func(r chi.Router) {
 		r.Get("/", customerHandler.GetCustomer)
 		//	r.Put("/", customerHandler.UpdateCustomer)
-		r.Delete("/", customerHandler.DeleteCustomer)
+		r.With(identity.RequireStore).Delete("/", customerHandler.DeleteCustomer)
 	})
 
 }
@@ -2,8 +2,9 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"order-service/cmd/model"
+	"order-service/cmd/tracing"
 	"os"
 	"time"
 
@@ -43,6 +44,9 @@ func openDB(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
 
@@ -53,18 +57,18 @@ func connectToDb() *gorm.DB {
 		db, err := openDB(dsn)
 
 		if err != nil {
-			log.Println("Postgres not yet ready...")
+			slog.Warn("Postgres not yet ready...", "error", err)
 			count++
 		} else {
-			log.Println("Connected to Postgres!")
+			slog.Info("Connected to Postgres!")
 			return db
 		}
 		if count > 9 {
-			log.Println(err)
+			slog.Error("Giving up connecting to Postgres", "error", err)
 			return nil
 		}
 
-		log.Println("Backing off two seconds")
+		slog.Info("Backing off two seconds")
 		time.Sleep(2 * time.Second)
 
 	}
@@ -0,0 +1,227 @@
+package identity
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	apperrors "order-service/cmd/errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// knownSignature is the signature the gateway's identity.Signer gives for the same fields.
+const knownSignature = "e1741847cfd2687c2fa4e3774f58e20423cb0d458df6c70f00899344530fb2bc"
+
+func TestSignatureMatchesGateway(t *testing.T) {
+	v := &Verifier{secret: []byte("test-secret")}
+	got := hex.EncodeToString(v.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000"))
+	if got != knownSignature {
+		t.Fatalf("signature = %s, want %s", got, knownSignature)
+	}
+}
+
+// signed returns a request to method and path carrying identity headers signed by v at signedAt.
+func signed(v *Verifier, method, path, userID, storeIDs string, signedAt time.Time) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	r.Header.Set(HeaderUserID, userID)
+	r.Header.Set(HeaderStoreIDs, storeIDs)
+	r.Header.Set(HeaderRequestID, "req-1")
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(v.signature(method, path, userID, storeIDs, "req-1", timestamp)))
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	v := &Verifier{secret: []byte("test-secret")}
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		request   func() *http.Request
+		wantErr   string
+		wantUser  int
+		wantStore []int
+	}{
+		{
+			name:      "valid",
+			request:   func() *http.Request { return signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now) },
+			wantUser:  42,
+			wantStore: []int{1, 2},
+		},
+		{
+			name:    "anonymous",
+			request: func() *http.Request { return signed(v, "GET", "/stores/1/orders", "", "", now) },
+		},
+		{
+			name: "replayed on another method",
+			request: func() *http.Request {
+				r := signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now)
+				r.Method = "DELETE"
+				return r
+			},
+			wantErr: "invalid identity signature",
+		},
+		{
+			name: "replayed on another path",
+			request: func() *http.Request {
+				r := signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now)
+				r.URL.Path = "/orders/8/items/3"
+				return r
+			},
+			wantErr: "invalid identity signature",
+		},
+		{
+			name: "store added by the caller",
+			request: func() *http.Request {
+				r := signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now)
+				r.Header.Set(HeaderStoreIDs, "1,2,3")
+				return r
+			},
+			wantErr: "invalid identity signature",
+		},
+		{
+			name: "other secret",
+			request: func() *http.Request {
+				return signed(&Verifier{secret: []byte("other")}, "PUT", "/orders/7/items/3", "42", "1,2", now)
+			},
+			wantErr: "invalid identity signature",
+		},
+		{
+			name: "expired",
+			request: func() *http.Request {
+				return signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now.Add(-maxAge-time.Minute))
+			},
+			wantErr: "identity signature expired",
+		},
+		{
+			name: "from the future",
+			request: func() *http.Request {
+				return signed(v, "PUT", "/orders/7/items/3", "42", "1,2", now.Add(maxAge+time.Minute))
+			},
+			wantErr: "identity signature expired",
+		},
+		{
+			name:    "invalid user id",
+			request: func() *http.Request { return signed(v, "PUT", "/orders/7/items/3", "abc", "1,2", now) },
+			wantErr: "invalid user id",
+		},
+		{
+			name:    "invalid store ids",
+			request: func() *http.Request { return signed(v, "PUT", "/orders/7/items/3", "42", "1,x", now) },
+			wantErr: "invalid store ids",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := v.verify(tt.request())
+			if tt.wantErr != "" {
+				var appErr apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Message != tt.wantErr {
+					t.Fatalf("verify error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify error = %v", err)
+			}
+			if id.UserID != tt.wantUser || !equalInts(id.StoreIDs, tt.wantStore) {
+				t.Fatalf("identity = %d %v, want %d %v", id.UserID, id.StoreIDs, tt.wantUser, tt.wantStore)
+			}
+		})
+	}
+}
+
+func TestForwardSignsTheOutgoingRequest(t *testing.T) {
+	v := &Verifier{secret: []byte("test-secret")}
+	id, err := v.verify(signed(v, "POST", "/stores/1/orders", "42", "1", time.Now()))
+	if err != nil {
+		t.Fatalf("verify error = %v", err)
+	}
+	ctx := context.WithValue(context.Background(), contextKey{}, id)
+
+	out := httptest.NewRequest(http.MethodPost, "http://product-service/verify-order", nil)
+	Forward(ctx, out)
+
+	forwarded, err := v.verify(out)
+	if err != nil {
+		t.Fatalf("forwarded request does not verify: %v", err)
+	}
+	if forwarded.UserID != 42 || !equalInts(forwarded.StoreIDs, []int{1}) || forwarded.RequestID != "req-1" {
+		t.Fatalf("forwarded identity = %+v", forwarded)
+	}
+}
+
+func TestRequireStoreOf(t *testing.T) {
+	lookup := func(storeID int, err error) func(*http.Request) (int, error) {
+		return func(*http.Request) (int, error) { return storeID, err }
+	}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		storeOf  func(*http.Request) (int, error)
+		want     int
+	}{
+		{"own store", &Identity{UserID: 1, StoreIDs: []int{1, 2}}, lookup(2, nil), http.StatusOK},
+		{"other store", &Identity{UserID: 1, StoreIDs: []int{1}}, lookup(2, nil), http.StatusForbidden},
+		{"not signed", nil, lookup(1, nil), http.StatusUnauthorized},
+		{"item not found", &Identity{UserID: 1, StoreIDs: []int{1}}, lookup(0, apperrors.NewNotFoundError("order item not found")), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/orders/7/items/3", nil)
+			if tt.identity != nil {
+				r = r.WithContext(context.WithValue(r.Context(), contextKey{}, *tt.identity))
+			}
+			w := httptest.NewRecorder()
+			RequireStoreOf(tt.storeOf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		storeID string
+		want    int
+	}{
+		{"own store", "1", http.StatusOK},
+		{"other store", "2", http.StatusForbidden},
+		{"not a number", "abc", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.With(RequireStore).Delete("/stores/{store_id}", func(w http.ResponseWriter, r *http.Request) {})
+
+			r := httptest.NewRequest(http.MethodDelete, "/stores/"+tt.storeID, nil)
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, Identity{UserID: 1, StoreIDs: []int{1}}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"order-service/cmd/model"
@@ -42,9 +43,9 @@ func AddStoreCustomer(storeCustomer *model.StoreCustomer, tx *gorm.DB) (bool, er
 }
 
 // CreateCustomer inserts a new customer into the database
-func (r *CustomerRepository) CreateCustomer(customer *model.Customer, storeID uint) error {
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer *model.Customer, storeID uint) error {
 	// start transaction
-	tx := r.db.Begin()
+	tx := r.db.WithContext(ctx).Begin()
 	// Defer rollback if transaction fails
 	defer func() {
 		if r := recover(); r != nil {
@@ -70,9 +71,9 @@ func (r *CustomerRepository) CreateCustomer(customer *model.Customer, storeID ui
 }
 
 // GetStoreCustomerWithOrders retrieves a customer by ID along with their orders
-func (r *CustomerRepository) GetStoreCustomerWithOrders(storeCustomer *model.StoreCustomer) (*model.Customer, error) {
+func (r *CustomerRepository) GetStoreCustomerWithOrders(ctx context.Context, storeCustomer *model.StoreCustomer) (*model.Customer, error) {
 	var customer *model.Customer
-	err := r.db.Preload("Orders", "store_id = ?", storeCustomer.StoreID).First(&customer, storeCustomer.CustomerID).
+	err := r.db.WithContext(ctx).Preload("Orders", "store_id = ?", storeCustomer.StoreID).First(&customer, storeCustomer.CustomerID).
 		Error
 	if err != nil {
 		return nil, err
@@ -82,26 +83,26 @@ func (r *CustomerRepository) GetStoreCustomerWithOrders(storeCustomer *model.Sto
 }
 
 // GetStoreCustomerByEmail retrieves a customer using their email with their orders
-func (r *CustomerRepository) GetStoreCustomerByEmail(customer *model.Customer, storeID uint) error {
+func (r *CustomerRepository) GetStoreCustomerByEmail(ctx context.Context, customer *model.Customer, storeID uint) error {
 
-	return r.db.Preload("Orders", "store_id = ?", storeID).Where("email = ?", customer.Email).First(&customer).Error
+	return r.db.WithContext(ctx).Preload("Orders", "store_id = ?", storeID).Where("email = ?", customer.Email).First(&customer).Error
 
 }
 
 // GetStoreCustomers retrieves all customers linked to a specific store
-func (r *CustomerRepository) GetStoreCustomers(storeID uint) ([]model.StoreCustomerItem, error) {
+func (r *CustomerRepository) GetStoreCustomers(ctx context.Context, storeID uint) ([]model.StoreCustomerItem, error) {
 	storeCustomerItems := []model.StoreCustomerItem{}
 
 	// Query to retrieve customers and their order statistics for a specific store
-	query := r.db.Model(&model.Customer{}).
+	query := r.db.WithContext(ctx).Model(&model.Customer{}).
 		Select(`
             customers.id as customer_id, 
             customers.email as customer_email,  
             COUNT(orders.id) as number_of_orders, 
             COALESCE(SUM(orders.total_price), 0) as total_spent
         `).
-		Joins("JOIN store_customers ON store_customers.customer_id = customers.id").
-		Joins("LEFT JOIN orders ON orders.customer_id = customers.id AND orders.store_id = ?", storeID).
+		Joins("JOIN store_customers ON store_customers.customer_id = customers.id AND store_customers.deleted_at IS NULL").
+		Joins("LEFT JOIN orders ON orders.customer_id = customers.id AND orders.store_id = ? AND orders.deleted_at IS NULL", storeID).
 		Where("store_customers.store_id = ?", storeID).
 		Group("customers.id")
 
@@ -114,16 +115,16 @@ func (r *CustomerRepository) GetStoreCustomers(storeID uint) ([]model.StoreCusto
 }
 
 // FindStoreCustomer Find Store Customer retrieves rows Affected
-func (r *CustomerRepository) FindStoreCustomer(storeCustomer *model.StoreCustomer) (int64, error) {
+func (r *CustomerRepository) FindStoreCustomer(ctx context.Context, storeCustomer *model.StoreCustomer) (int64, error) {
 
-	result := r.db.Where("store_id = ? AND customer_id = ?", storeCustomer.StoreID, storeCustomer.CustomerID).Find(storeCustomer)
+	result := r.db.WithContext(ctx).Where("store_id = ? AND customer_id = ?", storeCustomer.StoreID, storeCustomer.CustomerID).Find(storeCustomer)
 	return result.RowsAffected, result.Error
 }
 
 // DeleteStoreCustomer removes a relationship between a store and a customer
-func (r *CustomerRepository) DeleteStoreCustomer(storeCustomer *model.StoreCustomer) error {
+func (r *CustomerRepository) DeleteStoreCustomer(ctx context.Context, storeCustomer *model.StoreCustomer) error {
 	// Start a transaction
-	tx := r.db.Begin()
+	tx := r.db.WithContext(ctx).Begin()
 
 	// Defer rollback if transaction fails
 	defer func() {
@@ -155,13 +156,13 @@ func (r *CustomerRepository) DeleteStoreCustomer(storeCustomer *model.StoreCusto
 }
 
 // FindCustomer Find Customer By ID retrieves rows Affected
-func (r *CustomerRepository) FindCustomer(customer *model.Customer) (int64, error) {
+func (r *CustomerRepository) FindCustomer(ctx context.Context, customer *model.Customer) (int64, error) {
 
-	result := r.db.Find(customer, customer.ID)
+	result := r.db.WithContext(ctx).Find(customer, customer.ID)
 	return result.RowsAffected, result.Error
 }
 
 // DeleteCustomer removes a customer by ID
-func (r *CustomerRepository) DeleteCustomer(customer *model.Customer) error {
-	return r.db.Unscoped().Delete(customer, customer.ID).Error
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, customer *model.Customer) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(customer, customer.ID).Error
 }
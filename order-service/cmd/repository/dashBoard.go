@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"order-service/cmd/model"
 	"time"
 
@@ -14,14 +15,14 @@ type DashBoardRepository struct {
 func NewDashBoardRepository(db *gorm.DB) *DashBoardRepository {
 	return &DashBoardRepository{db: db}
 }
-func (d *DashBoardRepository) GetMonthlySales(storeID uint) ([]model.MonthlySales, error) {
+func (d *DashBoardRepository) GetMonthlySales(ctx context.Context, storeID uint) ([]model.MonthlySales, error) {
 	monthlySales := []model.MonthlySales{}
 
 	createdAt := time.Now().AddDate(0, -12, 0)
 
-	result := d.db.Table("orders").
+	result := d.db.WithContext(ctx).Table("orders").
 		Select("TO_CHAR(created_at, 'YYYY-MM') as month, COALESCE(SUM(total_price), 0) as sales").
-		Where("store_id = ? AND created_at >= ? AND status != ?", storeID, createdAt, "cancelled").
+		Where("store_id = ? AND created_at >= ? AND status != ? AND deleted_at IS NULL", storeID, createdAt, "cancelled").
 		Group("month").
 		Order("month").
 		Scan(&monthlySales)
@@ -32,10 +33,10 @@ func (d *DashBoardRepository) GetMonthlySales(storeID uint) ([]model.MonthlySale
 
 	return monthlySales, nil
 }
-func (d *DashBoardRepository) GetLatestOrders(storeID uint) ([]model.Order, error) {
+func (d *DashBoardRepository) GetLatestOrders(ctx context.Context, storeID uint) ([]model.Order, error) {
 	var latestOrders []model.Order
-	result := d.db.Table("orders").
-		Where("store_id = ? AND status != 'cancelled'", storeID).
+	result := d.db.WithContext(ctx).Table("orders").
+		Where("store_id = ? AND status != 'cancelled' AND deleted_at IS NULL", storeID).
 		Order("created_at DESC").
 		Limit(7).
 		Find(&latestOrders)
@@ -44,10 +45,10 @@ func (d *DashBoardRepository) GetLatestOrders(storeID uint) ([]model.Order, erro
 	}
 	return latestOrders, nil
 }
-func (d *DashBoardRepository) GetLatestCustomers(storeID uint) ([]model.CustomerDashboardResponse, error) {
+func (d *DashBoardRepository) GetLatestCustomers(ctx context.Context, storeID uint) ([]model.CustomerDashboardResponse, error) {
 	latestCustomers := []model.CustomerDashboardResponse{}
 
-	result := d.db.Table("customers").
+	result := d.db.WithContext(ctx).Table("customers").
 		Select(`
             customers.id AS customer_id,
             customers.email AS customer_name,
@@ -55,8 +56,8 @@ func (d *DashBoardRepository) GetLatestCustomers(storeID uint) ([]model.Customer
             COALESCE(SUM(orders.total_price), 0) AS total_spent,
             TO_CHAR(customers.created_at, 'YYYY-MM') AS join_date
         `).
-		Joins("JOIN store_customers ON store_customers.customer_id = customers.id").
-		Joins("LEFT JOIN orders ON orders.customer_id = customers.id AND orders.store_id = ?", storeID).
+		Joins("JOIN store_customers ON store_customers.customer_id = customers.id AND store_customers.deleted_at IS NULL").
+		Joins("LEFT JOIN orders ON orders.customer_id = customers.id AND orders.store_id = ? AND orders.deleted_at IS NULL", storeID).
 		Where("store_customers.store_id = ?", storeID).
 		Group("customers.id, customers.email, customers.created_at").
 		Order("customers.created_at DESC").
@@ -68,12 +69,12 @@ func (d *DashBoardRepository) GetLatestCustomers(storeID uint) ([]model.Customer
 	return latestCustomers, nil
 }
 
-func (r *DashBoardRepository) GetStoreSummary(storeID uint, startDate, endDate time.Time) (*model.Summary, string, error) {
+func (r *DashBoardRepository) GetStoreSummary(ctx context.Context, storeID uint, startDate, endDate time.Time) (*model.Summary, string, error) {
 	var summary model.Summary
 
 	// Get store slug
 	var storeSlug string
-	if err := r.db.Model(&model.Store{}).
+	if err := r.db.WithContext(ctx).Model(&model.Store{}).
 		Select("slug").
 		Where("id = ?", storeID).
 		Scan(&storeSlug).Error; err != nil {
@@ -81,7 +82,7 @@ func (r *DashBoardRepository) GetStoreSummary(storeID uint, startDate, endDate t
 	}
 
 	// Current period totals - consider only non-cancelled orders
-	if err := r.db.Model(&model.Order{}).
+	if err := r.db.WithContext(ctx).Model(&model.Order{}).
 		Where("store_id = ? AND status != ?", storeID, "cancelled").
 		Count(&summary.TotalOrders).
 		Select("COALESCE(SUM(total_price), 0)").
@@ -97,7 +98,7 @@ func (r *DashBoardRepository) GetStoreSummary(storeID uint, startDate, endDate t
 
 	var prevRevenue float64
 	var prevOrders int64
-	if err := r.db.Model(&model.Order{}).
+	if err := r.db.WithContext(ctx).Model(&model.Order{}).
 		Where("store_id = ? AND created_at BETWEEN ? AND ? AND status != ?",
 			storeID, prevStart, prevEnd, "cancelled").
 		Count(&prevOrders).
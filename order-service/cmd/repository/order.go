@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"order-service/cmd/model"
 
 	"gorm.io/gorm"
@@ -14,16 +15,16 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-func (r *OrderRepository) GetOrder(order *model.Order, id string) error {
-	return r.db.Preload("Customer").Preload("Store").First(order, id).Error
+func (r *OrderRepository) GetOrder(ctx context.Context, order *model.Order, id string) error {
+	return r.db.WithContext(ctx).Preload("Customer").Preload("Store").First(order, id).Error
 }
 
-func (r *OrderRepository) GetOrderDetails(order *model.Order, id string) error {
-	return r.db.Preload("OrderItems").Preload("Customer").Preload("Store").Preload("StatusHistory").First(order, id).Error
+func (r *OrderRepository) GetOrderDetails(ctx context.Context, order *model.Order, id string) error {
+	return r.db.WithContext(ctx).Preload("OrderItems").Preload("Customer").Preload("Store").Preload("StatusHistory").First(order, id).Error
 }
-func (r *OrderRepository) GetAllOrder(id string) ([]model.Order, error) {
+func (r *OrderRepository) GetAllOrder(ctx context.Context, id string) ([]model.Order, error) {
 	var orders []model.Order
-	err := r.db.Preload("Customer").Preload("Store").Where("store_id = ?", id).Find(&orders).Error
+	err := r.db.WithContext(ctx).Preload("Customer").Preload("Store").Where("store_id = ?", id).Find(&orders).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,10 +32,10 @@ func (r *OrderRepository) GetAllOrder(id string) ([]model.Order, error) {
 	return orders, nil
 }
 
-func (r *OrderRepository) AddOrder(storeId uint, orderRequest *model.OrderRequestDetails) (*model.Order, error) {
+func (r *OrderRepository) AddOrder(ctx context.Context, storeId uint, orderRequest *model.OrderRequestDetails) (*model.Order, error) {
 
 	// start transaction
-	tx := r.db.Begin()
+	tx := r.db.WithContext(ctx).Begin()
 	// Defer rollback if transaction fails
 	defer func() {
 		if r := recover(); r != nil {
@@ -86,9 +87,9 @@ func (r *OrderRepository) AddOrder(storeId uint, orderRequest *model.OrderReques
 	return order, nil
 }
 
-func (r *OrderRepository) ChangeOrderStatus(order *model.Order, newStatus string) error {
+func (r *OrderRepository) ChangeOrderStatus(ctx context.Context, order *model.Order, newStatus string) error {
 	// start transaction
-	tx := r.db.Begin()
+	tx := r.db.WithContext(ctx).Begin()
 	// Defer rollback if transaction fails
 	defer func() {
 		if r := recover(); r != nil {
@@ -113,19 +114,19 @@ func (r *OrderRepository) ChangeOrderStatus(order *model.Order, newStatus string
 	return nil
 }
 
-func (r *OrderRepository) UpdateOrder(orderRequest *model.OrderRequest, id uint) error {
-	return r.db.Model(&model.Order{}).Where("id = ?", id).Updates(orderRequest).Error
+func (r *OrderRepository) UpdateOrder(ctx context.Context, orderRequest *model.OrderRequest, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.Order{}).Where("id = ?", id).Updates(orderRequest).Error
 }
-func (r *OrderRepository) FindOrder(order *model.Order, id string) (int64, error) {
+func (r *OrderRepository) FindOrder(ctx context.Context, order *model.Order, id string) (int64, error) {
 
-	result := r.db.Preload("OrderItems").Find(order, id)
+	result := r.db.WithContext(ctx).Preload("OrderItems").Find(order, id)
 	return result.RowsAffected, result.Error
 }
-func (r *OrderRepository) IsOrderExist(order *model.Order, id string) (int64, error) {
+func (r *OrderRepository) IsOrderExist(ctx context.Context, order *model.Order, id string) (int64, error) {
 
-	result := r.db.First(order, id)
+	result := r.db.WithContext(ctx).First(order, id)
 	return result.RowsAffected, result.Error
 }
-func (r *OrderRepository) DeleteOrder(order *model.Order) error {
-	return r.db.Unscoped().Delete(order).Error
+func (r *OrderRepository) DeleteOrder(ctx context.Context, order *model.Order) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(order).Error
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"order-service/cmd/model"
 
 	"gorm.io/gorm"
@@ -16,13 +17,13 @@ func NewOrderItemRepository(db *gorm.DB) *OrderItemRepository {
 
 // Getters for each Model  using ID or Email
 
-func (r *OrderItemRepository) GetOrderItem(item *model.OrderItem, id string) error {
-	return r.db.First(item, id).Error
+func (r *OrderItemRepository) GetOrderItem(ctx context.Context, item *model.OrderItem, id string) error {
+	return r.db.WithContext(ctx).First(item, id).Error
 }
-func (r *OrderItemRepository) GetAllOrderItems(orderId string) ([]model.OrderItem, error) {
+func (r *OrderItemRepository) GetAllOrderItems(ctx context.Context, orderId string) ([]model.OrderItem, error) {
 
 	var order model.Order
-	err := r.db.Preload("OrderItems").First(&order, orderId).Error
+	err := r.db.WithContext(ctx).Preload("OrderItems").First(&order, orderId).Error
 	if err != nil {
 
 		return nil, err
@@ -36,21 +37,34 @@ func CreateOrderItem(orderItem *model.OrderItem, tx *gorm.DB) error {
 	return tx.Create(orderItem).Error
 }
 
-func (r *OrderItemRepository) AddOrderItem(orderItem *model.OrderItem) error {
-	return r.db.Create(orderItem).Error
+func (r *OrderItemRepository) AddOrderItem(ctx context.Context, orderItem *model.OrderItem) error {
+	return r.db.WithContext(ctx).Create(orderItem).Error
 }
 
 // update functions for each model
 
-func (r *OrderItemRepository) UpdateOrderItem(item *model.OrderItemRequest, id string) error {
-	return r.db.Model(&model.OrderItem{}).Where("id = ?", id).Updates(item).Error
+func (r *OrderItemRepository) UpdateOrderItem(ctx context.Context, item *model.OrderItemRequest, id string) error {
+	return r.db.WithContext(ctx).Model(&model.OrderItem{}).Where("id = ?", id).Updates(item).Error
 }
 
-func (r *OrderItemRepository) FindOrderItem(orderItem *model.OrderItem, id string) (int64, error) {
+func (r *OrderItemRepository) FindOrderItem(ctx context.Context, orderItem *model.OrderItem, id string) (int64, error) {
 
-	result := r.db.Find(orderItem, id)
+	result := r.db.WithContext(ctx).Find(orderItem, id)
 	return result.RowsAffected, result.Error
 }
-func (r *OrderItemRepository) DeleteOrderItem(orderItem *model.OrderItem) error {
-	return r.db.Unscoped().Delete(orderItem).Error
+
+// GetOrderItemStoreID returns the store of the order the item belongs to, or gorm.ErrRecordNotFound
+// when the item is not part of that order.
+func (r *OrderItemRepository) GetOrderItemStoreID(ctx context.Context, orderId, itemId uint) (uint, error) {
+	var row struct{ StoreID uint }
+	err := r.db.WithContext(ctx).Model(&model.OrderItem{}).
+		Select("orders.store_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.id = ? AND order_items.order_id = ?", itemId, orderId).
+		Take(&row).Error
+	return row.StoreID, err
+}
+
+func (r *OrderItemRepository) DeleteOrderItem(ctx context.Context, orderItem *model.OrderItem) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(orderItem).Error
 }
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"order-service/cmd/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,30 +17,75 @@ func NewStoreRepository(db *gorm.DB) *StoreRepository {
 }
 
 // create store to the database
-func (sr *StoreRepository) CreateStore(store *model.Store) error {
-	result := sr.db.Create(store)
+func (sr *StoreRepository) CreateStore(ctx context.Context, store *model.Store) error {
+	result := sr.db.WithContext(ctx).Create(store)
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
 
-// delete store from the database
-func (sr *StoreRepository) DeleteStore(storeID uint) error {
-	result := sr.db.Delete(&model.Store{}, storeID)
+// storeChildren are the tables archived along with their store. Order items and status history
+// are only reached through their orders, so they go with them.
+var storeChildren = []any{&model.Order{}, &model.StoreCustomer{}}
+
+// delete store from the database, archiving its orders and customer links with it. They all get the
+// store's deletion time, so restoring the store brings back what was deleted with it but not what
+// had been deleted before.
+func (sr *StoreRepository) DeleteStore(ctx context.Context, storeID uint) error {
+	// Postgres keeps microseconds, so the rows are matched up again by this exact value
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	return sr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Store{}).Where("id = ?", storeID).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, child := range storeChildren {
+			if err := tx.Model(child).Where("store_id = ?", storeID).Update("deleted_at", deletedAt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restore an archived store with what was archived along with it, and report whether it exists
+func (sr *StoreRepository) RestoreStore(ctx context.Context, storeID uint) (bool, error) {
+	store := &model.Store{}
+	result := sr.db.WithContext(ctx).Unscoped().Where("id = ?", storeID).Limit(1).Find(store)
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
 	}
 	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+		return false, nil
 	}
-	return nil
+	if !store.DeletedAt.Valid {
+		return true, nil
+	}
+	deletedAt := store.DeletedAt.Time
+	return true, sr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(store).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		for _, child := range storeChildren {
+			err := tx.Unscoped().Model(child).
+				Where("store_id = ? AND deleted_at = ?", storeID, deletedAt).
+				Update("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // get store by id from the database
-func (sr *StoreRepository) GetStoreByID(storeID uint) (*model.Store, error) {
+func (sr *StoreRepository) GetStoreByID(ctx context.Context, storeID uint) (*model.Store, error) {
 	result := &model.Store{}
-	err := sr.db.First(result, storeID).Error
+	err := sr.db.WithContext(ctx).First(result, storeID).Error
 	if err != nil {
 		return nil, err
 	}
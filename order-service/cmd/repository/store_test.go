@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"order-service/cmd/database"
+	"order-service/cmd/model"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// openTestDatabase connects to the Postgres database named by TEST_DATABASE_DSN and migrates it.
+// Tests that need one are skipped when it is not set.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	if err := (&database.Database{DB: db}).SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDeleteStoreArchivesItsOrders(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDatabase(t)
+	stores := NewStoreRepository(db)
+	orders := NewOrderRepository(db)
+	customers := NewCustomerRepository(db)
+	items := NewOrderItemRepository(db)
+
+	storeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	customer := model.Customer{Email: fmt.Sprintf("archived-%d@example.com", storeID)}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM order_items WHERE order_id IN (SELECT id FROM orders WHERE store_id = ?)", storeID)
+		db.Exec("DELETE FROM orders WHERE store_id = ?", storeID)
+		db.Exec("DELETE FROM store_customers WHERE store_id = ?", storeID)
+		db.Exec("DELETE FROM customers WHERE id = ?", customer.ID)
+		db.Exec("DELETE FROM stores WHERE id = ?", storeID)
+	})
+
+	if err := stores.CreateStore(ctx, &model.Store{ID: storeID, Name: "Archived", Slug: "archived"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&customer).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.StoreCustomer{StoreID: storeID, CustomerID: customer.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	newOrder := func() *model.Order {
+		return &model.Order{StoreID: storeID, CustomerID: customer.ID, TotalPrice: 10, CustomerName: "Sara",
+			PhoneNumber: "0100", Address: "Nile St", PaymentMethod: "cash", City: "Cairo", ShippingMethod: "standard",
+			OrderItems: []model.OrderItem{{SkuID: 1, Price: 10, Quantity: 1}}}
+	}
+	live, removed := newOrder(), newOrder()
+	for _, order := range []*model.Order{live, removed} {
+		if err := db.Omit("Customer", "Store").Create(order).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An order deleted before the store must stay deleted when the store is restored
+	if err := db.Omit(clause.Associations).Delete(removed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.DeleteStore(ctx, storeID); err != nil {
+		t.Fatal(err)
+	}
+
+	id := strconv.FormatUint(uint64(storeID), 10)
+	if got, err := orders.GetAllOrder(ctx, id); err != nil || len(got) != 0 {
+		t.Fatalf("GetAllOrder of a deleted store = %d orders, %v; want none", len(got), err)
+	}
+	if got, err := customers.GetStoreCustomers(ctx, storeID); err != nil || len(got) != 0 {
+		t.Fatalf("GetStoreCustomers of a deleted store = %d customers, %v; want none", len(got), err)
+	}
+	if _, err := items.GetOrderItemStoreID(ctx, live.ID, live.OrderItems[0].ID); err == nil {
+		t.Fatal("GetOrderItemStoreID found an item of a deleted store")
+	}
+
+	if found, err := stores.RestoreStore(ctx, storeID); err != nil || !found {
+		t.Fatalf("RestoreStore = %t, %v", found, err)
+	}
+
+	got, err := orders.GetAllOrder(ctx, id)
+	if err != nil || len(got) != 1 || got[0].ID != live.ID {
+		t.Fatalf("GetAllOrder after restoring = %d orders, %v; want only order %d", len(got), err, live.ID)
+	}
+	linked, err := customers.GetStoreCustomers(ctx, storeID)
+	if err != nil || len(linked) != 1 || linked[0].NumberOfOrders != 1 {
+		t.Fatalf("GetStoreCustomers after restoring = %+v, %v; want the customer with one order", linked, err)
+	}
+}
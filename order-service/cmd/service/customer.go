@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"order-service/cmd/model"
 	"order-service/cmd/repository"
@@ -13,31 +14,31 @@ type CustomerService struct {
 func NewCustomerService(r *repository.CustomerRepository) *CustomerService {
 	return &CustomerService{CustomerRepo: r}
 }
-func (customerService *CustomerService) CreateNewCustomer(customerRequest *model.CustomerRequest, storeID uint) (*model.CustomerResponseInfo, error) {
+func (customerService *CustomerService) CreateNewCustomer(ctx context.Context, customerRequest *model.CustomerRequest, storeID uint) (*model.CustomerResponseInfo, error) {
 	//TODO: Verfiy Store ID Before Create New Customer From My Store Table If Not Existing Go To User Services
 
 	customer := customerRequest.CreateCustomer()
-	err := customerService.CustomerRepo.CreateCustomer(customer, storeID)
+	err := customerService.CustomerRepo.CreateCustomer(ctx, customer, storeID)
 	if err != nil {
 		return nil, err
 	}
 	customerResponse := customer.CreateCustomerResponseInfo()
 	return customerResponse, nil
 }
-func (customerService *CustomerService) GetAllCustomers(storeID uint) (*model.CustomersResponse, error) {
+func (customerService *CustomerService) GetAllCustomers(ctx context.Context, storeID uint) (*model.CustomersResponse, error) {
 
-	customers, err := customerService.CustomerRepo.GetStoreCustomers(storeID)
+	customers, err := customerService.CustomerRepo.GetStoreCustomers(ctx, storeID)
 	if err != nil {
 		return nil, err
 	}
 	customersResponse := model.GetCustomersResponse(customers)
 	return customersResponse, nil
 }
-func (customerService *CustomerService) GetCustomer(storeID, customerID uint) (*model.CustomerResponseDetails, error) {
+func (customerService *CustomerService) GetCustomer(ctx context.Context, storeID, customerID uint) (*model.CustomerResponseDetails, error) {
 	//TODO: Verfiy Store ID Before Create New Customer From My Store Table If not Existing ? Ask Robaa
 	storeCustomer := model.CreateStoreCustmer(storeID, customerID)
 	//  Validate the customer's relationship with the store
-	rowAffected, err := customerService.CustomerRepo.FindStoreCustomer(storeCustomer)
+	rowAffected, err := customerService.CustomerRepo.FindStoreCustomer(ctx, storeCustomer)
 	if err != nil {
 		return nil, err
 	} else if rowAffected == 0 {
@@ -45,7 +46,7 @@ func (customerService *CustomerService) GetCustomer(storeID, customerID uint) (*
 	}
 
 	//  Fetch the customer and their orders for the specified store
-	customer, err := customerService.CustomerRepo.GetStoreCustomerWithOrders(storeCustomer)
+	customer, err := customerService.CustomerRepo.GetStoreCustomerWithOrders(ctx, storeCustomer)
 	if err != nil {
 		return nil, err
 	}
@@ -55,17 +56,17 @@ func (customerService *CustomerService) GetCustomer(storeID, customerID uint) (*
 func (customerService *CustomerService) UpdateCustomer() {
 	//TODO: Update Customer Info
 }
-func (customerService *CustomerService) DeleteCustomer(storeID, customerID uint) error {
+func (customerService *CustomerService) DeleteCustomer(ctx context.Context, storeID, customerID uint) error {
 	storeCustomer := model.CreateStoreCustmer(storeID, customerID)
 	//  Validate the customer's relationship with the store
-	rowAffected, err := customerService.CustomerRepo.FindStoreCustomer(storeCustomer)
+	rowAffected, err := customerService.CustomerRepo.FindStoreCustomer(ctx, storeCustomer)
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
 		return errors.New("customer not found")
 	}
 	//  Delete the customer and their orders for the specified store
-	err = customerService.CustomerRepo.DeleteStoreCustomer(storeCustomer)
+	err = customerService.CustomerRepo.DeleteStoreCustomer(ctx, storeCustomer)
 	if err != nil {
 		return err
 	}
@@ -1,11 +1,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"order-service/cmd/model"
 	"order-service/cmd/repository"
-	"os"
 	"time"
 )
 
@@ -16,13 +15,10 @@ type OrderDashBoardService struct {
 
 func NewDashBoardService(dashBoardRepository *repository.DashBoardRepository) *OrderDashBoardService {
 	return &OrderDashBoardService{DashBoardRepository: dashBoardRepository,
-		ProductService: &ProductService{
-			ProductServiceURL: os.Getenv("PRODUCT_SERVICE_URL"),
-			client:            &http.Client{Timeout: 5 * time.Second},
-		},
+		ProductService: NewProductService(),
 	}
 }
-func (s *OrderDashBoardService) GetDashboardInfo(storeID uint, startDate, endDate time.Time) (*model.DashBoardResponse, error) {
+func (s *OrderDashBoardService) GetDashboardInfo(ctx context.Context, storeID uint, startDate, endDate time.Time) (*model.DashBoardResponse, error) {
 	if startDate.IsZero() {
 		startDate = time.Now()
 	}
@@ -31,27 +27,27 @@ func (s *OrderDashBoardService) GetDashboardInfo(storeID uint, startDate, endDat
 	}
 
 	// Get all required data
-	productsDashBoard, err := s.ProductService.GetStoreProductsDashboard(storeID, startDate, endDate)
+	productsDashBoard, err := s.ProductService.GetStoreProductsDashboard(ctx, storeID, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products dashboard: %w", err)
 	}
 
-	summary, storeSlug, err := s.DashBoardRepository.GetStoreSummary(storeID, startDate, endDate)
+	summary, storeSlug, err := s.DashBoardRepository.GetStoreSummary(ctx, storeID, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get store summary: %w", err)
 	}
 
-	monthlySales, err := s.DashBoardRepository.GetMonthlySales(storeID)
+	monthlySales, err := s.DashBoardRepository.GetMonthlySales(ctx, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monthly sales: %w", err)
 	}
 
-	latestOrders, err := s.DashBoardRepository.GetLatestOrders(storeID)
+	latestOrders, err := s.DashBoardRepository.GetLatestOrders(ctx, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest orders: %w", err)
 	}
 
-	latestCustomers, err := s.DashBoardRepository.GetLatestCustomers(storeID)
+	latestCustomers, err := s.DashBoardRepository.GetLatestCustomers(ctx, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest customers: %w", err)
 	}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"order-service/cmd/metrics"
 	"order-service/cmd/model"
 	"order-service/cmd/repository"
 	"order-service/cmd/utils"
@@ -20,41 +22,42 @@ type OrderService struct {
 
 func NewOrderService(r *repository.OrderRepository) *OrderService {
 	return &OrderService{
-		OrderRepo: r,
-		ProductService: &ProductService{
-			ProductServiceURL: os.Getenv("PRODUCT_SERVICE_URL"),
-			client:            &http.Client{Timeout: 5 * time.Second},
-		},
+		OrderRepo:      r,
+		ProductService: NewProductService(),
 		UserServiceURL: os.Getenv("USER_SERVICE_URL"),
 		client:         &http.Client{Timeout: 5 * time.Second},
 	}
 }
 
-func (s *OrderService) AddNewOrder(storeId uint, orderRequest *model.OrderRequestDetails) (*model.OrderResponse, error) {
-	err := s.ProductService.VerifyOrderItems(storeId, orderRequest.OrderItems)
+func (s *OrderService) AddNewOrder(ctx context.Context, storeId uint, orderRequest *model.OrderRequestDetails) (*model.OrderResponse, error) {
+	err := s.ProductService.VerifyOrderItems(ctx, storeId, orderRequest.OrderItems)
 	if err != nil {
+		metrics.OrderCreationFailures.WithLabelValues("verification").Inc()
 		return nil, err
 	}
 
 	// Payment Logic using payment gateway (To Be Implemented)
 
 	// Manage inventory
-	err = s.ProductService.UpdateInventory(orderRequest.OrderItems)
+	err = s.ProductService.UpdateInventory(ctx, orderRequest.OrderItems)
 	if err != nil {
+		metrics.OrderCreationFailures.WithLabelValues("inventory").Inc()
 		return nil, err
 	}
 
-	order, err := s.OrderRepo.AddOrder(storeId, orderRequest)
+	order, err := s.OrderRepo.AddOrder(ctx, storeId, orderRequest)
 	if err != nil {
+		metrics.OrderCreationFailures.WithLabelValues("storage").Inc()
 		return nil, err
 	}
+	metrics.OrdersCreated.Inc()
 	orderResponse := order.CreateOrderResponse()
 	return orderResponse, nil
 
 }
 
-func (s *OrderService) GetAllOrder(storeId string) (*model.OrdersResponse, error) {
-	orders, err := s.OrderRepo.GetAllOrder(storeId)
+func (s *OrderService) GetAllOrder(ctx context.Context, storeId string) (*model.OrdersResponse, error) {
+	orders, err := s.OrderRepo.GetAllOrder(ctx, storeId)
 	if err != nil {
 		return nil, err
 	}
@@ -70,9 +73,9 @@ func (s *OrderService) GetAllOrder(storeId string) (*model.OrdersResponse, error
 	return ordersResponse, nil
 }
 
-func (s *OrderService) GetOrderDetails(orderId string) (*model.OrderDetailsResponse, error) {
+func (s *OrderService) GetOrderDetails(ctx context.Context, orderId string) (*model.OrderDetailsResponse, error) {
 	var order model.Order
-	err := s.OrderRepo.GetOrderDetails(&order, orderId)
+	err := s.OrderRepo.GetOrderDetails(ctx, &order, orderId)
 	if err != nil {
 		return nil, err
 	}
@@ -80,16 +83,16 @@ func (s *OrderService) GetOrderDetails(orderId string) (*model.OrderDetailsRespo
 	// Create basic order details response
 	orderDetailsResponse := order.CreateOrderDetailsResponse()
 
-	err = s.ProductService.GetOrderItemDetails(orderDetailsResponse.StoreID, orderDetailsResponse.OrderItems)
+	err = s.ProductService.GetOrderItemDetails(ctx, orderDetailsResponse.StoreID, orderDetailsResponse.OrderItems)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order item details: %w", err)
 	}
 	return orderDetailsResponse, nil
 }
 
-func (s *OrderService) GetOrder(orderId string) (*model.OrderResponse, error) {
+func (s *OrderService) GetOrder(ctx context.Context, orderId string) (*model.OrderResponse, error) {
 	var order model.Order
-	err := s.OrderRepo.GetOrder(&order, orderId)
+	err := s.OrderRepo.GetOrder(ctx, &order, orderId)
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +101,9 @@ func (s *OrderService) GetOrder(orderId string) (*model.OrderResponse, error) {
 
 	return orderResponse, nil
 }
-func (s *OrderService) ChangeOrderStatus(orderId uint, newStatus string) error {
+func (s *OrderService) ChangeOrderStatus(ctx context.Context, orderId uint, newStatus string) error {
 	var order model.Order
-	rowAffected, err := s.OrderRepo.IsOrderExist(&order, utils.ItoS(orderId))
+	rowAffected, err := s.OrderRepo.IsOrderExist(ctx, &order, utils.ItoS(orderId))
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
@@ -110,23 +113,24 @@ func (s *OrderService) ChangeOrderStatus(orderId uint, newStatus string) error {
 	if !model.CanTransition(order.Status, newStatus) {
 		return errors.New("invalid status transition from " + order.Status + " to " + newStatus)
 	}
-	err = s.OrderRepo.ChangeOrderStatus(&order, newStatus)
+	err = s.OrderRepo.ChangeOrderStatus(ctx, &order, newStatus)
 	if err != nil {
 		return err
 	}
+	metrics.OrderStatusChanges.WithLabelValues(newStatus).Inc()
 
 	return nil
 }
 
-func (s *OrderService) UpdateOrder(orderId uint, orderRequest *model.OrderRequest) error {
+func (s *OrderService) UpdateOrder(ctx context.Context, orderId uint, orderRequest *model.OrderRequest) error {
 	var order model.Order
-	rowAffected, err := s.OrderRepo.FindOrder(&order, utils.ItoS(orderId))
+	rowAffected, err := s.OrderRepo.FindOrder(ctx, &order, utils.ItoS(orderId))
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
 		return errors.New("order not found")
 	}
-	err = s.OrderRepo.UpdateOrder(orderRequest, orderId)
+	err = s.OrderRepo.UpdateOrder(ctx, orderRequest, orderId)
 	if err != nil {
 		return err
 	}
@@ -134,16 +138,16 @@ func (s *OrderService) UpdateOrder(orderId uint, orderRequest *model.OrderReques
 	return nil
 }
 
-func (s *OrderService) DeleteOrder(orderId string) error {
+func (s *OrderService) DeleteOrder(ctx context.Context, orderId string) error {
 	// Delete order  from database by order id
 	var order model.Order
-	rowAffected, err := s.OrderRepo.FindOrder(&order, orderId)
+	rowAffected, err := s.OrderRepo.FindOrder(ctx, &order, orderId)
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
 		return errors.New("order not found")
 	}
-	err = s.OrderRepo.DeleteOrder(&order)
+	err = s.OrderRepo.DeleteOrder(ctx, &order)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
+	apperrors "order-service/cmd/errors"
 	"order-service/cmd/model"
 	"order-service/cmd/repository"
+
+	"gorm.io/gorm"
 )
 
 type OrderItemService struct {
@@ -15,13 +19,13 @@ func NewOrderItemService(orderItemRepo *repository.OrderItemRepository) *OrderIt
 	return &OrderItemService{OrderItemRepo: orderItemRepo}
 }
 
-func (s *OrderItemService) AddOrderItem(orderId uint, orderItemRequest *model.OrderItemRequest) (*model.OrderItemResponse, error) {
+func (s *OrderItemService) AddOrderItem(ctx context.Context, orderId uint, orderItemRequest *model.OrderItemRequest) (*model.OrderItemResponse, error) {
 
 	// mapping order item request into order Item Model and add to database
 	orderItem := orderItemRequest.CreateOrderItem(orderId)
-	err := s.OrderItemRepo.AddOrderItem(orderItem)
+	err := s.OrderItemRepo.AddOrderItem(ctx, orderItem)
 	if err != nil {
-		log.Println("Error, Creating orderItem in database")
+		slog.ErrorContext(ctx, "Error, Creating orderItem in database", "order_id", orderId, "error", err)
 		return nil, err
 	}
 
@@ -30,10 +34,10 @@ func (s *OrderItemService) AddOrderItem(orderId uint, orderItemRequest *model.Or
 	return orderItemResponse, nil
 }
 
-func (s *OrderItemService) GetAllOrderItems(orderId string) ([]model.OrderItemResponse, error) {
+func (s *OrderItemService) GetAllOrderItems(ctx context.Context, orderId string) ([]model.OrderItemResponse, error) {
 
 	// Query to Get all order item from database
-	orderItems, err := s.OrderItemRepo.GetAllOrderItems(orderId)
+	orderItems, err := s.OrderItemRepo.GetAllOrderItems(ctx, orderId)
 	if err != nil {
 
 		return nil, err
@@ -47,10 +51,10 @@ func (s *OrderItemService) GetAllOrderItems(orderId string) ([]model.OrderItemRe
 
 	return orderItemsResponse, nil
 }
-func (s *OrderItemService) GetOrderItem(orderItemId string) (*model.OrderItemResponse, error) {
+func (s *OrderItemService) GetOrderItem(ctx context.Context, orderItemId string) (*model.OrderItemResponse, error) {
 	// Query to Get order item from database
 	var orderItem model.OrderItem
-	err := s.OrderItemRepo.GetOrderItem(&orderItem, orderItemId)
+	err := s.OrderItemRepo.GetOrderItem(ctx, &orderItem, orderItemId)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +62,10 @@ func (s *OrderItemService) GetOrderItem(orderItemId string) (*model.OrderItemRes
 	orderItemResponse := orderItem.CreateOrderItemResponse()
 	return orderItemResponse, nil
 }
-func (s *OrderItemService) UpdateOrderItem(orderItemId string, orderItemRequest *model.OrderItemRequest) error {
+func (s *OrderItemService) UpdateOrderItem(ctx context.Context, orderItemId string, orderItemRequest *model.OrderItemRequest) error {
 
 	var orderItem model.OrderItem
-	rowAffected, err := s.OrderItemRepo.FindOrderItem(&orderItem, orderItemId)
+	rowAffected, err := s.OrderItemRepo.FindOrderItem(ctx, &orderItem, orderItemId)
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
@@ -72,23 +76,37 @@ func (s *OrderItemService) UpdateOrderItem(orderItemId string, orderItemRequest
 		return errors.New("can't change sku for item")
 	}
 
-	err = s.OrderItemRepo.UpdateOrderItem(orderItemRequest, orderItemId)
+	err = s.OrderItemRepo.UpdateOrderItem(ctx, orderItemRequest, orderItemId)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
-func (s *OrderItemService) DeleteOrderItem(orderItemId string) error {
+
+// StoreOfOrderItem returns the store that owns the order the item belongs to.
+func (s *OrderItemService) StoreOfOrderItem(ctx context.Context, orderId, itemId uint) (uint, error) {
+	storeID, err := s.OrderItemRepo.GetOrderItemStoreID(ctx, orderId, itemId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, apperrors.NewNotFoundError("order item not found")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error, Finding the store of order item", "order_id", orderId, "item_id", itemId, "error", err)
+		return 0, apperrors.NewInternalServerError("Failed to find order item")
+	}
+	return storeID, nil
+}
+
+func (s *OrderItemService) DeleteOrderItem(ctx context.Context, orderItemId string) error {
 	// Delete orderItem  from database by order id
 	var orderItem model.OrderItem
-	rowAffected, err := s.OrderItemRepo.FindOrderItem(&orderItem, orderItemId)
+	rowAffected, err := s.OrderItemRepo.FindOrderItem(ctx, &orderItem, orderItemId)
 	if err != nil {
 		return err
 	} else if rowAffected == 0 {
 		return errors.New("order item not found")
 	}
-	err = s.OrderItemRepo.DeleteOrderItem(&orderItem)
+	err = s.OrderItemRepo.DeleteOrderItem(ctx, &orderItem)
 	if err != nil {
 		return err
 	}
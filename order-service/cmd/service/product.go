@@ -2,19 +2,33 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"order-service/cmd/identity"
 	"order-service/cmd/model"
+	"order-service/cmd/tracing"
+	"os"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type ProductService struct {
 	ProductServiceURL string
 	client            *http.Client
 }
+
+func NewProductService() *ProductService {
+	return &ProductService{
+		ProductServiceURL: os.Getenv("PRODUCT_SERVICE_URL"),
+		client:            &http.Client{Transport: tracing.Transport(http.DefaultTransport), Timeout: 5 * time.Second},
+	}
+}
+
 type VerificationResponse struct {
 	Valid   bool           `json:"valid"`
 	Message string         `json:"messages"`
@@ -42,7 +56,7 @@ type SKUProductResponse struct {
 	ImgURL      string `json:"image_url"`
 }
 
-func (s *ProductService) VerifyOrderItems(storeID uint, items []model.OrderItemRequest) error {
+func (s *ProductService) VerifyOrderItems(ctx context.Context, storeID uint, items []model.OrderItemRequest) error {
 	verificationRequest := struct {
 		StoreID uint                     `json:"store_id"`
 		Items   []model.OrderItemRequest `json:"items"`
@@ -56,7 +70,7 @@ func (s *ProductService) VerifyOrderItems(storeID uint, items []model.OrderItemR
 		return err
 	}
 
-	resp, err := http.Post(s.ProductServiceURL+"/verify-order", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := s.send(ctx, http.MethodPost, "/verify-order", jsonData)
 	if err != nil {
 		return err
 	}
@@ -81,13 +95,13 @@ func (s *ProductService) VerifyOrderItems(storeID uint, items []model.OrderItemR
 	return nil
 }
 
-func (s *ProductService) UpdateInventory(items []model.OrderItemRequest) error {
+func (s *ProductService) UpdateInventory(ctx context.Context, items []model.OrderItemRequest) error {
 	jsonData, err := json.Marshal(items)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Post(s.ProductServiceURL+"/update-inventory", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := s.send(ctx, http.MethodPost, "/update-inventory", jsonData)
 	if err != nil {
 		return err
 	}
@@ -101,7 +115,7 @@ func (s *ProductService) UpdateInventory(items []model.OrderItemRequest) error {
 }
 
 // GetSkuDetails fetches detailed information about SKUs from product service
-func (s *ProductService) GetSkuDetails(storeID uint, skuIDs []uint) (*SKUsResponse, error) {
+func (s *ProductService) GetSkuDetails(ctx context.Context, storeID uint, skuIDs []uint) (*SKUsResponse, error) {
 	skusRequest := SKUsRequest{
 		IDs: skuIDs,
 	}
@@ -110,9 +124,9 @@ func (s *ProductService) GetSkuDetails(storeID uint, skuIDs []uint) (*SKUsRespon
 	if err != nil {
 		return nil, err
 	}
-	log.Println("GetSkuDetails request:", skusRequest)
+	slog.DebugContext(ctx, "GetSkuDetails request", "store_id", storeID, "sku_ids", skuIDs)
 
-	resp, err := http.Post(s.ProductServiceURL+fmt.Sprintf("/stores/%d/skus/info", storeID), "application/json", bytes.NewBuffer(jsonData))
+	resp, err := s.send(ctx, http.MethodPost, fmt.Sprintf("/stores/%d/skus/info", storeID), jsonData)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +147,7 @@ func (s *ProductService) GetSkuDetails(storeID uint, skuIDs []uint) (*SKUsRespon
 	return &skusResponse, nil
 
 }
-func (s *ProductService) GetOrderItemDetails(storeID uint, orderItems []model.OrderItemResponse) error {
+func (s *ProductService) GetOrderItemDetails(ctx context.Context, storeID uint, orderItems []model.OrderItemResponse) error {
 	// Check if orderItems is empty
 	if len(orderItems) == 0 {
 		return nil
@@ -145,7 +159,7 @@ func (s *ProductService) GetOrderItemDetails(storeID uint, orderItems []model.Or
 		skuIDs = append(skuIDs, item.SkuID)
 		skuIndexMap[item.SkuID] = i
 	}
-	skusResponse, err := s.GetSkuDetails(storeID, skuIDs)
+	skusResponse, err := s.GetSkuDetails(ctx, storeID, skuIDs)
 	if err != nil {
 		return err
 	}
@@ -160,9 +174,9 @@ func (s *ProductService) GetOrderItemDetails(storeID uint, orderItems []model.Or
 
 	return nil
 }
-func (s *ProductService) GetStoreProductsDashboard(storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
+func (s *ProductService) GetStoreProductsDashboard(ctx context.Context, storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
 
-	resp, err := http.Get(s.ProductServiceURL + fmt.Sprintf("/stores/%d/products/dashboard", storeID))
+	resp, err := s.send(ctx, http.MethodGet, fmt.Sprintf("/stores/%d/products/dashboard", storeID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -181,3 +195,19 @@ func (s *ProductService) GetStoreProductsDashboard(storeID uint, startDate, endD
 
 	return &productsDashboardResponse, nil
 }
+
+// send calls product-service with ctx so the caller's trace and signed identity continue there.
+func (s *ProductService) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.ProductServiceURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := middleware.GetReqID(ctx); id != "" {
+		req.Header.Set(middleware.RequestIDHeader, id)
+	}
+	identity.Forward(ctx, req)
+	return s.client.Do(req)
+}
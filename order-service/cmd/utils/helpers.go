@@ -3,19 +3,16 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	apperrors "order-service/cmd/errors"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-type jsonResponse struct {
-	Error   bool   `json:"error"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
-}
-
 func ReadJSON(w http.ResponseWriter, r *http.Request, data any) error {
 	maxBytes := 1048576 // one megabyte
 
@@ -55,16 +52,55 @@ func WriteJSON(w http.ResponseWriter, status int, data any, headers ...http.Head
 	return nil
 }
 
+// ErrorJSON answers with err as an RFC 7807 problem. The status is the one given, otherwise the
+// AppError's, and 400 for other errors.
 func ErrorJSON(w http.ResponseWriter, err error, status ...int) error {
 	statusCode := http.StatusBadRequest
+
+	var appErr apperrors.AppError
 	if len(status) > 0 {
 		statusCode = status[0]
+	} else if errors.As(err, &appErr) {
+		statusCode = appErr.StatusCode
+	}
+
+	problem := apperrors.NewProblem(statusCode, err)
+	problem.RequestID = w.Header().Get(middleware.RequestIDHeader)
+	return WriteProblem(w, problem)
+}
+
+// WriteProblem sends problem as the response, with its status.
+func WriteProblem(w http.ResponseWriter, problem apperrors.Problem) error {
+	out, err := json.Marshal(problem)
+	if err != nil {
+		return err
 	}
-	var payload jsonResponse
-	payload.Error = true
-	payload.Message = err.Error()
 
-	return WriteJSON(w, statusCode, payload)
+	w.Header().Set("Content-Type", apperrors.ContentTypeProblem)
+	w.WriteHeader(problem.Status)
+	_, err = w.Write(out)
+	return err
+}
+
+// ExposeRequestID sends the request ID back in the X-Request-Id header, where ErrorJSON also reads
+// it from. It must run after middleware.RequestID.
+func ExposeRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(middleware.RequestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotFound answers requests that match no route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	_ = ErrorJSON(w, apperrors.NewNotFoundError("no route for "+r.URL.Path))
+}
+
+// MethodNotAllowed answers requests for a route that does not accept their method.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	_ = ErrorJSON(w, fmt.Errorf("method %s is not allowed here", r.Method), http.StatusMethodNotAllowed)
 }
 
 func GetID(r *http.Request, key string) (uint, error) {
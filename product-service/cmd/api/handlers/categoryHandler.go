@@ -29,7 +29,7 @@ func (ch *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	categoryResponse, err := ch.service.CreateCategory(storeID, &categoryRequest)
+	categoryResponse, err := ch.service.CreateCategory(r.Context(), storeID, &categoryRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -45,7 +45,7 @@ func (ch *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid store ID"))
 		return
 	}
-	categories, err := ch.service.GetCategories(storeID)
+	categories, err := ch.service.GetCategories(r.Context(), storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -67,7 +67,7 @@ func (ch *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Reques
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid category_id"))
 		return
 	}
-	category, err := ch.service.GetCategoryByID(storeID, categoryID)
+	category, err := ch.service.GetCategoryByID(r.Context(), storeID, categoryID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -89,7 +89,7 @@ func (ch *CategoryHandler) GetCategoryBySlug(w http.ResponseWriter, r *http.Requ
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid category_slug"))
 		return
 	}
-	category, err := ch.service.GetCategoryBySlug(storeID, categorySlug)
+	category, err := ch.service.GetCategoryBySlug(r.Context(), storeID, categorySlug)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -116,7 +116,7 @@ func (ch *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
 		return
 	}
-	err = ch.service.UpdateCategory(storeID, categoryID)
+	err = ch.service.UpdateCategory(r.Context(), storeID, categoryID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -141,7 +141,7 @@ func (ch *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = ch.service.DeleteCategory(storeID, categoryID)
+	err = ch.service.DeleteCategory(r.Context(), storeID, categoryID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
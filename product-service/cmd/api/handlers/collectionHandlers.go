@@ -29,7 +29,7 @@ func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	collectionResponse, err := h.service.CreateCollection(storeID, &collectionRequest)
+	collectionResponse, err := h.service.CreateCollection(r.Context(), storeID, &collectionRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -44,7 +44,7 @@ func (h *CollectionHandler) GetCollections(w http.ResponseWriter, r *http.Reques
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid store ID"))
 		return
 	}
-	collections, err := h.service.GetCollections(storeID)
+	collections, err := h.service.GetCollections(r.Context(), storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -65,7 +65,7 @@ func (h *CollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid collection_id"))
 		return
 	}
-	collection, err := h.service.GetCollection(storeID, collectionID)
+	collection, err := h.service.GetCollection(r.Context(), storeID, collectionID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -93,7 +93,7 @@ func (h *CollectionHandler) AddProductToCollection(w http.ResponseWriter, r *htt
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
 		return
 	}
-	err = h.service.AddProductToCollection(storeID, collectionID, &collectionProductsRequest)
+	err = h.service.AddProductToCollection(r.Context(), storeID, collectionID, &collectionProductsRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -121,7 +121,7 @@ func (h *CollectionHandler) RemoveProductFromCollection(w http.ResponseWriter, r
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid product_id"))
 		return
 	}
-	err = h.service.RemoveProductFromCollection(storeID, collectionID, productID)
+	err = h.service.RemoveProductFromCollection(r.Context(), storeID, collectionID, productID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -142,7 +142,7 @@ func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Requ
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid collection_id"))
 		return
 	}
-	err = h.service.DeleteCollection(storeID, collectionID)
+	err = h.service.DeleteCollection(r.Context(), storeID, collectionID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -169,7 +169,7 @@ func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Requ
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid collection request"))
 		return
 	}
-	err = h.service.UpdateCollection(storeID, collectionID, &collectionRequest)
+	err = h.service.UpdateCollection(r.Context(), storeID, collectionID, &collectionRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
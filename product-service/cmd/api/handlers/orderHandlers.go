@@ -3,9 +3,10 @@ package handlers
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
+	"github.com/robaa12/product-service/cmd/metrics"
 	"github.com/robaa12/product-service/cmd/model"
 	"github.com/robaa12/product-service/cmd/utils"
 	"gorm.io/gorm"
@@ -43,6 +44,7 @@ type VerifiedItem struct {
 func (h *OrderHandler) VerifyOrderItems(w http.ResponseWriter, r *http.Request) {
 	var req VerificationRequest
 	if err := utils.ReadJSON(w, r, &req); err != nil {
+		metrics.OrderVerifications.WithLabelValues("error").Inc()
 		_ = utils.ErrorJSON(w, err)
 		return
 	}
@@ -65,7 +67,7 @@ func (h *OrderHandler) VerifyOrderItems(w http.ResponseWriter, r *http.Request)
 
 	// Fetch all SKUs in a single query
 	var skus []model.Sku
-	err := h.DB.Transaction(func(tx *gorm.DB) error {
+	err := h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
 		// Fix: Properly specify the join condition and table references
 		if err := tx.Joins("JOIN products ON skus.product_id = products.id").
 			Where("skus.id IN ? AND products.store_id = ?", skuIDs, req.StoreID).
@@ -121,13 +123,16 @@ func (h *OrderHandler) VerifyOrderItems(w http.ResponseWriter, r *http.Request)
 	})
 
 	if err != nil {
+		metrics.OrderVerifications.WithLabelValues("error").Inc()
 		_ = utils.ErrorJSON(w, err)
-		log.Println(err)
+		slog.ErrorContext(r.Context(), "Error verifying order items", "error", err)
 		return
 	}
 	if !response.Valid {
+		metrics.OrderVerifications.WithLabelValues("rejected").Inc()
 		response.Message = "Unverified Order Items"
 	} else {
+		metrics.OrderVerifications.WithLabelValues("verified").Inc()
 		response.Message = "Verified Order Items"
 	}
 
@@ -139,8 +144,10 @@ func (h *OrderHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	var orderItems []VerificationItem
 
 	if err := utils.ReadJSON(w, r, &orderItems); err != nil {
-		log.Println(err)
+		slog.WarnContext(r.Context(), "Invalid inventory update payload", "error", err)
+		metrics.InventoryUpdates.WithLabelValues("error").Inc()
 		_ = utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
 	}
 
 	var skus []model.Sku
@@ -153,9 +160,12 @@ func (h *OrderHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 		skus = append(skus, sku)
 	}
 
-	if err := model.UpdateInventory(h.DB, skus); err != nil {
+	if err := model.UpdateInventory(h.DB.WithContext(r.Context()), skus); err != nil {
+		metrics.InventoryUpdates.WithLabelValues("error").Inc()
 		_ = utils.ErrorJSON(w, errors.New("error Updating Inventory"), http.StatusNotFound)
+		return
 	}
 
+	metrics.InventoryUpdates.WithLabelValues("success").Inc()
 	_ = utils.WriteJSON(w, 200, "Inventory Updated Successfully.")
 }
@@ -31,7 +31,7 @@ func (h *ProductHandler) NewProduct(w http.ResponseWriter, r *http.Request) {
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
 		return
 	}
-	productResponse, err := h.ProductService.NewProduct(storeID, productRequest)
+	productResponse, err := h.ProductService.NewProduct(r.Context(), storeID, productRequest)
 
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
@@ -55,7 +55,7 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Get the product from the database
-	productResponse, err := h.ProductService.GetProduct(id, storeID)
+	productResponse, err := h.ProductService.GetProduct(r.Context(), id, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -87,7 +87,7 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the product and get the updated response
-	updatedProduct, err := h.ProductService.UpdateProduct(id, storeID, product)
+	updatedProduct, err := h.ProductService.UpdateProduct(r.Context(), id, storeID, product)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -114,7 +114,7 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the service to delete the product
-	err = h.ProductService.DeleteProduct(id, storeID)
+	err = h.ProductService.DeleteProduct(r.Context(), id, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -173,7 +173,7 @@ func (h *ProductHandler) GetStoreProducts(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	productsResponse, err := h.ProductService.GetStoreProducts(storeID, limit, offset)
+	productsResponse, err := h.ProductService.GetStoreProducts(r.Context(), storeID, limit, offset)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -209,26 +209,26 @@ func (h *ProductHandler) GetStoreProductDashboard(w http.ResponseWriter, r *http
 		// Parse and validate start date
 		startDate, err = time.Parse(layout, startDateStr)
 		if err != nil {
-			http.Error(w, "Invalid start date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+			_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("Invalid start date format. Use YYYY-MM-DD."))
 			return
 		}
 
 		// Parse and validate end date
 		endDate, err = time.Parse(layout, endDateStr)
 		if err != nil {
-			http.Error(w, "Invalid end date format. Use YYYY-MM-DD.", http.StatusBadRequest)
+			_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("Invalid end date format. Use YYYY-MM-DD."))
 			return
 		}
 
 		// Optional: Check if startDate is before endDate
 		if startDate.After(endDate) {
-			http.Error(w, "Start date must be before end date.", http.StatusBadRequest)
+			_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("Start date must be before end date."))
 			return
 		}
 
 	}
 
-	productsDashboardResponse, err := h.ProductService.GetStoreProductsDashboard(storeID, startDate, endDate)
+	productsDashboardResponse, err := h.ProductService.GetStoreProductsDashboard(r.Context(), storeID, startDate, endDate)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -252,7 +252,7 @@ func (h *ProductHandler) GetProductDetails(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	productDetailsResponse, err := h.ProductService.GetProductDetails(productId, storeId)
+	productDetailsResponse, err := h.ProductService.GetProductDetails(r.Context(), productId, storeId)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -273,7 +273,7 @@ func (h *ProductHandler) GetProductBySlug(w http.ResponseWriter, r *http.Request
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("Invalid store ID"), http.StatusBadRequest)
 		return
 	}
-	productDetailsResponse, err := h.ProductService.GetProductBySlug(slug, uint(StoreID))
+	productDetailsResponse, err := h.ProductService.GetProductBySlug(r.Context(), slug, uint(StoreID))
 	if err != nil {
 		_ = utils.ErrorJSON(w, apperrors.NewNotFoundError("Product not found"), http.StatusNotFound)
 		return
@@ -331,7 +331,7 @@ func (h *ProductHandler) GetProductsByStoreSlug(w http.ResponseWriter, r *http.R
 		}
 	}
 
-	productsResponse, err := h.ProductService.GetProductsByStoreSlug(storeSlug, limit, offset)
+	productsResponse, err := h.ProductService.GetProductsByStoreSlug(r.Context(), storeSlug, limit, offset)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
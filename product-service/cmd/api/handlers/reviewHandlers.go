@@ -37,7 +37,7 @@ func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reviewResponse, err := h.service.CreateReview(productID, storeID, &reviewRequest)
+	reviewResponse, err := h.service.CreateReview(r.Context(), productID, storeID, &reviewRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -59,7 +59,7 @@ func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	reviewResponses, err := h.service.GetProductReviews(productID, storeID)
+	reviewResponses, err := h.service.GetProductReviews(r.Context(), productID, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -85,7 +85,7 @@ func (h *ReviewHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	review, err := h.service.GetReview(reviewID, productID, storeID)
+	review, err := h.service.GetReview(r.Context(), reviewID, productID, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -107,7 +107,7 @@ func (h *ReviewHandler) GetReviewStatistics(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	statistics, err := h.service.GetReviewStatistics(productID, storeID)
+	statistics, err := h.service.GetReviewStatistics(r.Context(), productID, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -43,7 +43,7 @@ func (h *SKUHandler) UpdateSKU(w http.ResponseWriter, r *http.Request) {
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
 		return
 	}
-	err = h.service.UpdateSKU(skuID, productID, storeID, &skuRequest)
+	err = h.service.UpdateSKU(r.Context(), skuID, productID, storeID, &skuRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -69,7 +69,7 @@ func (h *SKUHandler) GetSKU(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	skuResponse, err := h.service.GetSKU(skuID, productID, storeID)
+	skuResponse, err := h.service.GetSKU(r.Context(), skuID, productID, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -90,7 +90,7 @@ func (h *SKUHandler) GetSKUs(w http.ResponseWriter, r *http.Request) {
 		_ = utils.ErrorJSON(w, apperrors.NewBadRequestError("invalid request payload"))
 		return
 	}
-	skuResponse, err := h.service.GetSKUs(storeID, &skusRequest)
+	skuResponse, err := h.service.GetSKUs(r.Context(), storeID, &skusRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -117,7 +117,7 @@ func (h *SKUHandler) DeleteSKU(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find SKU by ID
-	err = h.service.DeleteSKU(skuID, productID, storeID)
+	err = h.service.DeleteSKU(r.Context(), skuID, productID, storeID)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -145,7 +145,7 @@ func (h *SKUHandler) NewSKU(w http.ResponseWriter, r *http.Request) {
 
 	// Start Database Transaction
 	// Create a new SKU
-	skuResponse, err := h.service.NewSKU(storeID, productID, &skuRequest)
+	skuResponse, err := h.service.NewSKU(r.Context(), storeID, productID, &skuRequest)
 	if err != nil {
 		_ = utils.ErrorJSON(w, err)
 		return
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/robaa12/product-service/cmd/database"
+	"github.com/robaa12/product-service/cmd/logging"
 	"github.com/robaa12/product-service/cmd/model"
+	"github.com/robaa12/product-service/cmd/tracing"
 )
 
 // WebPort Application Port
@@ -20,12 +24,22 @@ type Config struct {
 }
 
 func main() {
-	log.Printf("Starting Product Service On Port %s...\n", WebPort)
+	if err := logging.Setup("product-service"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.Info("Starting Product Service", "port", WebPort)
+
+	shutdownTracing, err := tracing.Init(context.Background(), "product-service", serviceVersion())
+	if err != nil {
+		fatal(err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Setup Database (migrations, indexes)
 	DB, err := database.New()
 	if err != nil {
-		log.Panic(err)
+		fatal(err)
 	}
 	err = DB.SetupDatabase()
 	if err != nil {
@@ -44,6 +58,11 @@ func main() {
 	}
 	err = srv.ListenAndServe()
 	if err != nil {
-		log.Panic(err)
+		fatal(err)
 	}
 }
+
+func fatal(err error) {
+	slog.Error("Product Service stopped", "error", err)
+	os.Exit(1)
+}
@@ -8,8 +8,12 @@ import (
 	"github.com/go-chi/chi/v5/middleware"                 // ✅ Corrected import for Chi middleware
 	"github.com/robaa12/product-service/cmd/api/handlers" // ✅ Alias for your custom middleware
 	"github.com/robaa12/product-service/cmd/database"
+	"github.com/robaa12/product-service/cmd/identity"
+	"github.com/robaa12/product-service/cmd/logging"
+	"github.com/robaa12/product-service/cmd/metrics"
 	"github.com/robaa12/product-service/cmd/repository"
 	"github.com/robaa12/product-service/cmd/service"
+	"github.com/robaa12/product-service/cmd/tracing"
 	"github.com/robaa12/product-service/cmd/utils"
 )
 
@@ -17,10 +21,18 @@ func (app *Config) routes() http.Handler {
 	mux := chi.NewRouter()
 
 	// Middleware
-	mux.Use(middleware.Logger)
-	mux.Use(middleware.Recoverer)
+	mux.Use(middleware.SetHeader("X-Service-Version", serviceVersion()))
+	mux.Use(middleware.Heartbeat("/ping"))
+	mux.Use(tracing.Middleware)
+	mux.Use(metrics.Middleware)
 	mux.Use(middleware.RequestID)
+	mux.Use(utils.ExposeRequestID)
 	mux.Use(middleware.RealIP)
+	mux.Use(logging.Middleware)
+	mux.Use(middleware.Recoverer)
+	mux.Use(identity.NewVerifier().Middleware)
+	mux.NotFound(utils.NotFound)
+	mux.MethodNotAllowed(utils.MethodNotAllowed)
 
 	// Order Handler
 	OrderHandler := handlers.OrderHandler{DB: app.db.DB}
@@ -42,25 +54,27 @@ func (app *Config) routes() http.Handler {
 	reviewHandler := handlers.NewReviewHandler(reviewService)
 	skuHandler := setupSKUHandler(app.db)
 
-	mux.Post("/verify-order", OrderHandler.VerifyOrderItems)
-	mux.Post("/update-inventory", OrderHandler.UpdateInventory)
+	mux.Handle("/metrics", metrics.Handler(os.Getenv("METRICS_TOKEN")))
+	// Called by order-service on behalf of a gateway request
+	mux.With(identity.RequireGateway).Post("/verify-order", OrderHandler.VerifyOrderItems)
+	mux.With(identity.RequireGateway).Post("/update-inventory", OrderHandler.UpdateInventory)
 
 	// Routes under /stores/{store_id}
 	mux.Route("/stores", func(r chi.Router) {
-		r.Post("/", storeHandler.CreateStore)
+		r.With(identity.RequireGateway).Post("/", storeHandler.CreateStore)
 		r.Get("/slug/{store_slug}/products", productHandler.GetProductsByStoreSlug)
 
 		r.Route("/{store_id}", func(r chi.Router) {
 			// Public Product Routes
-			r.Delete("/", storeHandler.DeleteStore)
+			r.With(identity.RequireStore).Delete("/", storeHandler.DeleteStore)
+			r.With(identity.RequireStore).Post("/restore", storeHandler.RestoreStore)
 			r.Get("/products", productHandler.GetStoreProducts)
 			r.Get("/products/dashboard", productHandler.GetStoreProductDashboard)
 			r.Get("/products/slug/{slug}", productHandler.GetProductBySlug)
-			r.Post("/skus/info", skuHandler.GetSKUs)
+			r.With(identity.RequireGateway).Post("/skus/info", skuHandler.GetSKUs)
 
 			r.Group(func(r chi.Router) {
-				//r.Use(customMiddleware.AuthenticateToken)
-				//r.Use(customMiddleware.VerifyStoreOwnership)
+				r.Use(identity.RequireStore)
 				r.Post("/products", productHandler.NewProduct)
 			})
 
@@ -72,8 +86,7 @@ func (app *Config) routes() http.Handler {
 
 				// Protected endpoints
 				r.Group(func(r chi.Router) {
-					//r.Use(customMiddleware.AuthenticateToken)
-					//r.Use(customMiddleware.VerifyStoreOwnership)
+					r.Use(identity.RequireStore)
 
 					r.Put("/", productHandler.UpdateProduct)
 					r.Delete("/", productHandler.DeleteProduct)
@@ -84,7 +97,7 @@ func (app *Config) routes() http.Handler {
 				r.Get("/reviews", reviewHandler.GetProductReviews)
 				r.Get("/reviews/{review_id}", reviewHandler.GetReview)
 				r.Get("/reviews/statistics", reviewHandler.GetReviewStatistics)
-				r.Post("/reviews", reviewHandler.CreateReview)
+				r.With(identity.RequireGateway).Post("/reviews", reviewHandler.CreateReview)
 			})
 			// Collection Routes /stores/{store_id}/collections
 			r.Route("/collections", app.collection)
@@ -93,34 +106,17 @@ func (app *Config) routes() http.Handler {
 		})
 	})
 
-	// Token Routes
-	if os.Getenv("APP_ENV") != "production" {
-		mux.Post("/generate-token", func(w http.ResponseWriter, r *http.Request) {
-			var req struct {
-				UserID  uint   `json:"user_id"`
-				StoreID uint   `json:"store_id"`
-				Role    string `json:"role"`
-			}
-
-			if err := utils.ReadJSON(w, r, &req); err != nil {
-				_ = utils.ErrorJSON(w, err)
-				return
-			}
-
-			token, err := utils.GenerateToken(req.UserID, req.StoreID, req.Role)
-			if err != nil {
-				_ = utils.ErrorJSON(w, err)
-				return
-			}
-
-			_ = utils.WriteJSON(w, http.StatusOK, map[string]string{
-				"token": token,
-			})
-		})
-	}
-
 	return mux
 }
+
+// serviceVersion is reported to the gateway's readiness checks
+func serviceVersion() string {
+	if version := os.Getenv("SERVICE_VERSION"); version != "" {
+		return version
+	}
+	return "dev"
+}
+
 func setupSKUHandler(db *database.Database) *handlers.SKUHandler {
 	skuRepo := repository.NewSkuRepository(db)
 	skuService := service.NewSKUService(skuRepo)
@@ -135,8 +131,7 @@ func (app *Config) sku(r chi.Router) {
 
 	// Protected endpoints
 	r.Group(func(r chi.Router) {
-		//	r.Use(customMiddleware.AuthenticateToken)
-		//	r.Use(customMiddleware.VerifyStoreOwnership)
+		r.Use(identity.RequireStore)
 
 		r.Post("/", skuHandler.NewSKU)
 		r.Put("/{sku_id}", skuHandler.UpdateSKU)
@@ -155,14 +150,13 @@ func (app *Config) collection(r chi.Router) {
 	// Public endpoints
 	r.Get("/", collectionHandler.GetCollections)
 	r.Get("/{collection_id}", collectionHandler.GetCollection)
-	r.Delete("/{collection_id}", collectionHandler.DeleteCollection)
-	r.Put("/{collection_id}", collectionHandler.UpdateCollection)
 
 	// Protected endpoints
 	r.Group(func(r chi.Router) {
-		//r.Use(customMiddleware.AuthenticateToken)
-		//r.Use(customMiddleware.VerifyStoreOwnership)
+		r.Use(identity.RequireStore)
 
+		r.Delete("/{collection_id}", collectionHandler.DeleteCollection)
+		r.Put("/{collection_id}", collectionHandler.UpdateCollection)
 		r.Post("/", collectionHandler.CreateCollection)
 		r.Post("/{collection_id}/products", collectionHandler.AddProductToCollection)
 		r.Delete("/{collection_id}/products/{product_id}", collectionHandler.RemoveProductFromCollection)
@@ -180,12 +174,12 @@ func (app *Config) category(r chi.Router) {
 	categoryHandler := setupCategoryHandler(app.db)
 	// Public endpoints
 	r.Get("/", categoryHandler.GetCategories)
-	r.Post("/", categoryHandler.CreateCategory)
+	r.With(identity.RequireStore).Post("/", categoryHandler.CreateCategory)
 	r.Get("/slug/{category_slug}", categoryHandler.GetCategoryBySlug)
 	r.Route("/{category_id}", func(r chi.Router) {
 		r.Get("/", categoryHandler.GetCategoryByID)
-		r.Post("/", categoryHandler.UpdateCategory)
-		r.Delete("/", categoryHandler.DeleteCategory)
+		r.With(identity.RequireStore).Post("/", categoryHandler.UpdateCategory)
+		r.With(identity.RequireStore).Delete("/", categoryHandler.DeleteCategory)
 	})
 
 }
@@ -2,11 +2,12 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
 	"github.com/robaa12/product-service/cmd/model"
+	"github.com/robaa12/product-service/cmd/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -64,6 +65,9 @@ func openDB(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
 
@@ -73,18 +77,18 @@ func connectToDB() *gorm.DB {
 	for {
 		connection, err := openDB(dsn)
 		if err != nil {
-			log.Println("Postgres not yet ready...")
+			slog.Warn("Postgres not yet ready...", "error", err)
 			counts++
 		} else {
-			log.Println("Connected to Postgres!")
+			slog.Info("Connected to Postgres!")
 			return connection
 		}
 
 		if counts > 10 {
-			log.Println(err)
+			slog.Error("Giving up connecting to Postgres", "error", err)
 			return nil
 		}
-		log.Println("Backing off two seconds")
+		slog.Info("Backing off two seconds")
 		time.Sleep(2 * time.Second)
 		continue
 	}
@@ -0,0 +1,160 @@
+// Package identity checks the signed identity headers the gateway adds to every request it
+// forwards, so writes are only accepted for the stores the gateway vouched for.
+package identity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	apperrors "github.com/robaa12/product-service/cmd/errors"
+	"github.com/robaa12/product-service/cmd/utils"
+)
+
+const (
+	HeaderUserID    = "X-User-ID"
+	HeaderStoreIDs  = "X-Store-IDs"
+	HeaderRequestID = "X-Request-ID"
+	HeaderTimestamp = "X-Identity-Timestamp"
+	HeaderSignature = "X-Identity-Signature"
+
+	// maxAge bounds how old a signed identity may be, allowing for clock skew between hosts
+	maxAge = 2 * time.Minute
+
+	// defaultSecret matches the gateway's development default
+	defaultSecret = "internal-dev-secret"
+)
+
+// Identity is who the gateway says the request is from. UserID is 0 for anonymous callers and for
+// store API keys.
+type Identity struct {
+	UserID    int
+	StoreIDs  []int
+	RequestID string
+}
+
+type contextKey struct{}
+
+// FromContext returns the verified identity of the request, if it carried one.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+// Verifier checks identity signatures with the secret shared with the gateway.
+type Verifier struct {
+	secret []byte
+}
+
+// NewVerifier reads the shared secret from INTERNAL_AUTH_SECRET.
+func NewVerifier() *Verifier {
+	secret := os.Getenv("INTERNAL_AUTH_SECRET")
+	if secret == "" {
+		slog.Warn("INTERNAL_AUTH_SECRET is not set, using the development secret")
+		secret = defaultSecret
+	}
+	return &Verifier{secret: []byte(secret)}
+}
+
+// Middleware verifies the identity headers when a request has them and rejects forged or stale
+// ones. Requests without them pass through anonymous; RequireGateway and RequireStore decide
+// whether that is enough.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderSignature) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := v.verify(r)
+		if err != nil {
+			_ = utils.ErrorJSON(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// RequireGateway only lets through requests that came through the gateway, anonymous or not.
+func RequireGateway(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContext(r.Context()); !ok {
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("request was not signed by the gateway"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireStore only lets through requests whose identity may act for the {store_id} in the path.
+func RequireStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromContext(r.Context())
+		if !ok {
+			_ = utils.ErrorJSON(w, apperrors.NewUnauthorizedError("request was not signed by the gateway"))
+			return
+		}
+		storeID, err := strconv.Atoi(chi.URLParam(r, "store_id"))
+		if err != nil || !slices.Contains(id.StoreIDs, storeID) {
+			_ = utils.ErrorJSON(w, apperrors.NewForbiddenError("not allowed to modify this store"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// signature must match the one computed by the gateway's identity.Signer. It covers the method and
+// path, so signed headers cannot be replayed on another route.
+func (v *Verifier) signature(method, path, userID, storeIDs, requestID, timestamp string) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(strings.Join([]string{"v2", method, path, userID, storeIDs, requestID, timestamp}, "\n")))
+	return mac.Sum(nil)
+}
+
+func (v *Verifier) verify(r *http.Request) (Identity, error) {
+	h := r.Header
+	userID := h.Get(HeaderUserID)
+	storeIDs := h.Get(HeaderStoreIDs)
+	requestID := h.Get(HeaderRequestID)
+	timestamp := h.Get(HeaderTimestamp)
+
+	expected := v.signature(r.Method, r.URL.Path, userID, storeIDs, requestID, timestamp)
+	signature, err := hex.DecodeString(h.Get(HeaderSignature))
+	if err != nil || !hmac.Equal(signature, expected) {
+		return Identity{}, apperrors.NewUnauthorizedError("invalid identity signature")
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, apperrors.NewUnauthorizedError("invalid identity timestamp")
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > maxAge || age < -maxAge {
+		return Identity{}, apperrors.NewUnauthorizedError("identity signature expired")
+	}
+
+	id := Identity{RequestID: requestID}
+	if userID != "" {
+		if id.UserID, err = strconv.Atoi(userID); err != nil {
+			return Identity{}, apperrors.NewUnauthorizedError("invalid user id")
+		}
+	}
+	if storeIDs != "" {
+		for _, raw := range strings.Split(storeIDs, ",") {
+			storeID, err := strconv.Atoi(raw)
+			if err != nil {
+				return Identity{}, apperrors.NewUnauthorizedError("invalid store ids")
+			}
+			id.StoreIDs = append(id.StoreIDs, storeID)
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// knownSignature is the signature the gateway's identity.Signer gives for the same fields.
+const knownSignature = "e1741847cfd2687c2fa4e3774f58e20423cb0d458df6c70f00899344530fb2bc"
+
+func TestSignatureMatchesGateway(t *testing.T) {
+	v := &Verifier{secret: []byte("test-secret")}
+	got := hex.EncodeToString(v.signature("PUT", "/orders/7/items/3", "42", "1,2", "req-1", "1700000000"))
+	if got != knownSignature {
+		t.Fatalf("signature = %s, want %s", got, knownSignature)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v := &Verifier{secret: []byte("test-secret")}
+	now := time.Now()
+
+	// sign returns a request to method and path signed at signedAt for user 42 in store 1.
+	sign := func(method, path string, signedAt time.Time) *http.Request {
+		r := httptest.NewRequest(method, path, nil)
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		r.Header.Set(HeaderUserID, "42")
+		r.Header.Set(HeaderStoreIDs, "1")
+		r.Header.Set(HeaderRequestID, "req-1")
+		r.Header.Set(HeaderTimestamp, timestamp)
+		r.Header.Set(HeaderSignature, hex.EncodeToString(v.signature(method, path, "42", "1", "req-1", timestamp)))
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		request    func() *http.Request
+		wantStatus int
+		wantSigned bool
+	}{
+		{
+			name:       "signed",
+			request:    func() *http.Request { return sign("POST", "/stores/1/products", now) },
+			wantStatus: http.StatusOK,
+			wantSigned: true,
+		},
+		{
+			name:       "unsigned passes through anonymous",
+			request:    func() *http.Request { return httptest.NewRequest("GET", "/stores/1/products", nil) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "replayed on another route",
+			request: func() *http.Request {
+				r := sign("POST", "/stores/1/products", now)
+				r.Method = "DELETE"
+				r.URL.Path = "/stores/1"
+				return r
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "expired",
+			request:    func() *http.Request { return sign("POST", "/stores/1/products", now.Add(-maxAge-time.Minute)) },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, signed = FromContext(r.Context())
+			})
+			w := httptest.NewRecorder()
+			v.Middleware(next).ServeHTTP(w, tt.request())
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if signed != tt.wantSigned {
+				t.Fatalf("signed = %t, want %t", signed, tt.wantSigned)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// The gateway and each service carry their own copy of this package. This test is the same in all
+// of them, so a key redacted in one service but logged in clear by another fails here.
+func TestSensitiveKeys(t *testing.T) {
+	want := []string{"password", "authorization", "token", "secret", "apikey", "cookie"}
+	if !slices.Equal(sensitiveKeys, want) {
+		t.Fatalf("sensitiveKeys = %q, want %q in every service", sensitiveKeys, want)
+	}
+}
+
+func TestRedactAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want any
+	}{
+		{name: "sensitive name", attr: slog.String("Refresh_Token", "abc"), want: redacted},
+		{name: "api key header name", attr: slog.String("X-API-Key", "abc"), want: redacted},
+		{name: "bearer value", attr: slog.String("header", "Bearer abc"), want: redacted},
+		{name: "plain value", attr: slog.String("email", "sara@example.com"), want: "sara@example.com"},
+		{
+			name: "header",
+			attr: slog.Any("headers", http.Header{"Cookie": {"session=1"}, "Accept": {"*/*"}}),
+			want: http.Header{"Cookie": {redacted}, "Accept": {"*/*"}},
+		},
+		{
+			name: "nested field",
+			attr: slog.Any("body", map[string]any{"user": map[string]any{"email": "sara@example.com", "password": "hunter2"}}),
+			want: map[string]any{"user": map[string]any{"email": "sara@example.com", "password": redacted}},
+		},
+		{name: "error", attr: slog.Any("error", errors.New("token expired")), want: errors.New("token expired")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactAttr(nil, tt.attr).Value.Any()
+			if err, ok := got.(error); ok {
+				got = errors.New(err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("redactAttr = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package metrics records HTTP request metrics for Prometheus and serves them on /metrics.
+//
+// The gateway and every service guard /metrics the same way: scrapes must send the deployment's
+// METRICS_TOKEN as a bearer token, and the endpoint answers 404 while no token is set. A Prometheus
+// scrape job for any of them looks like
+//
+//	scrape_configs:
+//	  - job_name: gateway
+//	    authorization:
+//	      credentials_file: /etc/prometheus/metrics-token
+//	    static_configs:
+//	      - targets: ["gateway-service:8080"]
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "product_http_requests_total",
+		Help: "HTTP requests handled by the product service, by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "product_http_request_duration_seconds",
+		Help:    "Time taken to answer HTTP requests, by route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// OrderVerifications counts order verification requests by result: verified, rejected or error.
+	OrderVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "product_order_verifications_total",
+		Help: "Order verification requests from the order service, by result.",
+	}, []string{"result"})
+
+	// InventoryUpdates counts inventory update requests by result: success or error.
+	InventoryUpdates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "product_inventory_updates_total",
+		Help: "Inventory updates requested by the order service, by result.",
+	}, []string{"result"})
+)
+
+// Handler serves the Prometheus scrape endpoint to requests bearing token. Metrics name routes
+// and error rates, so they are not served at all while token is empty.
+func Handler(token string) http.Handler {
+	scrape := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "metrics token required", http.StatusUnauthorized)
+			return
+		}
+		scrape.ServeHTTP(w, r)
+	})
+}
+
+// Middleware records request count and latency labelled with chi's route pattern.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+		requestsTotal.WithLabelValues(r.Method, route, statusLabel(ww.Status())).Inc()
+		requestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "unmatched"
+	}
+	return rctx.RoutePattern()
+}
+
+func statusLabel(status int) string {
+	// Handlers that write a body without calling WriteHeader answer 200
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "disabled without a token", authorization: "Bearer ", want: http.StatusNotFound},
+		{name: "no credentials", token: "scrape", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "scrape", authorization: "Bearer other", want: http.StatusUnauthorized},
+		{name: "metrics token", token: "scrape", authorization: "Bearer scrape", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			Handler(tt.token).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
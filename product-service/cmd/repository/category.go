@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,40 +19,40 @@ func NewCategoryRepository(db *database.Database) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-func (cr *CategoryRepository) GetCategoryByID(storeID uint, categoryID uint) (*model.Category, error) {
+func (cr *CategoryRepository) GetCategoryByID(ctx context.Context, storeID uint, categoryID uint) (*model.Category, error) {
 	var category model.Category
-	err := cr.db.DB.Where("store_id = ? AND id = ?", storeID, categoryID).Preload("Products").First(&category).Error
+	err := cr.db.DB.WithContext(ctx).Where("store_id = ? AND id = ?", storeID, categoryID).Preload("Products").First(&category).Error
 	return &category, err
 }
-func (cr *CategoryRepository) GetCategoryBySlug(storeID uint, slug string) (*model.Category, error) {
+func (cr *CategoryRepository) GetCategoryBySlug(ctx context.Context, storeID uint, slug string) (*model.Category, error) {
 	var category model.Category
-	err := cr.db.DB.Where("store_id = ? AND slug = ?", storeID, slug).Preload("Products").First(&category).Error
+	err := cr.db.DB.WithContext(ctx).Where("store_id = ? AND slug = ?", storeID, slug).Preload("Products").First(&category).Error
 	return &category, err
 }
 
-func (cr *CategoryRepository) GetStoreCategories(storeID uint) ([]model.Category, error) {
+func (cr *CategoryRepository) GetStoreCategories(ctx context.Context, storeID uint) ([]model.Category, error) {
 	var category []model.Category
-	err := cr.db.DB.Where("store_id = ?", storeID).Find(&category).Error
+	err := cr.db.DB.WithContext(ctx).Where("store_id = ?", storeID).Find(&category).Error
 	return category, err
 }
 
-func (cr *CategoryRepository) UpdateCategory(storeID, categoryID uint, category *model.Category) error {
-	return cr.db.DB.Model(&model.Product{}).Where("id = ? AND store_id = ?", categoryID, storeID).Updates(category).Error
+func (cr *CategoryRepository) UpdateCategory(ctx context.Context, storeID, categoryID uint, category *model.Category) error {
+	return cr.db.DB.WithContext(ctx).Model(&model.Product{}).Where("id = ? AND store_id = ?", categoryID, storeID).Updates(category).Error
 }
 
-func (cr *CategoryRepository) CreateCategory(category *model.Category) error {
+func (cr *CategoryRepository) CreateCategory(ctx context.Context, category *model.Category) error {
 	// TODO: remove when adding Distributed Transaction
 	//add new store if not exist in database using firstorcreate
 	store := model.Store{ID: category.StoreID}
-	if err := cr.db.DB.FirstOrCreate(&store, store).Error; err != nil {
+	if err := cr.db.DB.WithContext(ctx).FirstOrCreate(&store, store).Error; err != nil {
 		return err
 	}
 	// Create category
-	return cr.db.DB.Create(&category).Error
+	return cr.db.DB.WithContext(ctx).Create(&category).Error
 }
 
 // GenerateCategorySlug checks if the slug is unique within the store and generates a new one if necessary.
-func (cr *CategoryRepository) GenerateCategorySlug(name string, storeID uint) (string, error) {
+func (cr *CategoryRepository) GenerateCategorySlug(ctx context.Context, name string, storeID uint) (string, error) {
 	// Generate the base slug from the Category name
 	baseSlug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	slug := baseSlug
@@ -60,7 +61,7 @@ func (cr *CategoryRepository) GenerateCategorySlug(name string, storeID uint) (s
 	// Loop to find a unique slug
 	for i := 1; ; i++ {
 		// Check if a Category with the same slug and store_id already exists
-		cr.db.DB.Model(&model.Category{}).
+		cr.db.DB.WithContext(ctx).Model(&model.Category{}).
 			Where("slug = ? AND store_id = ?", slug, storeID).
 			Count(&count)
 
@@ -74,9 +75,9 @@ func (cr *CategoryRepository) GenerateCategorySlug(name string, storeID uint) (s
 	}
 }
 
-func (cr *CategoryRepository) FindCategory(storeID, categoryID uint) (*model.Category, error) {
+func (cr *CategoryRepository) FindCategory(ctx context.Context, storeID, categoryID uint) (*model.Category, error) {
 	var category model.Category
-	result := cr.db.DB.Where("store_id = ? AND id = ?", storeID, categoryID).Preload("Products").First(&category)
+	result := cr.db.DB.WithContext(ctx).Where("store_id = ? AND id = ?", storeID, categoryID).Preload("Products").First(&category)
 
 	if err := result.Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -86,6 +87,6 @@ func (cr *CategoryRepository) FindCategory(storeID, categoryID uint) (*model.Cat
 	}
 	return &category, nil
 }
-func (cr *CategoryRepository) DeleteCategory(category *model.Category) error {
-	return cr.db.DB.Unscoped().Delete(category).Error
+func (cr *CategoryRepository) DeleteCategory(ctx context.Context, category *model.Category) error {
+	return cr.db.DB.WithContext(ctx).Unscoped().Delete(category).Error
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,44 +19,44 @@ func NewCollectionRepository(db *database.Database) *CollectionRepository {
 	return &CollectionRepository{db: db}
 }
 
-func (cr *CollectionRepository) GetCollectionByID(storeID uint, collectionID uint) (*model.Collection, error) {
+func (cr *CollectionRepository) GetCollectionByID(ctx context.Context, storeID uint, collectionID uint) (*model.Collection, error) {
 	var collection model.Collection
-	err := cr.db.DB.Where("store_id = ? AND id = ?", storeID, collectionID).Preload("Products.Category").First(&collection).Error
+	err := cr.db.DB.WithContext(ctx).Where("store_id = ? AND id = ?", storeID, collectionID).Preload("Products.Category").First(&collection).Error
 	return &collection, err
 }
 
-func (cr *CollectionRepository) GetStoreCollections(storeID uint) ([]model.Collection, error) {
+func (cr *CollectionRepository) GetStoreCollections(ctx context.Context, storeID uint) ([]model.Collection, error) {
 	var collections []model.Collection
-	err := cr.db.DB.Where("store_id = ?", storeID).Find(&collections).Error
+	err := cr.db.DB.WithContext(ctx).Where("store_id = ?", storeID).Find(&collections).Error
 	return collections, err
 }
 
-func (cr *CollectionRepository) AddProductsToCollection(collection *model.Collection, products []model.Product) error {
-	return cr.db.DB.Model(collection).Association("Products").Append(&products)
+func (cr *CollectionRepository) AddProductsToCollection(ctx context.Context, collection *model.Collection, products []model.Product) error {
+	return cr.db.DB.WithContext(ctx).Model(collection).Association("Products").Append(&products)
 }
 
-func (cr *CollectionRepository) RemoveProductFromCollection(collectionID uint, productID uint) error {
-	return cr.db.DB.Exec("DELETE FROM collection_products WHERE product_id = ? AND collection_id = ?", productID, collectionID).Error
+func (cr *CollectionRepository) RemoveProductFromCollection(ctx context.Context, collectionID uint, productID uint) error {
+	return cr.db.DB.WithContext(ctx).Exec("DELETE FROM collection_products WHERE product_id = ? AND collection_id = ?", productID, collectionID).Error
 }
 
-func (cr *CollectionRepository) UpdateCollection(collectionID uint, collectionRequest *model.CollectionRequest) error {
-	return cr.db.DB.Model(&model.Collection{ID: collectionID}).Updates(collectionRequest).Error
+func (cr *CollectionRepository) UpdateCollection(ctx context.Context, collectionID uint, collectionRequest *model.CollectionRequest) error {
+	return cr.db.DB.WithContext(ctx).Model(&model.Collection{ID: collectionID}).Updates(collectionRequest).Error
 }
 
-func (cr *CollectionRepository) CreateCollection(collection *model.Collection) error {
+func (cr *CollectionRepository) CreateCollection(ctx context.Context, collection *model.Collection) error {
 	// TODO: remove when adding Distributed Transaction
 	//add new store if not exist in database using firstorcreate
 	store := model.Store{ID: collection.StoreID}
-	if err := cr.db.DB.FirstOrCreate(&store, store).Error; err != nil {
+	if err := cr.db.DB.WithContext(ctx).FirstOrCreate(&store, store).Error; err != nil {
 		return err
 	}
 
 	// Create Collection
-	return cr.db.DB.Create(&collection).Error
+	return cr.db.DB.WithContext(ctx).Create(&collection).Error
 }
 
 // GenerateCollectionSlug checks if the slug is unique within the store and generates a new one if necessary.
-func (cr *CollectionRepository) GenerateCollectionSlug(name string, storeID uint) (string, error) {
+func (cr *CollectionRepository) GenerateCollectionSlug(ctx context.Context, name string, storeID uint) (string, error) {
 	// Generate the base slug from the collection name
 	baseSlug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	slug := baseSlug
@@ -64,7 +65,7 @@ func (cr *CollectionRepository) GenerateCollectionSlug(name string, storeID uint
 	// Loop to find a unique slug
 	for i := 1; ; i++ {
 		// Check if a collection with the same slug and store_id already exists
-		cr.db.DB.Model(&model.Collection{}).
+		cr.db.DB.WithContext(ctx).Model(&model.Collection{}).
 			Where("slug = ? AND store_id = ?", slug, storeID).
 			Count(&count)
 
@@ -78,16 +79,16 @@ func (cr *CollectionRepository) GenerateCollectionSlug(name string, storeID uint
 	}
 }
 
-func (cr *CollectionRepository) DeleteCollection(collectionID uint) error {
-	if err := cr.db.DB.Exec("DELETE FROM collection_products WHERE collection_id = ?", collectionID).Error; err != nil {
+func (cr *CollectionRepository) DeleteCollection(ctx context.Context, collectionID uint) error {
+	if err := cr.db.DB.WithContext(ctx).Exec("DELETE FROM collection_products WHERE collection_id = ?", collectionID).Error; err != nil {
 		return err
 	}
 	// Then delete from the collections table
-	return cr.db.DB.Exec("DELETE FROM collections WHERE id = ?", collectionID).Error
+	return cr.db.DB.WithContext(ctx).Exec("DELETE FROM collections WHERE id = ?", collectionID).Error
 }
-func (cr *CollectionRepository) FindProducts(storeID uint, collectionProductsRequests *model.CollectionProductsRequest) ([]model.Product, error) {
+func (cr *CollectionRepository) FindProducts(ctx context.Context, storeID uint, collectionProductsRequests *model.CollectionProductsRequest) ([]model.Product, error) {
 	var products []model.Product
-	if err := cr.db.DB.Where("store_id = ? AND id IN (?)", storeID, collectionProductsRequests.ProductIDs).Find(&products).Error; err != nil {
+	if err := cr.db.DB.WithContext(ctx).Where("store_id = ? AND id IN (?)", storeID, collectionProductsRequests.ProductIDs).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	if len(products) != len(collectionProductsRequests.ProductIDs) {
@@ -96,9 +97,9 @@ func (cr *CollectionRepository) FindProducts(storeID uint, collectionProductsReq
 	return products, nil
 }
 
-func (cr *CollectionRepository) FindCollection(storeID, collectionID uint) (*model.Collection, error) {
+func (cr *CollectionRepository) FindCollection(ctx context.Context, storeID, collectionID uint) (*model.Collection, error) {
 	var collection model.Collection
-	if err := cr.db.DB.Where("store_id = ? AND id = ?", storeID, collectionID).First(&collection).Error; err != nil {
+	if err := cr.db.DB.WithContext(ctx).Where("store_id = ? AND id = ?", storeID, collectionID).First(&collection).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
@@ -107,11 +108,11 @@ func (cr *CollectionRepository) FindCollection(storeID, collectionID uint) (*mod
 	return &collection, nil
 }
 
-func (cr *CollectionRepository) FindCollectionProduct(storeID, collectionID, productID uint) (*model.Collection, error) {
+func (cr *CollectionRepository) FindCollectionProduct(ctx context.Context, storeID, collectionID, productID uint) (*model.Collection, error) {
 	var collection model.Collection
 
 	// One query: find the collection and preload the specific product
-	err := cr.db.DB.
+	err := cr.db.DB.WithContext(ctx).
 		Model(&model.Collection{}).
 		Where("id = ? AND store_id = ?", collectionID, storeID).
 		Preload("Products", func(db *gorm.DB) *gorm.DB {
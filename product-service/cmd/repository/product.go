@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -21,24 +22,24 @@ func NewProductRepository(db database.Database) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (pr *ProductRepository) GetProduct(productId uint, storeId uint) (*model.Product, error) {
+func (pr *ProductRepository) GetProduct(ctx context.Context, productId uint, storeId uint) (*model.Product, error) {
 	var product model.Product
 	// Find the product with the given id and store_id
-	err := pr.db.DB.Preload("Category").Preload("SKUs").Where("id = ? AND store_id = ?", productId, storeId).First(&product).Error
+	err := pr.db.DB.WithContext(ctx).Preload("Category").Preload("SKUs").Where("id = ? AND store_id = ?", productId, storeId).First(&product).Error
 	if err != nil {
 		return nil, gorm.ErrRecordNotFound
 	}
 
 	// Fetch collection IDs
-	product.CollectionIDs = pr.fetchCollectionIDs(product.ID)
+	product.CollectionIDs = pr.fetchCollectionIDs(ctx, product.ID)
 
 	return &product, nil
 }
 
-func (pr *ProductRepository) UpdateProduct(p model.ProductResponse, id uint, storeId uint) (*model.Product, error) {
+func (pr *ProductRepository) UpdateProduct(ctx context.Context, p model.ProductResponse, id uint, storeId uint) (*model.Product, error) {
 	// Find the product with the given id and store_id
 	var product model.Product
-	err := pr.db.DB.Where("id = ? AND store_id = ?", id, storeId).First(&product).Error
+	err := pr.db.DB.WithContext(ctx).Where("id = ? AND store_id = ?", id, storeId).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -82,32 +83,32 @@ func (pr *ProductRepository) UpdateProduct(p model.ProductResponse, id uint, sto
 	}
 
 	// Apply updates to the product
-	if err := pr.db.DB.Model(&product).Updates(updates).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Model(&product).Updates(updates).Error; err != nil {
 		return nil, err
 	}
 
 	// Fetch the updated product with related data
 	var updatedProduct model.Product
-	if err := pr.db.DB.Preload("Category").Where("id = ?", id).First(&updatedProduct).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Preload("Category").Where("id = ?", id).First(&updatedProduct).Error; err != nil {
 		return nil, err
 	}
 
-	updatedProduct.CollectionIDs = pr.fetchCollectionIDs(updatedProduct.ID)
+	updatedProduct.CollectionIDs = pr.fetchCollectionIDs(ctx, updatedProduct.ID)
 
 	return &updatedProduct, nil
 }
 
-func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.ProductRequest) (*model.Product, error) {
+func (pr *ProductRepository) CreateProduct(ctx context.Context, storeID uint, productRequest model.ProductRequest) (*model.Product, error) {
 	// Generate product slug
 
 	product := productRequest.CreateProduct(storeID)
 
-	err := pr.db.DB.Transaction(func(tx *gorm.DB) error {
+	err := pr.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// TODO: remove when adding Distributed Transaction
 		//add new store if not exist in database using firstorcreate
 		store := model.Store{ID: storeID}
 		if err := tx.FirstOrCreate(&store, store).Error; err != nil {
-			log.Println("Error creating store in database")
+			slog.ErrorContext(tx.Statement.Context, "Error creating store in database", "store_id", storeID, "error", err)
 			return err
 		}
 
@@ -127,7 +128,7 @@ func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.Pr
 
 		// Add Product to the database
 		if err := tx.Create(&product).Error; err != nil {
-			log.Println("Error creating product in database")
+			slog.ErrorContext(tx.Statement.Context, "Error creating product in database", "store_id", storeID, "error", err)
 			return err
 		}
 		var verifyProduct model.Product
@@ -145,7 +146,7 @@ func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.Pr
 
 			// Add the SKU to the database
 			if err := tx.Create(&sku).Error; err != nil {
-				log.Println("Error creating sku in database")
+				slog.ErrorContext(tx.Statement.Context, "Error creating sku in database", "product_id", product.ID, "error", err)
 				return err
 			}
 
@@ -155,7 +156,7 @@ func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.Pr
 
 				// Check if the variant already exists in the database or not and create it if it doesn't
 				if err := tx.FirstOrCreate(&variant, model.Variant{Name: variantRequest.Name}).Error; err != nil {
-					log.Println("Error creating variant in database")
+					slog.ErrorContext(tx.Statement.Context, "Error creating variant in database", "product_id", product.ID, "error", err)
 					return err
 				}
 
@@ -164,7 +165,7 @@ func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.Pr
 
 				// Add the SKU Variant to the database
 				if err := tx.Create(&skuVariant).Error; err != nil {
-					log.Println("Error creating sku variant in database")
+					slog.ErrorContext(tx.Statement.Context, "Error creating sku variant in database", "sku_id", sku.ID, "error", err)
 					return err
 				}
 			}
@@ -177,7 +178,7 @@ func (pr *ProductRepository) CreateProduct(storeID uint, productRequest model.Pr
 	return product, nil
 }
 
-func (pr *ProductRepository) GenerateProductSlug(name string, storeID uint) (string, error) {
+func (pr *ProductRepository) GenerateProductSlug(ctx context.Context, name string, storeID uint) (string, error) {
 	// Generate the base slug from the product name
 	baseSlug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	slug := baseSlug
@@ -186,7 +187,7 @@ func (pr *ProductRepository) GenerateProductSlug(name string, storeID uint) (str
 	// Loop to find a unique slug
 	for i := 1; ; i++ {
 		// Check if a product with the same slug and store_id already exists
-		pr.db.DB.Model(&model.Product{}).
+		pr.db.DB.WithContext(ctx).Model(&model.Product{}).
 			Where("slug = ? AND store_id = ?", slug, storeID).
 			Count(&count)
 
@@ -200,17 +201,17 @@ func (pr *ProductRepository) GenerateProductSlug(name string, storeID uint) (str
 	}
 }
 
-func (pr *ProductRepository) DeleteProduct(productID uint, storeID uint) error {
+func (pr *ProductRepository) DeleteProduct(ctx context.Context, productID uint, storeID uint) error {
 	var product model.Product
 
 	// First check if the product exists
-	if err := pr.db.DB.Where("id = ? AND store_id = ?", productID, storeID).First(&product).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Where("id = ? AND store_id = ?", productID, storeID).First(&product).Error; err != nil {
 		return err // This will return gorm.ErrRecordNotFound if the product doesn't exist
 	}
 
 	// If you need to load related data for cascade deletion, do it here
 	// But don't combine it with the delete operation
-	if err := pr.db.DB.Preload("SKUs.SKUVariants").Preload("SKUs.Variants").
+	if err := pr.db.DB.WithContext(ctx).Preload("SKUs.SKUVariants").Preload("SKUs.Variants").
 		Where("id = ?", productID).First(&product).Error; err != nil {
 		return err
 	}
@@ -220,20 +221,20 @@ func (pr *ProductRepository) DeleteProduct(productID uint, storeID uint) error {
 	// return pr.db.DB.Delete(&product).Error
 
 	// Option 2: Hard delete (completely removes the record)
-	return pr.db.DB.Unscoped().Delete(&product).Error
+	return pr.db.DB.WithContext(ctx).Unscoped().Delete(&product).Error
 }
 
-func (pr *ProductRepository) GetStoreProducts(storeID uint, limit, offset int) ([]model.Product, int64, error) {
+func (pr *ProductRepository) GetStoreProducts(ctx context.Context, storeID uint, limit, offset int) ([]model.Product, int64, error) {
 	products := []model.Product{}
 	var total int64
 
 	// Count total products for pagination info
-	if err := pr.db.DB.Model(&model.Product{}).Where("store_id = ?", storeID).Count(&total).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Model(&model.Product{}).Where("store_id = ?", storeID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Build the base query
-	query := pr.db.DB.Model(&model.Product{}).
+	query := pr.db.DB.WithContext(ctx).Model(&model.Product{}).
 		Preload("Category").
 		Preload("SKUs").
 		Where("store_id = ?", storeID).
@@ -257,7 +258,7 @@ func (pr *ProductRepository) GetStoreProducts(storeID uint, limit, offset int) (
 
 	// For each product, fetch the collection IDs
 	for i := range products {
-		products[i].CollectionIDs = pr.fetchCollectionIDs(products[i].ID)
+		products[i].CollectionIDs = pr.fetchCollectionIDs(ctx, products[i].ID)
 	}
 
 	if len(products) == 0 && offset == 0 {
@@ -267,10 +268,10 @@ func (pr *ProductRepository) GetStoreProducts(storeID uint, limit, offset int) (
 	return products, total, nil
 }
 
-func (pr *ProductRepository) GetProductDetails(productID uint, storeID uint) (*model.Product, error) {
+func (pr *ProductRepository) GetProductDetails(ctx context.Context, productID uint, storeID uint) (*model.Product, error) {
 	var product model.Product
 
-	result := pr.db.DB.Where("id=? AND store_id = ?", productID, storeID).
+	result := pr.db.DB.WithContext(ctx).Where("id=? AND store_id = ?", productID, storeID).
 		Preload("SKUs.SKUVariants").
 		Preload("SKUs.Variants").
 		Preload("Category").
@@ -283,15 +284,15 @@ func (pr *ProductRepository) GetProductDetails(productID uint, storeID uint) (*m
 	}
 
 	// Fetch collection IDs
-	product.CollectionIDs = pr.fetchCollectionIDs(product.ID)
+	product.CollectionIDs = pr.fetchCollectionIDs(ctx, product.ID)
 
 	return &product, nil
 }
 
-func (pr *ProductRepository) GetProductBySlug(slug string, storeID uint) (*model.Product, error) {
+func (pr *ProductRepository) GetProductBySlug(ctx context.Context, slug string, storeID uint) (*model.Product, error) {
 	var product model.Product
 
-	result := pr.db.DB.Where("slug = ? AND store_id = ?", slug, storeID).
+	result := pr.db.DB.WithContext(ctx).Where("slug = ? AND store_id = ?", slug, storeID).
 		Preload("SKUs.SKUVariants").
 		Preload("SKUs.Variants").
 		Preload("Category").
@@ -306,15 +307,15 @@ func (pr *ProductRepository) GetProductBySlug(slug string, storeID uint) (*model
 	}
 
 	// Fetch collection IDs
-	product.CollectionIDs = pr.fetchCollectionIDs(product.ID)
+	product.CollectionIDs = pr.fetchCollectionIDs(ctx, product.ID)
 
 	return &product, nil
 }
 
-func (pr *ProductRepository) GetRelatedProducts(productID uint, categoryID uint, storeID uint, limit int) ([]model.Product, error) {
+func (pr *ProductRepository) GetRelatedProducts(ctx context.Context, productID uint, categoryID uint, storeID uint, limit int) ([]model.Product, error) {
 	var products []model.Product
 
-	result := pr.db.DB.Where("id != ? AND category_id = ? AND store_id = ? AND published = ?",
+	result := pr.db.DB.WithContext(ctx).Where("id != ? AND category_id = ? AND store_id = ? AND published = ?",
 		productID, categoryID, storeID, true).
 		Order("RANDOM()").
 		Limit(limit).
@@ -324,35 +325,35 @@ func (pr *ProductRepository) GetRelatedProducts(productID uint, categoryID uint,
 
 	// Fetch collection IDs for each product
 	for i := range products {
-		products[i].CollectionIDs = pr.fetchCollectionIDs(products[i].ID)
+		products[i].CollectionIDs = pr.fetchCollectionIDs(ctx, products[i].ID)
 	}
 
 	return products, result.Error
 }
 
 // Helper method to fetch collection IDs for a product
-func (pr *ProductRepository) fetchCollectionIDs(productID uint) []uint {
+func (pr *ProductRepository) fetchCollectionIDs(ctx context.Context, productID uint) []uint {
 	var collectionIDs []uint
-	if err := pr.db.DB.Table("collection_products").
+	if err := pr.db.DB.WithContext(ctx).Table("collection_products").
 		Select("collection_id").
 		Where("product_id = ?", productID).
 		Pluck("collection_id", &collectionIDs).Error; err != nil {
 		// Just log the error and return empty array
-		log.Printf("Error fetching collection IDs for product %d: %v", productID, err)
+		slog.ErrorContext(ctx, "Error fetching collection IDs", "product_id", productID, "error", err)
 		return []uint{}
 	}
 	return collectionIDs
 }
 
 // GetProductsByStoreSlug retrieves all products for a store identified by its slug
-func (pr *ProductRepository) GetProductsByStoreSlug(storeSlug string, limit, offset int) ([]model.Product, uint, int64, error) {
+func (pr *ProductRepository) GetProductsByStoreSlug(ctx context.Context, storeSlug string, limit, offset int) ([]model.Product, uint, int64, error) {
 	products := []model.Product{}
 	var total int64
 	var storeID uint
 
 	// First, find the store by slug
 	var store model.Store
-	if err := pr.db.DB.Where("slug = ?", storeSlug).First(&store).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Where("slug = ?", storeSlug).First(&store).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, 0, 0, errors.New("store not found")
 		}
@@ -362,12 +363,12 @@ func (pr *ProductRepository) GetProductsByStoreSlug(storeSlug string, limit, off
 	storeID = store.ID
 
 	// Count total products for pagination info
-	if err := pr.db.DB.Model(&model.Product{}).Where("store_id = ?", storeID).Count(&total).Error; err != nil {
+	if err := pr.db.DB.WithContext(ctx).Model(&model.Product{}).Where("store_id = ?", storeID).Count(&total).Error; err != nil {
 		return nil, storeID, 0, err
 	}
 
 	// Build the base query
-	query := pr.db.DB.Model(&model.Product{}).
+	query := pr.db.DB.WithContext(ctx).Model(&model.Product{}).
 		Preload("Category").
 		Preload("SKUs").
 		Where("store_id = ?", storeID).
@@ -391,7 +392,7 @@ func (pr *ProductRepository) GetProductsByStoreSlug(storeSlug string, limit, off
 
 	// For each product, fetch the collection IDs
 	for i := range products {
-		products[i].CollectionIDs = pr.fetchCollectionIDs(products[i].ID)
+		products[i].CollectionIDs = pr.fetchCollectionIDs(ctx, products[i].ID)
 	}
 
 	if len(products) == 0 && offset == 0 {
@@ -400,12 +401,12 @@ func (pr *ProductRepository) GetProductsByStoreSlug(storeSlug string, limit, off
 
 	return products, storeID, total, nil
 }
-func (pr *ProductRepository) GetStoreProductsDashboard(storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
+func (pr *ProductRepository) GetStoreProductsDashboard(ctx context.Context, storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
 	var totalProducts int64
 	var productsChange float64
 
 	// Count total products in the current period
-	if err := pr.db.DB.Model(&model.Product{}).
+	if err := pr.db.DB.WithContext(ctx).Model(&model.Product{}).
 		Where("store_id = ? AND created_at BETWEEN ? AND ?", storeID, startDate, endDate).
 		Count(&totalProducts).Error; err != nil {
 		return nil, err
@@ -418,7 +419,7 @@ func (pr *ProductRepository) GetStoreProductsDashboard(storeID uint, startDate,
 
 	// Count products in the previous period
 	var previousCount int64
-	if err := pr.db.DB.Model(&model.Product{}).
+	if err := pr.db.DB.WithContext(ctx).Model(&model.Product{}).
 		Where("store_id = ? AND created_at BETWEEN ? AND ?", storeID, prevPeriodStart, prevPeriodEnd).
 		Count(&previousCount).Error; err != nil {
 		return nil, err
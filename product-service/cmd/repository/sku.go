@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/robaa12/product-service/cmd/database"
@@ -16,10 +17,10 @@ func NewSkuRepository(db *database.Database) *SkuRepository {
 	return &SkuRepository{db: db}
 }
 
-func (sr *SkuRepository) GetSku(skuID, productID, storeID uint) (*model.Sku, error) {
+func (sr *SkuRepository) GetSku(ctx context.Context, skuID, productID, storeID uint) (*model.Sku, error) {
 	var sku model.Sku
 	// Join Product and SKU tables and find the SKU with the given id, product_id and store_id in the database and preload the variants
-	result := sr.db.DB.Model(&model.Sku{}).
+	result := sr.db.DB.WithContext(ctx).Model(&model.Sku{}).
 		Joins("JOIN products ON skus.product_id = products.id").
 		Where("skus.id = ? AND skus.product_id = ? AND products.store_id = ?", skuID, productID, storeID).
 		Preload("Variants").Preload("SKUVariants").First(&sku)
@@ -31,10 +32,10 @@ func (sr *SkuRepository) GetSku(skuID, productID, storeID uint) (*model.Sku, err
 	}
 	return &sku, nil
 }
-func (sr *SkuRepository) GetSkus(storeID uint, skuIDs []uint) (*[]model.SKUProductResponse, error) {
+func (sr *SkuRepository) GetSkus(ctx context.Context, storeID uint, skuIDs []uint) (*[]model.SKUProductResponse, error) {
 
 	var skusResponse []model.SKUProductResponse
-	result := sr.db.DB.Model(&model.Sku{}).
+	result := sr.db.DB.WithContext(ctx).Model(&model.Sku{}).
 		Select("skus.id as sku_id, skus.name as sku_name, products.id as product_id, products.name as product_name, skus.image_url as image_url").
 		Joins("JOIN products ON skus.product_id = products.id").
 		Where("products.store_id = ? AND skus.id IN ?", storeID, skuIDs).
@@ -50,8 +51,8 @@ func (sr *SkuRepository) GetSkus(storeID uint, skuIDs []uint) (*[]model.SKUProdu
 
 }
 
-func (sr *SkuRepository) UpdateSku(sku *model.Sku, storeID uint) error {
-	result := sr.db.DB.Model(&model.Sku{}).
+func (sr *SkuRepository) UpdateSku(ctx context.Context, sku *model.Sku, storeID uint) error {
+	result := sr.db.DB.WithContext(ctx).Model(&model.Sku{}).
 		Joins("JOIN products ON skus.product_id = products.id").
 		Where("skus.id = ? AND skus.product_id = ? AND products.store_id = ?", sku.ID, sku.ProductID, storeID).
 		Updates(&sku)
@@ -60,9 +61,9 @@ func (sr *SkuRepository) UpdateSku(sku *model.Sku, storeID uint) error {
 	}
 	return result.Error
 }
-func (sr *SkuRepository) FindSku(skuID, productID, storeID uint) (*model.Sku, error) {
+func (sr *SkuRepository) FindSku(ctx context.Context, skuID, productID, storeID uint) (*model.Sku, error) {
 	var sku model.Sku
-	result := sr.db.DB.Model(&model.Sku{}).
+	result := sr.db.DB.WithContext(ctx).Model(&model.Sku{}).
 		Joins("JOIN products ON skus.product_id = products.id").
 		Where("skus.id = ? AND skus.product_id = ? AND products.store_id = ?", skuID, productID, storeID).
 		First(&sku)
@@ -74,8 +75,8 @@ func (sr *SkuRepository) FindSku(skuID, productID, storeID uint) (*model.Sku, er
 	}
 	return &sku, nil
 }
-func (sr *SkuRepository) DeleteSKU(sku *model.Sku, storeID uint) error {
-	result := sr.db.DB.Model(&model.Sku{}).
+func (sr *SkuRepository) DeleteSKU(ctx context.Context, sku *model.Sku, storeID uint) error {
+	result := sr.db.DB.WithContext(ctx).Model(&model.Sku{}).
 		Joins("JOIN products ON skus.product_id = products.id").
 		Where("skus.id = ? AND skus.product_id = ? AND products.store_id = ?", sku.ID, sku.ProductID, storeID).Unscoped().Delete(&sku)
 	if result.RowsAffected == 0 {
@@ -83,10 +84,10 @@ func (sr *SkuRepository) DeleteSKU(sku *model.Sku, storeID uint) error {
 	}
 	return result.Error
 }
-func (sr *SkuRepository) CreateSku(storeID uint, sku *model.Sku, variantsRequest []model.VariantRequest) (*model.Sku, error) {
+func (sr *SkuRepository) CreateSku(ctx context.Context, storeID uint, sku *model.Sku, variantsRequest []model.VariantRequest) (*model.Sku, error) {
 	// Check if the product exists in the store
 	var product model.Product
-	result := sr.db.DB.Where("id = ? AND store_id = ?", sku.ProductID, storeID).First(&product)
+	result := sr.db.DB.WithContext(ctx).Where("id = ? AND store_id = ?", sku.ProductID, storeID).First(&product)
 	if result.RowsAffected == 0 {
 		return nil, errors.New("product not found or doesn't belong to the store")
 	}
@@ -95,7 +96,7 @@ func (sr *SkuRepository) CreateSku(storeID uint, sku *model.Sku, variantsRequest
 	}
 
 	// make transaction
-	tx := sr.db.DB.Begin()
+	tx := sr.db.DB.WithContext(ctx).Begin()
 	// Add the SKU to the database
 	if err := tx.Create(&sku).Error; err != nil {
 		return nil, errors.New("error creating sku in database")
@@ -123,9 +124,9 @@ func (sr *SkuRepository) CreateSku(storeID uint, sku *model.Sku, variantsRequest
 }
 
 // UpdateInventory updates the inventory of the SKUs
-func (sr *SkuRepository) UpdateInventory(skus []model.Sku) error {
+func (sr *SkuRepository) UpdateInventory(ctx context.Context, skus []model.Sku) error {
 	// Update inventory for each SKU
-	tx := sr.db.DB.Begin()
+	tx := sr.db.DB.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/robaa12/product-service/cmd/database"
 	"github.com/robaa12/product-service/cmd/model"
 	"gorm.io/gorm"
@@ -15,8 +18,8 @@ func NewStoreRepository(db database.Database) *StoreRepository {
 }
 
 // create store to the database
-func (sr *StoreRepository) CreateStore(store *model.Store) error {
-	result := sr.db.DB.Create(store)
+func (sr *StoreRepository) CreateStore(ctx context.Context, store *model.Store) error {
+	result := sr.db.DB.WithContext(ctx).Create(store)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -24,31 +27,86 @@ func (sr *StoreRepository) CreateStore(store *model.Store) error {
 }
 
 // get store by slug from the database
-func (sr *StoreRepository) GetStoreBySlug(slug string) (*model.Store, error) {
+func (sr *StoreRepository) GetStoreBySlug(ctx context.Context, slug string) (*model.Store, error) {
 	result := &model.Store{}
-	err := sr.db.DB.Where("slug = ?", slug).First(result).Error
+	err := sr.db.DB.WithContext(ctx).Where("slug = ?", slug).First(result).Error
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-// delete store from the database
-func (sr *StoreRepository) DeleteStore(storeID uint) error {
-	result := sr.db.DB.Delete(&model.Store{}, storeID)
+// storeChildren are the tables archived along with their store: those with a store_id column and
+// the SKUs of the store's products.
+var storeChildren = []struct {
+	model any
+	where string
+}{
+	{&model.Sku{}, "product_id IN (SELECT id FROM products WHERE store_id = ?)"},
+	{&model.Product{}, "store_id = ?"},
+	{&model.Review{}, "store_id = ?"},
+	{&model.Collection{}, "store_id = ?"},
+	{&model.Category{}, "store_id = ?"},
+}
+
+// delete store from the database, archiving its products, SKUs, reviews, collections and categories
+// with it. They all get the store's deletion time, so restoring the store brings back what was
+// deleted with it but not what had been deleted before.
+func (sr *StoreRepository) DeleteStore(ctx context.Context, storeID uint) error {
+	// Postgres keeps microseconds, so the rows are matched up again by this exact value
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	return sr.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Store{}).Where("id = ?", storeID).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, child := range storeChildren {
+			if err := tx.Model(child.model).Where(child.where, storeID).Update("deleted_at", deletedAt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restore an archived store with what was archived along with it, and report whether it exists
+func (sr *StoreRepository) RestoreStore(ctx context.Context, storeID uint) (bool, error) {
+	store := &model.Store{}
+	result := sr.db.DB.WithContext(ctx).Unscoped().Where("id = ?", storeID).Limit(1).Find(store)
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
 	}
 	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+		return false, nil
 	}
-	return nil
+	if !store.DeletedAt.Valid {
+		return true, nil
+	}
+	deletedAt := store.DeletedAt.Time
+	return true, sr.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(store).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		for _, child := range storeChildren {
+			err := tx.Unscoped().Model(child.model).
+				Where(child.where, storeID).
+				Where("deleted_at = ?", deletedAt).
+				Update("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // get store by id from the database
-func (sr *StoreRepository) GetStoreByID(storeID uint) (*model.Store, error) {
+func (sr *StoreRepository) GetStoreByID(ctx context.Context, storeID uint) (*model.Store, error) {
 	result := &model.Store{}
-	err := sr.db.DB.First(result, storeID).Error
+	err := sr.db.DB.WithContext(ctx).First(result, storeID).Error
 	if err != nil {
 		return nil, err
 	}
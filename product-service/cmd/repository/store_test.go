@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/robaa12/product-service/cmd/database"
+	"github.com/robaa12/product-service/cmd/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openTestDatabase connects to the Postgres database named by TEST_DATABASE_DSN and migrates it.
+// Tests that need one are skipped when it is not set.
+func openTestDatabase(t *testing.T) database.Database {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	d := database.Database{DB: db}
+	if err := d.SetupDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDeleteStoreArchivesItsCatalogue(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDatabase(t)
+	stores := NewStoreRepository(db)
+	products := NewProductRepository(db)
+	reviews := NewReviewRepository(db)
+
+	storeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	slug := fmt.Sprintf("archived-store-%d", storeID)
+	t.Cleanup(func() {
+		db.DB.Exec("DELETE FROM skus WHERE product_id IN (SELECT id FROM products WHERE store_id = ?)", storeID)
+		for _, table := range []string{"reviews", "products", "collections", "categories", "stores"} {
+			column := "store_id"
+			if table == "stores" {
+				column = "id"
+			}
+			db.DB.Exec("DELETE FROM "+table+" WHERE "+column+" = ?", storeID)
+		}
+	})
+
+	if err := stores.CreateStore(ctx, &model.Store{ID: storeID, Name: "Archived", Slug: slug}); err != nil {
+		t.Fatal(err)
+	}
+	live := model.Product{StoreID: storeID, Name: "Mug", Slug: "mug", StartPrice: 10, MainImageURL: "mug.png",
+		SKUs: []model.Sku{{Name: "Mug", Stock: 3, Price: 10}}}
+	removed := model.Product{StoreID: storeID, Name: "Cup", Slug: "cup", StartPrice: 5, MainImageURL: "cup.png"}
+	for _, product := range []*model.Product{&live, &removed} {
+		if err := db.DB.Omit("Category").Create(product).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := reviews.CreateReview(ctx, &model.Review{ProductID: live.ID, StoreID: storeID, UserName: "Sara", Rating: 5}); err != nil {
+		t.Fatal(err)
+	}
+	// A product deleted before the store must stay deleted when the store is restored
+	if err := db.DB.Delete(&removed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.DeleteStore(ctx, storeID); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, total, err := products.GetStoreProducts(ctx, storeID, 0, 0); err != nil || len(got) != 0 || total != 0 {
+		t.Fatalf("GetStoreProducts of a deleted store = %d products (total %d), %v; want none", len(got), total, err)
+	}
+	if got, _, _, err := products.GetProductsByStoreSlug(ctx, slug, 0, 0); err == nil || len(got) != 0 {
+		t.Fatalf("GetProductsByStoreSlug of a deleted store = %d products, %v; want store not found", len(got), err)
+	}
+	if _, err := products.GetProduct(ctx, live.ID, storeID); err == nil {
+		t.Fatal("GetProduct found a product of a deleted store")
+	}
+	if got, _ := reviews.GetProductReviews(ctx, live.ID, storeID); len(got) != 0 {
+		t.Fatalf("GetProductReviews of a deleted store = %d reviews, want none", len(got))
+	}
+	var skus int64
+	db.DB.Model(&model.Sku{}).Where("product_id = ?", live.ID).Count(&skus)
+	if skus != 0 {
+		t.Fatalf("%d SKUs of a deleted store are still live", skus)
+	}
+
+	if found, err := stores.RestoreStore(ctx, storeID); err != nil || !found {
+		t.Fatalf("RestoreStore = %t, %v", found, err)
+	}
+
+	got, total, err := products.GetStoreProducts(ctx, storeID, 0, 0)
+	if err != nil || len(got) != 1 || total != 1 || got[0].ID != live.ID {
+		t.Fatalf("GetStoreProducts after restoring = %d products (total %d), %v; want only %q", len(got), total, err, live.Name)
+	}
+	if len(got[0].SKUs) != 1 {
+		t.Fatalf("restored product has %d SKUs, want 1", len(got[0].SKUs))
+	}
+	if got, _ := reviews.GetProductReviews(ctx, live.ID, storeID); len(got) != 1 {
+		t.Fatalf("GetProductReviews after restoring = %d reviews, want 1", len(got))
+	}
+}
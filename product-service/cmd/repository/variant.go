@@ -1,7 +1,8 @@
 package repository
 
 import (
-	"log"
+	"context"
+	"log/slog"
 
 	"github.com/robaa12/product-service/cmd/database"
 	"github.com/robaa12/product-service/cmd/model"
@@ -14,31 +15,31 @@ type VariantRepository struct {
 
 func AddVariant(v *model.Variant, tx *gorm.DB) error {
 	if err := tx.FirstOrCreate(&v, model.Variant{Name: v.Name}).Error; err != nil {
-		log.Println("Error creating variant in database")
+		slog.Error("Error creating variant in database", "error", err)
 		return err
 	}
 	return nil
 }
 func AddSKUVariant(sv *model.SKUVariant, tx *gorm.DB) error {
 	if err := tx.Create(&sv).Error; err != nil {
-		log.Println("Error creating sku variant in database")
+		slog.Error("Error creating sku variant in database", "error", err)
 		return err
 	}
 	return nil
 }
 
-func (vr *VariantRepository) GetVariant(v model.Variant, id int) error {
-	return vr.db.DB.First(v, id).Error
+func (vr *VariantRepository) GetVariant(ctx context.Context, v model.Variant, id int) error {
+	return vr.db.DB.WithContext(ctx).First(v, id).Error
 }
 
-func (vr *VariantRepository) UpdateVariant(v model.Variant) error {
-	return vr.db.DB.Save(v).Error
+func (vr *VariantRepository) UpdateVariant(ctx context.Context, v model.Variant) error {
+	return vr.db.DB.WithContext(ctx).Save(v).Error
 }
 
-func (vr *VariantRepository) CreateVariant(v model.Variant) error {
-	return vr.db.DB.Create(v).Error
+func (vr *VariantRepository) CreateVariant(ctx context.Context, v model.Variant) error {
+	return vr.db.DB.WithContext(ctx).Create(v).Error
 }
 
-func (vr *VariantRepository) DeleteVariant(v model.Variant) error {
-	return vr.db.DB.Delete(v).Error
+func (vr *VariantRepository) DeleteVariant(ctx context.Context, v model.Variant) error {
+	return vr.db.DB.WithContext(ctx).Delete(v).Error
 }
@@ -1,9 +1,9 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	apperrors "github.com/robaa12/product-service/cmd/errors"
 	"github.com/robaa12/product-service/cmd/model"
@@ -18,22 +18,26 @@ func NewCategoryService(repository *repository.CategoryRepository) *CategoryServ
 	return &CategoryService{repository: repository}
 }
 
-func (cs *CategoryService) CreateCategory(storeID uint, categoryRequest *model.CategoryRequest) (*model.CategoryResponse, error) {
+func (cs *CategoryService) CreateCategory(ctx context.Context, storeID uint, categoryRequest *model.CategoryRequest) (*model.CategoryResponse, error) {
+	var invalid []apperrors.FieldError
 	if categoryRequest.Name == "" {
-		return nil, errors.New("category name is required")
+		invalid = append(invalid, apperrors.FieldError{Field: "name", Message: "category name is required"})
 	}
 	if categoryRequest.Description == "" {
-		return nil, errors.New("category description is required")
+		invalid = append(invalid, apperrors.FieldError{Field: "description", Message: "category description is required"})
+	}
+	if len(invalid) > 0 {
+		return nil, apperrors.NewValidationError(invalid[0].Message, invalid...)
 	}
 	category := categoryRequest.ToCategory(storeID)
-	slug, err := cs.repository.GenerateCategorySlug(category.Name, category.StoreID)
+	slug, err := cs.repository.GenerateCategorySlug(ctx, category.Name, category.StoreID)
 	fmt.Println(slug)
 	if err != nil {
-		log.Println("Error Generating Category's Slug")
+		slog.ErrorContext(ctx, "Error Generating Category's Slug", "store_id", storeID, "error", err)
 		return nil, err
 	}
 	category.Slug = slug
-	err = cs.repository.CreateCategory(category)
+	err = cs.repository.CreateCategory(ctx, category)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -44,10 +48,10 @@ func (cs *CategoryService) CreateCategory(storeID uint, categoryRequest *model.C
 }
 
 // GetCategories - GET /stores/{store_id}/categories/
-func (cs *CategoryService) GetCategories(storeID uint) (*model.CategoriesResponse, error) {
+func (cs *CategoryService) GetCategories(ctx context.Context, storeID uint) (*model.CategoriesResponse, error) {
 
 	// Get categories from the database by store ID
-	categories, err := cs.repository.GetStoreCategories(storeID)
+	categories, err := cs.repository.GetStoreCategories(ctx, storeID)
 	//err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -61,10 +65,10 @@ func (cs *CategoryService) GetCategories(storeID uint) (*model.CategoriesRespons
 }
 
 // GetCategoryByID - GET /stores/{store_id}/categories/{category_id}
-func (cs *CategoryService) GetCategoryByID(storeID, categoryID uint) (*model.CategoryDetailsResponse, error) {
+func (cs *CategoryService) GetCategoryByID(ctx context.Context, storeID, categoryID uint) (*model.CategoryDetailsResponse, error) {
 
 	// Get category from the database by store ID and category ID
-	category, err := cs.repository.GetCategoryByID(storeID, categoryID)
+	category, err := cs.repository.GetCategoryByID(ctx, storeID, categoryID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -74,10 +78,10 @@ func (cs *CategoryService) GetCategoryByID(storeID, categoryID uint) (*model.Cat
 }
 
 // GetCategoryBySlug - GET /stores/{store_id}/categories/{category_slug}
-func (cs *CategoryService) GetCategoryBySlug(storeID uint, CategorySlug string) (*model.CategoryDetailsResponse, error) {
+func (cs *CategoryService) GetCategoryBySlug(ctx context.Context, storeID uint, CategorySlug string) (*model.CategoryDetailsResponse, error) {
 
 	// Get category from the database by store ID and category slug
-	category, err := cs.repository.GetCategoryBySlug(storeID, CategorySlug)
+	category, err := cs.repository.GetCategoryBySlug(ctx, storeID, CategorySlug)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -87,31 +91,31 @@ func (cs *CategoryService) GetCategoryBySlug(storeID uint, CategorySlug string)
 }
 
 // UpdateCategory Update Category - POST /stores/{store_id}/categories/{category_id}
-func (cs *CategoryService) UpdateCategory(storeID, categoryID uint) error {
+func (cs *CategoryService) UpdateCategory(ctx context.Context, storeID, categoryID uint) error {
 	// Validate category
-	category, err := cs.repository.FindCategory(storeID, categoryID)
+	category, err := cs.repository.FindCategory(ctx, storeID, categoryID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// update category
-	err = cs.repository.UpdateCategory(storeID, categoryID, category)
+	err = cs.repository.UpdateCategory(ctx, storeID, categoryID, category)
 	err = apperrors.ErrCheck(err)
 	return err
 }
 
 // DeleteCategory Delete category  - DELETE /stores/{store_id}/categories/{category_id}
-func (cs *CategoryService) DeleteCategory(storeID, categoryID uint) error {
+func (cs *CategoryService) DeleteCategory(ctx context.Context, storeID, categoryID uint) error {
 	// Validate category
-	category, err := cs.repository.FindCategory(storeID, categoryID)
+	category, err := cs.repository.FindCategory(ctx, storeID, categoryID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// Delete category
-	err = cs.repository.DeleteCategory(category)
+	err = cs.repository.DeleteCategory(ctx, category)
 	err = apperrors.ErrCheck(err)
 	return err
 }
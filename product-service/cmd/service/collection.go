@@ -1,8 +1,8 @@
 package service
 
 import (
-	"errors"
-	"log"
+	"context"
+	"log/slog"
 
 	apperrors "github.com/robaa12/product-service/cmd/errors"
 	"github.com/robaa12/product-service/cmd/model"
@@ -17,21 +17,25 @@ func NewCollectionService(repository *repository.CollectionRepository) *Collecti
 	return &CollectionService{repository: repository}
 }
 
-func (cs *CollectionService) CreateCollection(storeID uint, collectionRequest *model.CollectionRequest) (*model.CollectionResponse, error) {
+func (cs *CollectionService) CreateCollection(ctx context.Context, storeID uint, collectionRequest *model.CollectionRequest) (*model.CollectionResponse, error) {
+	var invalid []apperrors.FieldError
 	if collectionRequest.Name == "" {
-		return nil, errors.New("collection name is required")
+		invalid = append(invalid, apperrors.FieldError{Field: "name", Message: "collection name is required"})
 	}
 	if collectionRequest.Description == "" {
-		return nil, errors.New("collection description is required")
+		invalid = append(invalid, apperrors.FieldError{Field: "description", Message: "collection description is required"})
+	}
+	if len(invalid) > 0 {
+		return nil, apperrors.NewValidationError(invalid[0].Message, invalid...)
 	}
 	collection := collectionRequest.ToCollection(storeID)
-	slug, err := cs.repository.GenerateCollectionSlug(collection.Name, collection.StoreID)
+	slug, err := cs.repository.GenerateCollectionSlug(ctx, collection.Name, collection.StoreID)
 	if err != nil {
-		log.Println("Error Generating Collection's Slug")
+		slog.ErrorContext(ctx, "Error Generating Collection's Slug", "store_id", storeID, "error", err)
 		return nil, err
 	}
 	collection.Slug = slug
-	err = cs.repository.CreateCollection(collection)
+	err = cs.repository.CreateCollection(ctx, collection)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -42,10 +46,10 @@ func (cs *CollectionService) CreateCollection(storeID uint, collectionRequest *m
 }
 
 // GetCollections - GET /stores/{store_id}/collections/
-func (cs *CollectionService) GetCollections(storeID uint) (*model.CollectionsResponse, error) {
+func (cs *CollectionService) GetCollections(ctx context.Context, storeID uint) (*model.CollectionsResponse, error) {
 
 	// Get collections from the database by store ID
-	collections, err := cs.repository.GetStoreCollections(storeID)
+	collections, err := cs.repository.GetStoreCollections(ctx, storeID)
 	// err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -56,10 +60,10 @@ func (cs *CollectionService) GetCollections(storeID uint) (*model.CollectionsRes
 }
 
 // GetCollection - GET /stores/{store_id}/collections/{collection_id}
-func (cs *CollectionService) GetCollection(storeID, collectionID uint) (*model.CollectionDetailsResponse, error) {
+func (cs *CollectionService) GetCollection(ctx context.Context, storeID, collectionID uint) (*model.CollectionDetailsResponse, error) {
 
 	// Get collection from the database by store ID and collection ID
-	collection, err := cs.repository.GetCollectionByID(storeID, collectionID)
+	collection, err := cs.repository.GetCollectionByID(ctx, storeID, collectionID)
 	//err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -69,66 +73,66 @@ func (cs *CollectionService) GetCollection(storeID, collectionID uint) (*model.C
 }
 
 // AddProductToCollection Add product to collection - POST /stores/{store_id}/collections/{collection_id}
-func (cs *CollectionService) AddProductToCollection(storeID, collectionID uint, collectionProductsRequest *model.CollectionProductsRequest) error {
+func (cs *CollectionService) AddProductToCollection(ctx context.Context, storeID, collectionID uint, collectionProductsRequest *model.CollectionProductsRequest) error {
 
 	// Validate collection
-	collection, err := cs.repository.FindCollection(storeID, collectionID)
+	collection, err := cs.repository.FindCollection(ctx, storeID, collectionID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// Validate products
-	products, err := cs.repository.FindProducts(storeID, collectionProductsRequest)
+	products, err := cs.repository.FindProducts(ctx, storeID, collectionProductsRequest)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 	// Add products to collection
-	err = cs.repository.AddProductsToCollection(collection, products)
+	err = cs.repository.AddProductsToCollection(ctx, collection, products)
 	err = apperrors.ErrCheck(err)
 	return err
 }
 
 // RemoveProductFromCollection Remove product from collection - DELETE /stores/{store_id}/collections/{collection_id}/products/{product_id}
-func (cs *CollectionService) RemoveProductFromCollection(storeID, collectionID, productID uint) error {
+func (cs *CollectionService) RemoveProductFromCollection(ctx context.Context, storeID, collectionID, productID uint) error {
 	// Validate collection Product
-	collection, err := cs.repository.FindCollectionProduct(storeID, collectionID, productID)
+	collection, err := cs.repository.FindCollectionProduct(ctx, storeID, collectionID, productID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// Remove product from collection
-	err = cs.repository.RemoveProductFromCollection(collection.ID, collection.Products[0].ID)
+	err = cs.repository.RemoveProductFromCollection(ctx, collection.ID, collection.Products[0].ID)
 	err = apperrors.ErrCheck(err)
 	return err
 }
 
-func (cs *CollectionService) DeleteCollection(storeID, collectionID uint) error {
+func (cs *CollectionService) DeleteCollection(ctx context.Context, storeID, collectionID uint) error {
 	// Validate collection
-	collection, err := cs.repository.FindCollection(storeID, collectionID)
+	collection, err := cs.repository.FindCollection(ctx, storeID, collectionID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// Delete collection
-	err = cs.repository.DeleteCollection(collection.ID)
+	err = cs.repository.DeleteCollection(ctx, collection.ID)
 	err = apperrors.ErrCheck(err)
 	return err
 }
 
-func (cs *CollectionService) UpdateCollection(storeID, collectionID uint, collectionRequest *model.CollectionRequest) error {
+func (cs *CollectionService) UpdateCollection(ctx context.Context, storeID, collectionID uint, collectionRequest *model.CollectionRequest) error {
 	// Validate collection
-	collection, err := cs.repository.FindCollection(storeID, collectionID)
+	collection, err := cs.repository.FindCollection(ctx, storeID, collectionID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
 
 	// Update collection
-	err = cs.repository.UpdateCollection(collection.ID, collectionRequest)
+	err = cs.repository.UpdateCollection(ctx, collection.ID, collectionRequest)
 	err = apperrors.ErrCheck(err)
 	return err
 }
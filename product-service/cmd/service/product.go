@@ -1,8 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	apperrors "github.com/robaa12/product-service/cmd/errors"
@@ -24,7 +25,7 @@ func NewProductService(repo *repository.ProductRepository, reviewSvc *ReviewServ
 }
 
 // NewProduct creates a new product , skus and variants in the database
-func (ps *ProductService) NewProduct(storeID uint, productRequest model.ProductRequest) (*model.ProductResponse, error) {
+func (ps *ProductService) NewProduct(ctx context.Context, storeID uint, productRequest model.ProductRequest) (*model.ProductResponse, error) {
 	// Product Request Validation
 	if len(productRequest.Name) > 255 {
 		return nil, errors.New("product name cannot exceed 255 characters")
@@ -49,14 +50,14 @@ func (ps *ProductService) NewProduct(storeID uint, productRequest model.ProductR
 		return nil, err
 	}
 
-	slug, err := ps.repository.GenerateProductSlug(productRequest.Name, storeID)
+	slug, err := ps.repository.GenerateProductSlug(ctx, productRequest.Name, storeID)
 	if err != nil {
-		log.Println("Error Generating Product's Slug")
+		slog.ErrorContext(ctx, "Error Generating Product's Slug", "store_id", storeID, "error", err)
 		return nil, err
 	}
 	productRequest.Slug = slug
 
-	product, err := ps.repository.CreateProduct(storeID, productRequest)
+	product, err := ps.repository.CreateProduct(ctx, storeID, productRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -64,8 +65,8 @@ func (ps *ProductService) NewProduct(storeID uint, productRequest model.ProductR
 	return productResponse, nil
 }
 
-func (ps *ProductService) GetProduct(id uint, storeID uint) (*model.ProductResponse, error) {
-	product, err := ps.repository.GetProduct(id, storeID)
+func (ps *ProductService) GetProduct(ctx context.Context, id uint, storeID uint) (*model.ProductResponse, error) {
+	product, err := ps.repository.GetProduct(ctx, id, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -74,24 +75,24 @@ func (ps *ProductService) GetProduct(id uint, storeID uint) (*model.ProductRespo
 	return productResponse, nil
 }
 
-func (ps *ProductService) UpdateProduct(id, storeID uint, productResponse model.ProductResponse) (*model.ProductResponse, error) {
+func (ps *ProductService) UpdateProduct(ctx context.Context, id, storeID uint, productResponse model.ProductResponse) (*model.ProductResponse, error) {
 	// Check if the product exists
-	product, err := ps.repository.GetProduct(id, storeID)
+	product, err := ps.repository.GetProduct(ctx, id, storeID)
 	if err != nil {
 		return nil, err
 	}
 
 	if product.Name != productResponse.Name {
-		slug, err := ps.repository.GenerateProductSlug(productResponse.Name, product.StoreID)
+		slug, err := ps.repository.GenerateProductSlug(ctx, productResponse.Name, product.StoreID)
 		if err != nil {
-			log.Println("Error Generating Product's Slug")
+			slog.ErrorContext(ctx, "Error Generating Product's Slug", "store_id", storeID, "error", err)
 			return nil, err
 		}
 		productResponse.Slug = slug
 	}
 
 	// Update the product
-	updatedProduct, err := ps.repository.UpdateProduct(productResponse, id, storeID)
+	updatedProduct, err := ps.repository.UpdateProduct(ctx, productResponse, id, storeID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,38 +101,38 @@ func (ps *ProductService) UpdateProduct(id, storeID uint, productResponse model.
 	return updatedProduct.ToProductResponse(), nil
 }
 
-func (ps *ProductService) DeleteProduct(productID uint, storeID uint) error {
+func (ps *ProductService) DeleteProduct(ctx context.Context, productID uint, storeID uint) error {
 	// Call the repository to delete the product
-	err := ps.repository.DeleteProduct(productID, storeID)
+	err := ps.repository.DeleteProduct(ctx, productID, storeID)
 	return apperrors.ErrCheck(err)
 }
 
-func (ps *ProductService) GetStoreProducts(storeID uint, limit, offset int) (*model.PaginatedProductsResponse, error) {
+func (ps *ProductService) GetStoreProducts(ctx context.Context, storeID uint, limit, offset int) (*model.PaginatedProductsResponse, error) {
 	// Check if we're fetching all products or using pagination
 	isPaginated := limit > 0
 
 	if isPaginated {
-		log.Printf("GetStoreProducts: Paginated request - storeID=%d, limit=%d, offset=%d", storeID, limit, offset)
+		slog.DebugContext(ctx, "GetStoreProducts: Paginated request", "store_id", storeID, "limit", limit, "offset", offset)
 	} else {
-		log.Printf("GetStoreProducts: Fetching all products for storeID=%d", storeID)
+		slog.DebugContext(ctx, "GetStoreProducts: Fetching all products", "store_id", storeID)
 	}
 
 	// Call the repository to get the products
-	products, total, err := ps.repository.GetStoreProducts(storeID, limit, offset)
+	products, total, err := ps.repository.GetStoreProducts(ctx, storeID, limit, offset)
 	err = apperrors.ErrCheck(err)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		log.Printf("Error getting products: %v", err)
+		slog.ErrorContext(ctx, "Error getting products", "store_id", storeID, "error", err)
 		return nil, err
 	}
 
-	log.Printf("Retrieved %d products out of total %d", len(products), total)
+	slog.DebugContext(ctx, "Retrieved products", "store_id", storeID, "count", len(products), "total", total)
 
 	// Create response with pagination info
 	paginatedResponse := model.GetPaginatedProductsResponse(products, total, limit, offset, isPaginated)
 	return paginatedResponse, nil
 }
 
-func (ps *ProductService) GetStoreProductsDashboard(storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
+func (ps *ProductService) GetStoreProductsDashboard(ctx context.Context, storeID uint, startDate, endDate time.Time) (*model.ProductsDashboardResponse, error) {
 	if startDate.IsZero() || endDate.IsZero() {
 		startDate = time.Now().AddDate(0, -30, 0) // Default to 30 days ago
 		if endDate.IsZero() {
@@ -140,20 +141,20 @@ func (ps *ProductService) GetStoreProductsDashboard(storeID uint, startDate, end
 	}
 
 	// Call the repository to get the products
-	productsDashboardResponse, err := ps.repository.GetStoreProductsDashboard(storeID, startDate, endDate)
+	productsDashboardResponse, err := ps.repository.GetStoreProductsDashboard(ctx, storeID, startDate, endDate)
 	err = apperrors.ErrCheck(err)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		log.Printf("Error getting products: %v", err)
+		slog.ErrorContext(ctx, "Error getting products", "store_id", storeID, "error", err)
 		return nil, err
 	}
 
-	log.Printf("Retrieved %v products dashboard", productsDashboardResponse)
+	slog.DebugContext(ctx, "Retrieved products dashboard", "store_id", storeID)
 
 	return productsDashboardResponse, nil
 }
-func (ps *ProductService) GetProductDetails(productID, storeID uint) (*model.ProductDetailsResponse, error) {
+func (ps *ProductService) GetProductDetails(ctx context.Context, productID, storeID uint) (*model.ProductDetailsResponse, error) {
 	// Call the repository to get the product with details
-	product, err := ps.repository.GetProductDetails(productID, storeID)
+	product, err := ps.repository.GetProductDetails(ctx, productID, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -164,7 +165,7 @@ func (ps *ProductService) GetProductDetails(productID, storeID uint) (*model.Pro
 
 	// Get the review statistics
 	if ps.reviewService != nil {
-		reviewsStats, err := ps.reviewService.GetReviewStatistics(productID, storeID)
+		reviewsStats, err := ps.reviewService.GetReviewStatistics(ctx, productID, storeID)
 		if err != nil {
 			return nil, err
 		}
@@ -174,13 +175,13 @@ func (ps *ProductService) GetProductDetails(productID, storeID uint) (*model.Pro
 	return productDetailsResponse, nil
 }
 
-func (ps *ProductService) GetProductBySlug(slug string, storeID uint) (*model.ProductDetailsResponse, error) {
+func (ps *ProductService) GetProductBySlug(ctx context.Context, slug string, storeID uint) (*model.ProductDetailsResponse, error) {
 	if slug == "" || storeID == 0 {
 		return nil, errors.New("both slug and store_id are required")
 	}
 
 	// Call repository to get the product
-	product, err := ps.repository.GetProductBySlug(slug, storeID)
+	product, err := ps.repository.GetProductBySlug(ctx, slug, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -190,7 +191,7 @@ func (ps *ProductService) GetProductBySlug(slug string, storeID uint) (*model.Pr
 	productDetailsResponse := product.ToProductDetailsResponse()
 
 	if product.CategoryID != nil {
-		relatedProducts, err := ps.repository.GetRelatedProducts(product.ID, *product.CategoryID, storeID, 4)
+		relatedProducts, err := ps.repository.GetRelatedProducts(ctx, product.ID, *product.CategoryID, storeID, 4)
 		if err == nil {
 			relatedProductResponses := make([]model.ProductResponse, 0, len(relatedProducts))
 			for _, rp := range relatedProducts {
@@ -201,7 +202,7 @@ func (ps *ProductService) GetProductBySlug(slug string, storeID uint) (*model.Pr
 	}
 
 	if ps.reviewService != nil {
-		reviewsStats, err := ps.reviewService.GetReviewStatistics(product.ID, storeID)
+		reviewsStats, err := ps.reviewService.GetReviewStatistics(ctx, product.ID, storeID)
 		if err != nil {
 			return nil, err
 		}
@@ -234,23 +235,23 @@ func validateProductImages(product model.ProductRequest) error {
 }
 
 // GetProductsByStoreSlug retrieves all products for a store identified by its slug
-func (ps *ProductService) GetProductsByStoreSlug(storeSlug string, limit, offset int) (*model.PaginatedProductsResponse, error) {
+func (ps *ProductService) GetProductsByStoreSlug(ctx context.Context, storeSlug string, limit, offset int) (*model.PaginatedProductsResponse, error) {
 	// Check if we're fetching all products or using pagination
 	isPaginated := limit > 0
 
 	if isPaginated {
-		log.Printf("GetProductsByStoreSlug: Paginated request - storeSlug=%s, limit=%d, offset=%d", storeSlug, limit, offset)
+		slog.DebugContext(ctx, "GetProductsByStoreSlug: Paginated request", "store_slug", storeSlug, "limit", limit, "offset", offset)
 	} else {
-		log.Printf("GetProductsByStoreSlug: Fetching all products for storeSlug=%s", storeSlug)
+		slog.DebugContext(ctx, "GetProductsByStoreSlug: Fetching all products", "store_slug", storeSlug)
 	}
 
 	// Call the repository to get the products
-	products, storeID, total, err := ps.repository.GetProductsByStoreSlug(storeSlug, limit, offset)
+	products, storeID, total, err := ps.repository.GetProductsByStoreSlug(ctx, storeSlug, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Retrieved %d products out of total %d for store %d (slug: %s)", len(products), total, storeID, storeSlug)
+	slog.DebugContext(ctx, "Retrieved products", "store_id", storeID, "store_slug", storeSlug, "count", len(products), "total", total)
 
 	// Create response with pagination info
 	paginatedResponse := model.GetPaginatedProductsResponse(products, total, limit, offset, isPaginated)
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	apperrors "github.com/robaa12/product-service/cmd/errors"
 	"github.com/robaa12/product-service/cmd/model"
 	"github.com/robaa12/product-service/cmd/repository"
@@ -14,9 +16,9 @@ func NewReviewService(reviewRepo *repository.ReviewRepository) *ReviewService {
 	return &ReviewService{reviewRepo: reviewRepo}
 }
 
-func (rs *ReviewService) CreateReview(productID, storeID uint, reviewRequest *model.ReviewRequest) (*model.ReviewResponse, error) {
+func (rs *ReviewService) CreateReview(ctx context.Context, productID, storeID uint, reviewRequest *model.ReviewRequest) (*model.ReviewResponse, error) {
 	// Check if product exists
-	exists, err := rs.reviewRepo.ProductExists(productID, storeID)
+	exists, err := rs.reviewRepo.ProductExists(ctx, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
@@ -27,15 +29,15 @@ func (rs *ReviewService) CreateReview(productID, storeID uint, reviewRequest *mo
 	// Create new review
 	review := reviewRequest.ToReview(productID, storeID)
 
-	err = rs.reviewRepo.CreateReview(review)
+	err = rs.reviewRepo.CreateReview(ctx, review)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
 	return review.ToReviewResponse(), nil
 }
 
-func (rs *ReviewService) GetProductReviews(productID, storeID uint) (*model.ReviewsResponse, error) {
-	exists, err := rs.reviewRepo.ProductExists(productID, storeID)
+func (rs *ReviewService) GetProductReviews(ctx context.Context, productID, storeID uint) (*model.ReviewsResponse, error) {
+	exists, err := rs.reviewRepo.ProductExists(ctx, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
@@ -43,7 +45,7 @@ func (rs *ReviewService) GetProductReviews(productID, storeID uint) (*model.Revi
 		return nil, apperrors.NewNotFoundError("product not found")
 	}
 
-	reviews, err := rs.reviewRepo.GetProductReviews(productID, storeID)
+	reviews, err := rs.reviewRepo.GetProductReviews(ctx, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
@@ -51,16 +53,16 @@ func (rs *ReviewService) GetProductReviews(productID, storeID uint) (*model.Revi
 	return reviewResponses, nil
 }
 
-func (rs *ReviewService) GetReview(reviewID, productID, storeID uint) (*model.ReviewResponse, error) {
-	review, err := rs.reviewRepo.GetReview(reviewID, productID, storeID)
+func (rs *ReviewService) GetReview(ctx context.Context, reviewID, productID, storeID uint) (*model.ReviewResponse, error) {
+	review, err := rs.reviewRepo.GetReview(ctx, reviewID, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
 	return review.ToReviewResponse(), nil
 }
 
-func (rs *ReviewService) GetReviewStatistics(productID, storeID uint) (*model.ProductReviewsStatistics, error) {
-	exists, err := rs.reviewRepo.ProductExists(productID, storeID)
+func (rs *ReviewService) GetReviewStatistics(ctx context.Context, productID, storeID uint) (*model.ProductReviewsStatistics, error) {
+	exists, err := rs.reviewRepo.ProductExists(ctx, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
@@ -68,7 +70,7 @@ func (rs *ReviewService) GetReviewStatistics(productID, storeID uint) (*model.Pr
 		return nil, apperrors.NewNotFoundError("product not found")
 	}
 
-	stats, err := rs.reviewRepo.GetReviewStatistics(productID, storeID)
+	stats, err := rs.reviewRepo.GetReviewStatistics(ctx, productID, storeID)
 	if err != nil {
 		return nil, apperrors.ErrCheck(err)
 	}
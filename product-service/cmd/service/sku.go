@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	apperrors "github.com/robaa12/product-service/cmd/errors"
 	"github.com/robaa12/product-service/cmd/model"
 	"github.com/robaa12/product-service/cmd/repository"
@@ -16,16 +18,16 @@ func NewSKUService(r *repository.SkuRepository) *SKUService {
 
 // GetStoreProducts returns all products of a store
 
-func (s *SKUService) UpdateSKU(skuID, productID, storeID uint, skuRequest *model.SKURequest) error {
+func (s *SKUService) UpdateSKU(ctx context.Context, skuID, productID, storeID uint, skuRequest *model.SKURequest) error {
 	sku := skuRequest.CreateSKU(productID)
 	sku.ID = skuID
 	// Find SKU by ID
-	_, err := s.repository.FindSku(skuID, sku.ProductID, storeID)
+	_, err := s.repository.FindSku(ctx, skuID, sku.ProductID, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
-	err = s.repository.UpdateSku(sku, storeID)
+	err = s.repository.UpdateSku(ctx, sku, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
@@ -34,10 +36,10 @@ func (s *SKUService) UpdateSKU(skuID, productID, storeID uint, skuRequest *model
 	return nil
 }
 
-func (s *SKUService) GetSKU(skuID, productID, storeID uint) (*model.SKUResponse, error) {
+func (s *SKUService) GetSKU(ctx context.Context, skuID, productID, storeID uint) (*model.SKUResponse, error) {
 
 	// Find SKU by ID
-	sku, err := s.repository.GetSku(skuID, productID, storeID)
+	sku, err := s.repository.GetSku(ctx, skuID, productID, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -47,10 +49,10 @@ func (s *SKUService) GetSKU(skuID, productID, storeID uint) (*model.SKUResponse,
 	return skuResponse, nil
 }
 
-func (s *SKUService) GetSKUs(storeID uint, skusRequest *model.SKUsRequest) (*model.SKUsResponse, error) {
+func (s *SKUService) GetSKUs(ctx context.Context, storeID uint, skusRequest *model.SKUsRequest) (*model.SKUsResponse, error) {
 
 	// Find SKU by ID
-	skus, err := s.repository.GetSkus(storeID, skusRequest.IDs)
+	skus, err := s.repository.GetSkus(ctx, storeID, skusRequest.IDs)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
@@ -65,15 +67,15 @@ func (s *SKUService) GetSKUs(storeID uint, skusRequest *model.SKUsRequest) (*mod
 	}
 	return skuResponse, nil
 }
-func (s *SKUService) DeleteSKU(skuID, productID, storeID uint) error {
+func (s *SKUService) DeleteSKU(ctx context.Context, skuID, productID, storeID uint) error {
 
 	// Find SKU by ID
-	sku, err := s.repository.FindSku(skuID, productID, storeID)
+	sku, err := s.repository.FindSku(ctx, skuID, productID, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
 	}
-	err = s.repository.DeleteSKU(sku, storeID)
+	err = s.repository.DeleteSKU(ctx, sku, storeID)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return err
@@ -81,14 +83,14 @@ func (s *SKUService) DeleteSKU(skuID, productID, storeID uint) error {
 	return nil
 }
 
-func (s *SKUService) NewSKU(storeID, productID uint, skuRequest *model.SKURequest) (*model.SKUResponse, error) {
+func (s *SKUService) NewSKU(ctx context.Context, storeID, productID uint, skuRequest *model.SKURequest) (*model.SKUResponse, error) {
 	// check if the product exists in the store
 
 	sku := skuRequest.CreateSKU(productID)
 
 	// Start Database Transaction
 	// Create a new SKU
-	sku, err := s.repository.CreateSku(storeID, sku, skuRequest.Variants)
+	sku, err := s.repository.CreateSku(ctx, storeID, sku, skuRequest.Variants)
 	err = apperrors.ErrCheck(err)
 	if err != nil {
 		return nil, err
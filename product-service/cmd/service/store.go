@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
+	"errors"
+
 	apperrors "github.com/robaa12/product-service/cmd/errors"
 	"github.com/robaa12/product-service/cmd/model"
 	"github.com/robaa12/product-service/cmd/repository"
+	"gorm.io/gorm"
 )
 
 type StoreService struct {
@@ -17,11 +21,11 @@ func NewStoreService(repo *repository.StoreRepository) *StoreService {
 }
 
 // / CreateStore creates a new store in the database
-func (s *StoreService) CreateStore(storeRequest *model.StoreRequest) (*model.StoreResponse, error) {
+func (s *StoreService) CreateStore(ctx context.Context, storeRequest *model.StoreRequest) (*model.StoreResponse, error) {
 	// Create a new store in the database
 	store := storeRequest.ToStore()
 	// Check if the store already exists
-	/*existingStore, err := s.repo.GetStoreByID(store.ID)
+	/*existingStore, err := s.repo.GetStoreByID(ctx, store.ID)
 	if err != nil {
 		return nil, apperrors.NewInternalServerError("Failed to check if store exists")
 	}
@@ -29,7 +33,7 @@ func (s *StoreService) CreateStore(storeRequest *model.StoreRequest) (*model.Sto
 		return nil, apperrors.NewBadRequestError("Store already exists")
 	}*/
 	// call CreateStore method from repository
-	err := s.repo.CreateStore(store)
+	err := s.repo.CreateStore(ctx, store)
 	if err != nil {
 		return nil, apperrors.NewInternalServerError("Failed to create store")
 	}
@@ -39,9 +43,12 @@ func (s *StoreService) CreateStore(storeRequest *model.StoreRequest) (*model.Sto
 }
 
 // deleteStore deletes a store from the database using the store ID
-func (s *StoreService) DeleteStore(storeID uint) error {
+func (s *StoreService) DeleteStore(ctx context.Context, storeID uint) error {
 	// Check if the store exists
-	store, err := s.repo.GetStoreByID(storeID)
+	store, err := s.repo.GetStoreByID(ctx, storeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.NewNotFoundError("Store not found")
+	}
 	if err != nil {
 		return apperrors.NewInternalServerError("Failed to check if store exists")
 	}
@@ -49,9 +56,22 @@ func (s *StoreService) DeleteStore(storeID uint) error {
 		return apperrors.NewNotFoundError("Store not found")
 	}
 	// Call the DeleteStore method from the repository
-	err = s.repo.DeleteStore(storeID)
+	err = s.repo.DeleteStore(ctx, storeID)
 	if err != nil {
 		return apperrors.NewInternalServerError("Failed to delete store")
 	}
 	return nil
 }
+
+// RestoreStore brings back a deleted store; deleting only archives it, so the gateway can undo a
+// store deletion that failed in another service
+func (s *StoreService) RestoreStore(ctx context.Context, storeID uint) error {
+	found, err := s.repo.RestoreStore(ctx, storeID)
+	if err != nil {
+		return apperrors.NewInternalServerError("Failed to restore store")
+	}
+	if !found {
+		return apperrors.NewNotFoundError("Store not found")
+	}
+	return nil
+}
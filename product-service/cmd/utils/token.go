@@ -1,28 +0,0 @@
-package utils
-
-import (
-	"os"
-	"time"
-
-	"github.com/golang-jwt/jwt"
-)
-
-func GenerateToken(userID, storeID uint, role string) (string, error) {
-	// Create the Claims
-	claims := jwt.MapClaims{
-		"user_id":  userID,
-		"store_id": storeID,
-		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-		"iat":      time.Now().Unix(),
-	}
-	// create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign the token with the secret
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		return "", err
-	}
-	return tokenString, nil
-}